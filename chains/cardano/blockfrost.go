@@ -12,23 +12,25 @@ import (
 	"github.com/sisu-network/deyes/config"
 )
 
+const (
+	// BlockfrostPageSize is the maximum number of items in a page of the Blockfrost API.
+	BlockfrostPageSize = 100
+)
+
 type blockfrostProvider struct {
 	inner blockfrost.APIClient
 }
 
 func NewBlockfrostProvider(cfg config.Chain) Provider {
-	var chain string
-	switch cfg.Chain {
-	case "cardano-testnet":
-		chain = "preprod"
-	default:
-		panic("Unknown chain: " + cfg.Chain)
+	network, err := GetNetwork(cfg)
+	if err != nil {
+		panic(err)
 	}
 
 	return &blockfrostProvider{
 		inner: blockfrost.NewAPIClient(blockfrost.APIClientOptions{
 			ProjectID: cfg.RpcSecret,
-			Server:    fmt.Sprintf("https://cardano-%s.blockfrost.io/api/v0", chain),
+			Server:    network.BlockfrostUrl(),
 		}),
 	}
 }
@@ -49,9 +51,10 @@ func (b blockfrostProvider) BlockLatest(ctx context.Context) (*providertypes.Blo
 	}
 
 	return &providertypes.Block{
-		Height: block.Height,
-		Time:   block.Time,
-		Hash:   block.Hash,
+		Height:        block.Height,
+		Time:          block.Time,
+		Hash:          block.Hash,
+		PreviousBlock: block.PreviousBlock,
 	}, nil
 }
 
@@ -62,9 +65,10 @@ func (b blockfrostProvider) Block(ctx context.Context, hashOrNumber string) (*pr
 	}
 
 	return &providertypes.Block{
-		Height: block.Height,
-		Time:   block.Time,
-		Hash:   block.Hash,
+		Height:        block.Height,
+		Time:          block.Time,
+		Hash:          block.Hash,
+		PreviousBlock: block.PreviousBlock,
 	}, nil
 }
 
@@ -110,6 +114,12 @@ func (b blockfrostProvider) TransactionMetadata(ctx context.Context, hash string
 func (b blockfrostProvider) TransactionUTXOs(ctx context.Context, hash string) (*providertypes.TransactionUTXOs, error) {
 	transactionUTXOs, err := b.inner.TransactionUTXOs(ctx, hash)
 	if err != nil {
+		if apiErr, ok := err.(*blockfrost.APIError); ok {
+			if _, ok := apiErr.Response.(blockfrost.NotFound); ok {
+				return nil, TxNotFound
+			}
+		}
+
 		return nil, err
 	}
 
@@ -272,3 +282,80 @@ func (b blockfrostProvider) Tip(blockHeight uint64) (*cardano.NodeTip, error) {
 		Slot:  uint64(block.Slot),
 	}, nil
 }
+
+// BlockAddressOutputs queries the transactions of each address in the block page by page, so that
+// only the transactions sent to the addresses are fetched.
+func (b blockfrostProvider) BlockAddressOutputs(ctx context.Context, height string, addresses []string) ([]*providertypes.AddressOutput, error) {
+	res := make([]*providertypes.AddressOutput, 0)
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		for page := 1; ; page++ {
+			btxs, err := b.inner.AddressTransactions(ctx, address, blockfrost.APIQueryParams{
+				Count: BlockfrostPageSize,
+				Page:  page,
+				From:  height,
+				To:    height,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, btx := range btxs {
+				if seen[btx.TxHash] {
+					continue
+				}
+				seen[btx.TxHash] = true
+
+				outputs, err := b.txAddressOutputs(ctx, btx.TxHash, addresses)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, outputs...)
+			}
+
+			if len(btxs) < BlockfrostPageSize {
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (b blockfrostProvider) txAddressOutputs(ctx context.Context, hash string, addresses []string) ([]*providertypes.AddressOutput, error) {
+	utxos, err := b.TransactionUTXOs(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*providertypes.AddressOutput, 0)
+	for i, output := range utxos.Outputs {
+		for _, address := range addresses {
+			if output.Address == address {
+				res = append(res, &providertypes.AddressOutput{
+					TxHash:      hash,
+					OutputIndex: i,
+					Address:     output.Address,
+					Amount:      output.Amount,
+				})
+				break
+			}
+		}
+	}
+
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	// Transactions that only spend from the addresses do not need the metadata.
+	metadata, err := b.TransactionMetadata(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, output := range res {
+		output.Metadata = metadata
+	}
+
+	return res, nil
+}
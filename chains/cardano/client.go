@@ -3,11 +3,9 @@ package cardano
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"strings"
 	"sync"
 
 	"github.com/echovl/cardano-go"
@@ -28,6 +26,7 @@ type CardanoClient interface {
 	AddressUTXOs(ctx context.Context, address string, query providertypes.APIQueryParams) ([]cardano.UTxO, error)
 	Balance(address string, maxBlock int64) (*cardano.Value, error)
 	SubmitTx(tx *cardano.Tx) (*cardano.Hash32, error)
+	IsTxConfirmed(hash string) (bool, error)
 	Tip(blockHeight uint64) (*cardano.NodeTip, error)
 }
 
@@ -45,7 +44,19 @@ type Provider interface {
 	Block(ctx context.Context, hashOrNumber string) (*providertypes.Block, error)
 	AddressTransactions(ctx context.Context, address string, query providertypes.APIQueryParams) ([]*providertypes.AddressTransactions, error)
 	TransactionMetadata(ctx context.Context, hash string) ([]*providertypes.TransactionMetadata, error)
+	// TransactionUTXOs returns the outputs of a transaction on chain, or TxNotFound if the provider
+	// does not have the transaction.
 	TransactionUTXOs(ctx context.Context, hash string) (*providertypes.TransactionUTXOs, error)
+
+	// BlockAddressOutputs returns all outputs in a block sent to one of the addresses with the
+	// metadata of their transactions.
+	BlockAddressOutputs(ctx context.Context, height string, addresses []string) ([]*providertypes.AddressOutput, error)
+}
+
+// TxSubmitter is implemented by providers that submit transactions directly to a node instead of
+// a submit API.
+type TxSubmitter interface {
+	SubmitTx(ctx context.Context, tx []byte) (string, error)
 }
 
 const (
@@ -55,24 +66,38 @@ const (
 
 var (
 	MetadataNotFound = fmt.Errorf("Metadata not found")
+	TxNotFound       = fmt.Errorf("Transaction not found")
 )
 
+// MalformedMetadataError is returned when the metadata of a transaction cannot be decoded.
+type MalformedMetadataError struct {
+	message string
+}
+
+func NewMalformedMetadataError(message string) error {
+	return &MalformedMetadataError{message: message}
+}
+
+func (e *MalformedMetadataError) Error() string {
+	return e.message
+}
+
 // DefaultCardanoClient implements CardanoClient
 type DefaultCardanoClient struct {
-	inner       Provider
-	submitTxURL string
-	secret      string
+	inner        Provider
+	submitTxURLs []string
+	secret       string
 
 	// cache assets
 	policyAssets map[string]*cardano.Assets
 	lock         *sync.RWMutex
 }
 
-func NewDefaultCardanoClient(inner Provider, submitTxURL, secret string) *DefaultCardanoClient {
+func NewDefaultCardanoClient(inner Provider, submitTxURLs []string, secret string) *DefaultCardanoClient {
 	return &DefaultCardanoClient{
 		inner:        inner,
 		secret:       secret,
-		submitTxURL:  submitTxURL,
+		submitTxURLs: submitTxURLs,
 		policyAssets: make(map[string]*cardano.Assets),
 		lock:         &sync.RWMutex{},
 	}
@@ -120,60 +145,37 @@ func (b *DefaultCardanoClient) NewTxs(fromHeight int, vault string) ([]*types.Ca
 	}
 
 	txs := make([]*types.CardanoTransactionUtxo, 0)
+	if len(vault) == 0 {
+		return txs, nil
+	}
 
-	txHashes, err := b.inner.BlockTransactions(context.Background(), fmt.Sprintf("%d", fromHeight))
+	outputs, err := b.inner.BlockAddressOutputs(b.getContext(), fmt.Sprintf("%d", fromHeight), []string{vault})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, txHash := range txHashes {
-		utxos, err := b.inner.TransactionUTXOs(context.Background(), string(txHash))
-		if err != nil {
-			return nil, err
-		}
-
-		if !b.shouldIncludeTx(utxos, vault) {
-			continue
-		}
-
-		metadata, err := b.GetTransactionMetadata(string(txHash))
+	for _, output := range outputs {
+		metadataErr := ""
+		metadata, err := decodeTransactionMetadata(output.Metadata)
 		if err != nil && err != MetadataNotFound {
-			return nil, err
+			// Report the deposit with the error so that it can be refunded.
+			metadataErr = err.Error()
 		}
 
-		for i, output := range utxos.Outputs {
-			if strings.EqualFold(output.Address, vault) {
-				tx := &types.CardanoTransactionUtxo{
-					Hash:     string(txHash),
-					Index:    i,
-					Address:  output.Address,
-					Metadata: metadata,
-					Amount:   make([]providertypes.TxAmount, len(output.Amount)),
-				}
-
-				for j, amount := range output.Amount {
-					tx.Amount[j] = providertypes.TxAmount{
-						Quantity: amount.Quantity,
-						Unit:     amount.Unit,
-					}
-				}
-
-				txs = append(txs, tx)
-			}
+		tx := &types.CardanoTransactionUtxo{
+			Hash:          output.TxHash,
+			Index:         output.OutputIndex,
+			Address:       output.Address,
+			Metadata:      metadata,
+			MetadataError: metadataErr,
+			Amount:        make([]providertypes.TxAmount, len(output.Amount)),
 		}
-	}
-
-	return txs, nil
-}
+		copy(tx.Amount, output.Amount)
 
-func (b *DefaultCardanoClient) shouldIncludeTx(utxos *providertypes.TransactionUTXOs, vault string) bool {
-	for _, output := range utxos.Outputs {
-		if strings.EqualFold(output.Address, vault) {
-			return true
-		}
+		txs = append(txs, tx)
 	}
 
-	return false
+	return txs, nil
 }
 
 func (b *DefaultCardanoClient) GetTransactionMetadata(txHash string) (*types.CardanoTxMetadata, error) {
@@ -183,6 +185,12 @@ func (b *DefaultCardanoClient) GetTransactionMetadata(txHash string) (*types.Car
 		return nil, err
 	}
 
+	return decodeTransactionMetadata(txMetadata)
+}
+
+// decodeTransactionMetadata decodes the bridge metadata of a transaction. It returns a
+// MalformedMetadataError if the metadata does not follow our convention.
+func decodeTransactionMetadata(txMetadata []*providertypes.TransactionMetadata) (*types.CardanoTxMetadata, error) {
 	if len(txMetadata) == 0 {
 		return nil, MetadataNotFound
 	}
@@ -191,7 +199,7 @@ func (b *DefaultCardanoClient) GetTransactionMetadata(txHash string) (*types.Car
 	log.Debug("Label = ", txMetadata[0].Label)
 	txMetadatum, ok := txMetadata[0].JsonMetadata.(map[string]interface{})
 	if !ok {
-		err := fmt.Errorf("unknown tx metadatum type. Expected map[string]interface{}, got: %T", txMetadata[0].JsonMetadata)
+		err := NewMalformedMetadataError(fmt.Sprintf("unknown tx metadatum type. Expected map[string]interface{}, got: %T", txMetadata[0].JsonMetadata))
 		log.Error(err)
 		return nil, err
 	}
@@ -199,7 +207,7 @@ func (b *DefaultCardanoClient) GetTransactionMetadata(txHash string) (*types.Car
 	txAdditionInfo := &types.CardanoTxMetadata{}
 	if err := utils.MapToJSONStruct(txMetadatum, txAdditionInfo); err != nil {
 		log.Error(err)
-		return nil, err
+		return nil, NewMalformedMetadataError(fmt.Sprintf("cannot decode metadata: %v", err))
 	}
 
 	return txAdditionInfo, nil
@@ -249,35 +257,88 @@ func (b *DefaultCardanoClient) SubmitTx(tx *cardano.Tx) (*cardano.Hash32, error)
 	// Copy from this https://github.com/echovl/cardano-go/blob/4936c872fbb1f1db4bf04f1242fc180b0fe9843f/blockfrost/blockfrost.go#L124
 	txBytes := tx.Bytes()
 
-	url := b.submitTxURL
-	req, err := http.NewRequest("POST", url, bytes.NewReader(txBytes))
+	txHash, err := tx.Hash()
 	if err != nil {
 		return nil, err
 	}
 
-	// This header is only used for Blockfrost.io call
-	req.Header.Add("project_id", b.secret)
+	var lastErr error
+	if submitter, ok := b.inner.(TxSubmitter); ok {
+		_, err := submitter.SubmitTx(b.getContext(), txBytes)
+		if err == nil {
+			return &txHash, nil
+		}
+
+		log.Warnf("Failed to submit cardano tx %s with the provider, err = %v", txHash, err)
+		if submitErr, ok := err.(*SubmitTxError); ok && submitErr.IsKnown() {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	// Try the submit endpoints in order until one accepts the transaction.
+	for _, url := range b.submitTxURLs {
+		err := b.submitTxToURL(url, txBytes)
+		if err == nil {
+			return &txHash, nil
+		}
+
+		log.Warnf("Failed to submit cardano tx %s to %s, err = %v", txHash, url, err)
+		if submitErr, ok := err.(*SubmitTxError); ok && submitErr.IsKnown() {
+			// The node rejected the transaction, other endpoints would reject it too.
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no submit endpoint is configured")
+	}
+
+	return nil, lastErr
+}
+
+// IsTxConfirmed implements CardanoClient. It returns true if the transaction is included in a
+// block known by the provider.
+func (b *DefaultCardanoClient) IsTxConfirmed(hash string) (bool, error) {
+	_, err := b.inner.TransactionUTXOs(b.getContext(), hash)
+	if err == TxNotFound {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (b *DefaultCardanoClient) submitTxToURL(url string, txBytes []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(txBytes))
+	if err != nil {
+		return err
+	}
+
+	// The Blockfrost key must not be sent to other submit endpoints.
+	if IsBlockfrostUrl(url) {
+		req.Header.Add("project_id", b.secret)
+	}
 	req.Header.Add("Content-Type", "application/cbor")
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		return nil, errors.New(string(respBody))
-	}
-
-	txHash, err := tx.Hash()
-	if err != nil {
-		return nil, err
+		return NewSubmitTxError(string(respBody))
 	}
 
-	return &txHash, nil
+	return nil
 }
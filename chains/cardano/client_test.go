@@ -0,0 +1,46 @@
+package cardano
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/echovl/cardano-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultCardanoClient_SubmitTxFallback(t *testing.T) {
+	receiver, err := cardano.NewAddress(testTestnetAddress)
+	require.Nil(t, err)
+	tx := &cardano.Tx{Body: cardano.TxBody{}}
+	tx.Body.Outputs = append(tx.Body.Outputs, cardano.NewTxOutput(receiver, cardano.NewValue(1000000)))
+
+	calls := make([]string, 0)
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "failed")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failed.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "ok")
+		require.Equal(t, "application/cbor", r.Header.Get("Content-Type"))
+		// The Blockfrost key is not sent to other endpoints.
+		require.Empty(t, r.Header.Get("project_id"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ok.Close()
+
+	client := NewDefaultCardanoClient(nil, []string{failed.URL, ok.URL}, "blockfrost_secret")
+	hash, err := client.SubmitTx(tx)
+	require.Nil(t, err)
+	expected, err := tx.Hash()
+	require.Nil(t, err)
+	require.Equal(t, expected, *hash)
+	require.Equal(t, []string{"failed", "ok"}, calls)
+
+	// All endpoints fail.
+	client = NewDefaultCardanoClient(nil, []string{failed.URL}, "")
+	_, err = client.SubmitTx(tx)
+	require.NotNil(t, err)
+}
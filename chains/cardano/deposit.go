@@ -0,0 +1,129 @@
+package cardano
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+// AssetWhitelistWildcard is a whitelist entry that accepts all native assets.
+const AssetWhitelistWildcard = "*"
+
+// assetWhitelist contains the native assets that the vault accepts. No asset is accepted if the
+// whitelist is empty.
+type assetWhitelist struct {
+	allowAll bool
+	policies map[string]bool
+	units    map[string]bool
+}
+
+func newAssetWhitelist(entries []string) *assetWhitelist {
+	whitelist := &assetWhitelist{
+		policies: make(map[string]bool),
+		units:    make(map[string]bool),
+	}
+
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		parts := strings.Split(entry, ".")
+		switch {
+		case entry == AssetWhitelistWildcard:
+			whitelist.allowAll = true
+		case len(parts) == 1 && len(entry) == providertypes.PolicyIdLength:
+			whitelist.policies[entry] = true
+		case len(parts) == 2 && len(parts[0]) == providertypes.PolicyIdLength:
+			whitelist.units[parts[0]+parts[1]] = true
+		default:
+			log.Errorf("Invalid cardano asset whitelist entry %s", entry)
+		}
+	}
+
+	return whitelist
+}
+
+func (wl *assetWhitelist) isAllowed(asset *providertypes.Asset) bool {
+	return wl.allowAll || wl.policies[asset.PolicyId] || wl.units[asset.PolicyId+asset.AssetName]
+}
+
+// parseDeposit decodes the amounts of a transaction output into lovelace and native assets and
+// validates the metadata. Malformed deposits are flagged with MetadataError instead of being
+// dropped so that Sisu can refund them.
+func parseDeposit(txIn *types.CardanoTransactionUtxo, whitelist *assetWhitelist) {
+	lovelace := big.NewInt(0)
+	for _, amount := range txIn.Amount {
+		if amount.Unit == UnitLovelace {
+			quantity, ok := new(big.Int).SetString(amount.Quantity, 10)
+			if ok {
+				lovelace.Add(lovelace, quantity)
+			}
+			continue
+		}
+
+		policyId, assetName, err := providertypes.ParseUnit(strings.ToLower(amount.Unit))
+		if err != nil {
+			log.Warnf("Invalid asset unit in tx %s, err = %v", txIn.Hash, err)
+			continue
+		}
+
+		asset := &providertypes.Asset{
+			Unit:      amount.Unit,
+			PolicyId:  policyId,
+			AssetName: assetName,
+			Quantity:  amount.Quantity,
+		}
+
+		if whitelist.isAllowed(asset) {
+			txIn.Assets = append(txIn.Assets, asset)
+		} else {
+			txIn.RejectedAssets = append(txIn.RejectedAssets, asset)
+		}
+	}
+	txIn.Lovelace = lovelace.String()
+
+	if len(txIn.MetadataError) > 0 {
+		// The metadata cannot be decoded.
+		return
+	}
+
+	if err := validateMetadata(txIn); err != nil {
+		log.Warnf("Cardano tx %s has invalid metadata, err = %v", txIn.Hash, err)
+		txIn.MetadataError = err.Error()
+	}
+}
+
+// validateMetadata checks the metadata of a deposit. NativeAda must be 1 if the user transfers ADA,
+// otherwise the deposit must contain at least one accepted native asset.
+func validateMetadata(txIn *types.CardanoTransactionUtxo) error {
+	metadata := txIn.Metadata
+	if metadata == nil {
+		return fmt.Errorf("metadata is missing")
+	}
+
+	if len(metadata.Chain) == 0 {
+		return fmt.Errorf("destination chain is missing")
+	}
+
+	if len(metadata.Recipient) == 0 {
+		return fmt.Errorf("recipient is missing")
+	}
+
+	switch metadata.NativeAda {
+	case 0:
+		if len(txIn.Assets) == 0 {
+			return fmt.Errorf("native_ada is not set and there is no accepted native asset")
+		}
+	case 1:
+	default:
+		return fmt.Errorf("invalid native_ada value %d", metadata.NativeAda)
+	}
+
+	if len(txIn.RejectedAssets) > 0 {
+		return fmt.Errorf("%d native asset(s) are not accepted", len(txIn.RejectedAssets))
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package cardano
+
+import (
+	"strings"
+	"testing"
+
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testPolicy1 = strings.Repeat("a1", 28)
+	testPolicy2 = strings.Repeat("b2", 28)
+)
+
+func newTestDeposit(metadata *types.CardanoTxMetadata, amounts ...providertypes.TxAmount) *types.CardanoTransactionUtxo {
+	return &types.CardanoTransactionUtxo{
+		Hash:     "hash",
+		Metadata: metadata,
+		Amount:   amounts,
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	policyId, assetName, err := providertypes.ParseUnit(testPolicy1 + "74555344")
+	require.Nil(t, err)
+	require.Equal(t, testPolicy1, policyId)
+	require.Equal(t, "74555344", assetName)
+
+	// Asset with empty name.
+	policyId, assetName, err = providertypes.ParseUnit(testPolicy1)
+	require.Nil(t, err)
+	require.Equal(t, testPolicy1, policyId)
+	require.Equal(t, "", assetName)
+
+	_, _, err = providertypes.ParseUnit("abcd")
+	require.NotNil(t, err)
+
+	_, _, err = providertypes.ParseUnit(strings.Repeat("zz", 28))
+	require.NotNil(t, err)
+}
+
+func TestParseDeposit(t *testing.T) {
+	metadata := &types.CardanoTxMetadata{Chain: "ganache1", Recipient: "0x123"}
+
+	t.Run("native_asset_in_whitelist", func(t *testing.T) {
+		whitelist := newAssetWhitelist([]string{testPolicy1})
+		txIn := newTestDeposit(metadata,
+			providertypes.TxAmount{Unit: UnitLovelace, Quantity: "1500000"},
+			providertypes.TxAmount{Unit: testPolicy1 + "74555344", Quantity: "100"},
+		)
+
+		parseDeposit(txIn, whitelist)
+		require.Equal(t, "1500000", txIn.Lovelace)
+		require.Len(t, txIn.Assets, 1)
+		require.Equal(t, testPolicy1, txIn.Assets[0].PolicyId)
+		require.Equal(t, "74555344", txIn.Assets[0].AssetName)
+		require.Equal(t, "100", txIn.Assets[0].Quantity)
+		require.Empty(t, txIn.RejectedAssets)
+		require.Empty(t, txIn.MetadataError)
+	})
+
+	t.Run("native_asset_not_in_whitelist", func(t *testing.T) {
+		whitelist := newAssetWhitelist([]string{testPolicy1 + ".74555344"})
+		txIn := newTestDeposit(metadata,
+			providertypes.TxAmount{Unit: UnitLovelace, Quantity: "1500000"},
+			providertypes.TxAmount{Unit: testPolicy1 + "74555344", Quantity: "100"},
+			providertypes.TxAmount{Unit: testPolicy2, Quantity: "5"},
+		)
+
+		parseDeposit(txIn, whitelist)
+		require.Len(t, txIn.Assets, 1)
+		require.Len(t, txIn.RejectedAssets, 1)
+		require.Equal(t, testPolicy2, txIn.RejectedAssets[0].PolicyId)
+		require.NotEmpty(t, txIn.MetadataError)
+	})
+
+	t.Run("empty_whitelist_rejects_all", func(t *testing.T) {
+		txIn := newTestDeposit(metadata,
+			providertypes.TxAmount{Unit: UnitLovelace, Quantity: "1500000"},
+			providertypes.TxAmount{Unit: testPolicy2, Quantity: "5"},
+		)
+
+		parseDeposit(txIn, newAssetWhitelist(nil))
+		require.Empty(t, txIn.Assets)
+		require.Len(t, txIn.RejectedAssets, 1)
+		require.NotEmpty(t, txIn.MetadataError)
+	})
+
+	t.Run("wildcard_accepts_all", func(t *testing.T) {
+		txIn := newTestDeposit(metadata,
+			providertypes.TxAmount{Unit: UnitLovelace, Quantity: "1500000"},
+			providertypes.TxAmount{Unit: testPolicy2, Quantity: "5"},
+		)
+
+		parseDeposit(txIn, newAssetWhitelist([]string{AssetWhitelistWildcard}))
+		require.Len(t, txIn.Assets, 1)
+		require.Empty(t, txIn.MetadataError)
+	})
+
+	t.Run("native_ada", func(t *testing.T) {
+		txIn := newTestDeposit(&types.CardanoTxMetadata{Chain: "ganache1", Recipient: "0x123", NativeAda: 1},
+			providertypes.TxAmount{Unit: UnitLovelace, Quantity: "10000000"},
+		)
+
+		parseDeposit(txIn, newAssetWhitelist(nil))
+		require.Equal(t, "10000000", txIn.Lovelace)
+		require.Empty(t, txIn.Assets)
+		require.Empty(t, txIn.MetadataError)
+	})
+
+	t.Run("invalid_metadata", func(t *testing.T) {
+		// ADA only deposit without native_ada flag.
+		txIn := newTestDeposit(metadata, providertypes.TxAmount{Unit: UnitLovelace, Quantity: "10000000"})
+		parseDeposit(txIn, newAssetWhitelist(nil))
+		require.NotEmpty(t, txIn.MetadataError)
+
+		// Missing metadata.
+		txIn = newTestDeposit(nil, providertypes.TxAmount{Unit: UnitLovelace, Quantity: "10000000"})
+		parseDeposit(txIn, newAssetWhitelist(nil))
+		require.NotEmpty(t, txIn.MetadataError)
+
+		// Missing recipient.
+		txIn = newTestDeposit(&types.CardanoTxMetadata{Chain: "ganache1", NativeAda: 1},
+			providertypes.TxAmount{Unit: UnitLovelace, Quantity: "10000000"})
+		parseDeposit(txIn, newAssetWhitelist(nil))
+		require.NotEmpty(t, txIn.MetadataError)
+	})
+
+	t.Run("malformed_metadata_is_kept", func(t *testing.T) {
+		txIn := newTestDeposit(nil, providertypes.TxAmount{Unit: UnitLovelace, Quantity: "10000000"})
+		txIn.MetadataError = "cannot decode metadata"
+
+		parseDeposit(txIn, newAssetWhitelist(nil))
+		require.Equal(t, "cannot decode metadata", txIn.MetadataError)
+		require.Equal(t, "10000000", txIn.Lovelace)
+	})
+}
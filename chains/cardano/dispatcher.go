@@ -7,13 +7,19 @@ import (
 	"github.com/sisu-network/lib/log"
 )
 
+// SignedTxSubmitter submits signed transactions. The Watcher implements it to release the utxos
+// reserved for a transaction that is rejected.
+type SignedTxSubmitter interface {
+	SubmitTx(tx *cardano.Tx) (*cardano.Hash32, error)
+}
+
 type CardanoDispatcher struct {
-	client CardanoClient
+	submitter SignedTxSubmitter
 }
 
-func NewDispatcher(client CardanoClient) chains.Dispatcher {
+func NewDispatcher(submitter SignedTxSubmitter) chains.Dispatcher {
 	return &CardanoDispatcher{
-		client: client,
+		submitter: submitter,
 	}
 }
 
@@ -33,10 +39,35 @@ func (d *CardanoDispatcher) Dispatch(request *types.DispatchedTxRequest) *types.
 		txInput.Amount = nil
 	}
 
-	hash, err := d.client.SubmitTx(tx)
+	hash, err := d.submitter.SubmitTx(tx)
 	if err != nil {
-		log.Error("error when submitting tx: ", err)
-		return types.NewDispatchTxError(request, types.ErrSubmitTx)
+		submitErr, ok := err.(*SubmitTxError)
+		if !ok {
+			log.Error("error when submitting tx: ", err)
+			result := types.NewDispatchTxError(request, types.ErrSubmitTx)
+			result.Reason = err.Error()
+			return result
+		}
+
+		if !submitErr.AlreadySubmitted {
+			log.Errorf("tx is rejected, reason = %s, err = %v", submitErr.Reason, err)
+			result := types.NewDispatchTxError(request, submitErr.Code)
+			result.Reason = submitErr.Reason
+			if len(result.Reason) == 0 {
+				result.Reason = submitErr.Error()
+			}
+			return result
+		}
+
+		// Another node has submitted the same transaction. This is counted as a successful
+		// submission.
+		txHash, err := tx.Hash()
+		if err != nil {
+			return types.NewDispatchTxError(request, types.ErrMarshal)
+		}
+
+		log.Verbose("Cardano tx is already submitted, hash = ", txHash)
+		hash = &txHash
 	}
 
 	log.Verbose("Cardano tx hash = ", hash)
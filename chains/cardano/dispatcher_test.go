@@ -1,9 +1,15 @@
 package cardano
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/echovl/cardano-go"
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/types"
 	"github.com/stretchr/testify/require"
 )
@@ -39,3 +45,186 @@ func TestCardanoDispatcher_DeserializeTx(t *testing.T) {
 	require.True(t, result.Success)
 	require.Equal(t, result.TxHash, txHash.String())
 }
+
+func TestNewSubmitTxError(t *testing.T) {
+	tests := []struct {
+		message string
+		code    types.DispatchError
+		reason  string
+	}{
+		{
+			message: `{"status_code":400,"error":"Bad Request","message":"\"transaction submit error ShelleyTxValidationError ShelleyBasedEraBabbage (ApplyTxError [UtxowFailure (UtxoFailure (FromAlonzoUtxoFail (BadInputsUTxO (fromList [TxIn (TxId {_unTxId = SafeHash \\\"ab\\\"}) (TxIx 0)]))))])\""}`,
+			code:    types.ErrInputsSpent,
+			reason:  "BadInputsUTxO",
+		},
+		{
+			message: `[{"valueNotConserved":{"consumed":{"coins":10},"produced":{"coins":20}}}]`,
+			code:    types.ErrValueNotConserved,
+			reason:  "ValueNotConserved",
+		},
+		{
+			message: `ApplyTxError [UtxowFailure (UtxoFailure (FeeTooSmallUTxO (Coin 100) (Coin 10)))]`,
+			code:    types.ErrInsufficientFee,
+			reason:  "FeeTooSmall",
+		},
+		{
+			message: `[{"outsideOfValidityInterval":{"interval":{"invalidAfter":10},"currentSlot":20}}]`,
+			code:    types.ErrTxExpired,
+			reason:  "OutsideValidityInterval",
+		},
+		{
+			message: `Transaction is already in mempool`,
+			code:    types.ErrNil,
+			reason:  "AlreadySubmitted",
+		},
+		{
+			message: `Internal server error`,
+			code:    types.ErrSubmitTx,
+		},
+	}
+
+	for _, test := range tests {
+		err := NewSubmitTxError(test.message)
+		require.Equal(t, test.code, err.Code, test.message)
+		require.Equal(t, test.reason, err.Reason, test.message)
+		require.Equal(t, test.code == types.ErrNil, IsAlreadySubmitted(err))
+	}
+}
+
+func TestCardanoDispatcher_SubmitError(t *testing.T) {
+	receiver, err := cardano.NewAddress(testTestnetAddress)
+	require.Nil(t, err)
+	tx := &cardano.Tx{Body: cardano.TxBody{}}
+	tx.Body.Outputs = append(tx.Body.Outputs, cardano.NewTxOutput(receiver, cardano.NewValue(1000000)))
+	bz, err := tx.MarshalCBOR()
+	require.Nil(t, err)
+	txHash, err := tx.Hash()
+	require.Nil(t, err)
+
+	var submitErr error
+	client := &MockCardanoClient{}
+	client.SubmitTxFunc = func(tx *cardano.Tx) (*cardano.Hash32, error) {
+		return nil, submitErr
+	}
+	dispatcher := NewDispatcher(client)
+
+	// The node rejects the transaction.
+	submitErr = NewSubmitTxError("(FeeTooSmallUTxO (Coin 100) (Coin 10))")
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+	require.False(t, result.Success)
+	require.Equal(t, types.ErrInsufficientFee, result.Err)
+	require.Equal(t, "FeeTooSmall", result.Reason)
+
+	// Another node has submitted the transaction.
+	submitErr = NewSubmitTxError("already in mempool")
+	result = dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+	require.True(t, result.Success)
+	require.Equal(t, txHash.String(), result.TxHash)
+}
+
+func TestCardanoDispatcher_ResubmitConfirmedTx(t *testing.T) {
+	receiver, err := cardano.NewAddress(testTestnetAddress)
+	require.Nil(t, err)
+	tx := &cardano.Tx{Body: cardano.TxBody{}}
+	tx.Body.Outputs = append(tx.Body.Outputs, cardano.NewTxOutput(receiver, cardano.NewValue(1000000)))
+	bz, err := tx.MarshalCBOR()
+	require.Nil(t, err)
+	txHash, err := tx.Hash()
+	require.Nil(t, err)
+
+	confirmed := false
+	client := &MockCardanoClient{}
+	client.SubmitTxFunc = func(tx *cardano.Tx) (*cardano.Hash32, error) {
+		return nil, NewSubmitTxError("(BadInputsUTxO (fromList []))")
+	}
+	client.IsTxConfirmedFunc = func(hash string) (bool, error) {
+		require.Equal(t, txHash.String(), hash)
+		return confirmed, nil
+	}
+
+	db := getTestDb(t)
+	defer db.Close()
+
+	watcher := NewWatcher(config.Chain{Chain: "cardano-testnet"}, db, nil, nil,
+		make(chan *chainstypes.TrackUpdate, 10), client)
+	dispatcher := NewDispatcher(watcher)
+
+	// The inputs are spent by another transaction.
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+	require.False(t, result.Success)
+	require.Equal(t, types.ErrInputsSpent, result.Err)
+
+	// The inputs are spent by the transaction itself.
+	confirmed = true
+	result = dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+	require.True(t, result.Success)
+	require.Equal(t, txHash.String(), result.TxHash)
+}
+
+func TestCardanoDispatcher_ReleaseUtxos(t *testing.T) {
+	utxo := newTestUtxo(t, "01", 0, cardano.NewValue(3_000_000))
+	client := &MockCardanoClient{}
+	client.AddressUTXOsFunc = func(ctx context.Context, addr string, query providertypes.APIQueryParams) ([]cardano.UTxO, error) {
+		return []cardano.UTxO{utxo}, nil
+	}
+	var submitErr error
+	client.SubmitTxFunc = func(tx *cardano.Tx) (*cardano.Hash32, error) {
+		return nil, submitErr
+	}
+
+	db := getTestDb(t)
+	defer db.Close()
+
+	watcher := NewWatcher(config.Chain{Chain: "cardano-testnet"}, db, nil, nil,
+		make(chan *chainstypes.TrackUpdate, 10), client)
+	watcher.vault = testTestnetAddress
+	_, err := watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+
+	receiver, err := cardano.NewAddress(testTestnetAddress)
+	require.Nil(t, err)
+	tx := &cardano.Tx{Body: cardano.TxBody{}}
+	tx.Body.Inputs = append(tx.Body.Inputs, cardano.NewTxInput(utxo.TxHash, 0, nil))
+	tx.Body.Outputs = append(tx.Body.Outputs, cardano.NewTxOutput(receiver, cardano.NewValue(1000000)))
+	bz, err := tx.MarshalCBOR()
+	require.Nil(t, err)
+	txHash, err := tx.Hash()
+	require.Nil(t, err)
+
+	require.Nil(t, watcher.ReserveUtxos(txHash.String(), []string{UtxoId(utxo.TxHash.String(), 0)}, time.Minute))
+	available, err := watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Len(t, available, 0)
+
+	// The transaction may still be included after an unknown error so its utxos stay reserved.
+	dispatcher := NewDispatcher(watcher)
+	for _, submitErr = range []error{errors.New("timeout"), NewSubmitTxError("internal error")} {
+		result := dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+		require.False(t, result.Success)
+		require.Equal(t, types.ErrSubmitTx, result.Err)
+
+		available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+		require.Nil(t, err)
+		require.Len(t, available, 0)
+	}
+
+	// Spent inputs keep their reservation. The transaction may have been included by another node.
+	submitErr = NewSubmitTxError("(BadInputsUTxO (fromList []))")
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+	require.False(t, result.Success)
+	require.Equal(t, types.ErrInputsSpent, result.Err)
+
+	available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Len(t, available, 0)
+
+	// The reserved utxos are available again after the node rejects the transaction.
+	submitErr = NewSubmitTxError("(FeeTooSmallUTxO (Coin 100) (Coin 10))")
+	result = dispatcher.Dispatch(&types.DispatchedTxRequest{Tx: bz})
+	require.False(t, result.Success)
+	require.Equal(t, types.ErrInsufficientFee, result.Err)
+
+	available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Equal(t, []cardano.UTxO{utxo}, available)
+}
@@ -0,0 +1,343 @@
+package cardano
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/echovl/cardano-go"
+	"github.com/sisu-network/deyes/types"
+)
+
+var (
+	// TxTTLSlots is the number of slots after the tip before an estimated transaction expires.
+	TxTTLSlots = uint64(1200)
+)
+
+const (
+	// Constant overhead of a utxo entry in the min-UTXO formula (Babbage).
+	utxoEntryOverhead = 160
+	// MaxEstimateInputs is the maximum number of vault utxos used in one transaction.
+	MaxEstimateInputs = 100
+)
+
+// EstimateTx builds an unsigned transaction that sends the outputs from the vault. The vault's
+// utxos at maxBlock are selected to cover the outputs and the fee and the change is sent back to
+// the vault.
+func (w *Watcher) EstimateTx(outputs []*types.CardanoTxOutput, maxBlock uint64) (*types.CardanoEstimateTxResult, error) {
+	w.lock.RLock()
+	vault := w.vault
+	w.lock.RUnlock()
+
+	if len(vault) == 0 {
+		return nil, fmt.Errorf("vault is not set for chain %s", w.cfg.Chain)
+	}
+
+	txOutputs := make([]*cardano.TxOutput, 0, len(outputs))
+	for _, output := range outputs {
+		if err := w.network.ValidateAddress(output.Address); err != nil {
+			return nil, err
+		}
+
+		addr, err := cardano.NewAddress(output.Address)
+		if err != nil {
+			return nil, err
+		}
+
+		value := cardano.NewValue(0)
+		if err := value.UnmarshalCBOR(output.Amount); err != nil {
+			return nil, fmt.Errorf("invalid amount for output %s, err = %v", output.Address, err)
+		}
+
+		txOutputs = append(txOutputs, cardano.NewTxOutput(addr, value))
+	}
+
+	params, err := w.client.ProtocolParams()
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := w.CardanoUtxos(vault, maxBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	tip, err := w.client.Tip(maxBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	changeAddr, err := cardano.NewAddress(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	return estimateTx(params, utxos, txOutputs, changeAddr, tip.Slot+TxTTLSlots)
+}
+
+// estimateTx selects utxos for the outputs and computes the fee and the change of the transaction.
+// Outputs with less ADA than the min-UTXO are raised to the min-UTXO.
+func estimateTx(params *cardano.ProtocolParams, utxos []cardano.UTxO, outputs []*cardano.TxOutput,
+	changeAddr cardano.Address, ttl uint64) (*types.CardanoEstimateTxResult, error) {
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no output")
+	}
+
+	minUtxos := make([]uint64, len(outputs))
+	outputTotal := cardano.NewValue(0)
+	for i, output := range outputs {
+		minCoins, err := minUtxo(params, output)
+		if err != nil {
+			return nil, err
+		}
+
+		minUtxos[i] = uint64(minCoins)
+		if output.Amount.Coin < minCoins {
+			output.Amount.Coin = minCoins
+		}
+		outputTotal = outputTotal.Add(output.Amount)
+	}
+
+	candidates := sortUtxos(utxos)
+	selected := make([]cardano.UTxO, 0)
+
+	// Select utxos that cover the outputs.
+	for {
+		missing := outputTotal.Sub(sumUtxos(selected))
+		if missing.IsZero() {
+			break
+		}
+
+		index := pickUtxo(candidates, missing)
+		if index < 0 || len(selected) >= MaxEstimateInputs {
+			return nil, fmt.Errorf("not enough balance in vault, missing %v", missing)
+		}
+
+		selected = append(selected, candidates[index])
+		candidates = append(candidates[:index], candidates[index+1:]...)
+	}
+
+	// Add more utxos until they also cover the fee and the change.
+	for {
+		tx, fee, err := buildTx(params, selected, outputs, changeAddr, ttl)
+		if err == nil {
+			txHash, err := tx.Hash()
+			if err != nil {
+				return nil, err
+			}
+
+			return &types.CardanoEstimateTxResult{
+				Tx:       tx.Bytes(),
+				TxHash:   hex.EncodeToString(txHash[:]),
+				Fee:      uint64(fee),
+				MinUtxos: minUtxos,
+			}, nil
+		}
+
+		if len(candidates) == 0 || len(selected) >= MaxEstimateInputs {
+			return nil, err
+		}
+
+		selected = append(selected, candidates[0])
+		candidates = candidates[1:]
+	}
+}
+
+// buildTx builds the transaction with a change output for the inputs. The fee is computed for the
+// transaction signed by one key.
+func buildTx(params *cardano.ProtocolParams, inputs []cardano.UTxO, outputs []*cardano.TxOutput,
+	changeAddr cardano.Address, ttl uint64) (*cardano.Tx, cardano.Coin, error) {
+	inputTotal := sumUtxos(inputs)
+	outputTotal := cardano.NewValue(0)
+	for _, output := range outputs {
+		outputTotal = outputTotal.Add(output.Amount)
+	}
+
+	tx := &cardano.Tx{IsValid: true}
+	for _, utxo := range inputs {
+		tx.Body.Inputs = append(tx.Body.Inputs, cardano.NewTxInput(utxo.TxHash, uint(utxo.Index), utxo.Amount))
+	}
+	tx.Body.TTL = cardano.NewUint64(ttl)
+
+	change := cardano.NewTxOutput(changeAddr, cardano.NewValue(0))
+	tx.Body.Outputs = append([]*cardano.TxOutput{change}, outputs...)
+
+	// The fee depends on the size of the change which depends on the fee. A few rounds are enough
+	// for the fee to converge.
+	fee := cardano.Coin(0)
+	for i := 0; i < 3; i++ {
+		required := outputTotal.Add(cardano.NewValue(fee))
+		if cmp := inputTotal.Cmp(required); cmp == -1 || cmp == 2 {
+			return nil, 0, fmt.Errorf("insufficient input, got %v want %v", inputTotal, required)
+		}
+
+		change.Amount = trimValue(inputTotal.Sub(required))
+		tx.Body.Fee = fee
+		fee = minFee(params, tx)
+	}
+
+	required := outputTotal.Add(cardano.NewValue(fee))
+	if cmp := inputTotal.Cmp(required); cmp == -1 || cmp == 2 {
+		return nil, 0, fmt.Errorf("insufficient input, got %v want %v", inputTotal, required)
+	}
+	change.Amount = trimValue(inputTotal.Sub(required))
+
+	minChange, err := minUtxo(params, change)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if change.Amount.Coin < minChange {
+		if !change.Amount.OnlyCoin() {
+			return nil, 0, fmt.Errorf("insufficient ADA for change with native assets, got %d want %d",
+				change.Amount.Coin, minChange)
+		}
+
+		// The change is too small for an output. Burn it as fee.
+		tx.Body.Outputs = outputs
+		fee += change.Amount.Coin
+	}
+
+	tx.Body.Fee = fee
+
+	return tx, fee, nil
+}
+
+// minFee returns the linear fee of a transaction with one vkey witness.
+func minFee(params *cardano.ProtocolParams, tx *cardano.Tx) cardano.Coin {
+	signed := *tx
+	signed.WitnessSet = cardano.WitnessSet{
+		VKeyWitnessSet: []cardano.VKeyWitness{{VKey: make([]byte, 32), Signature: make([]byte, 64)}},
+	}
+
+	return params.MinFeeA*cardano.Coin(len(signed.Bytes())) + params.MinFeeB
+}
+
+// minUtxo returns the min ADA of an output. Since Babbage, CoinsPerUTXOWord holds the coins per
+// utxo byte parameter.
+func minUtxo(params *cardano.ProtocolParams, output *cardano.TxOutput) (cardano.Coin, error) {
+	value, err := output.Amount.MarshalCBOR()
+	if err != nil {
+		return 0, err
+	}
+
+	// An output is encoded as an array of the address bytes and the value.
+	addr := output.Address.Bytes()
+	size := 1 + cborBytesHeaderLength(len(addr)) + len(addr) + len(value)
+
+	return cardano.Coin(utxoEntryOverhead+size) * params.CoinsPerUTXOWord, nil
+}
+
+func cborBytesHeaderLength(length int) int {
+	switch {
+	case length < 24:
+		return 1
+	case length < 256:
+		return 2
+	case length < 65536:
+		return 3
+	default:
+		return 5
+	}
+}
+
+// trimValue removes the native assets with zero amount that Value.Sub leaves behind. They must not
+// be in a transaction output.
+func trimValue(value *cardano.Value) *cardano.Value {
+	multiAsset := cardano.NewMultiAsset()
+	for _, policy := range value.MultiAsset.Keys() {
+		assets := value.MultiAsset.Get(policy)
+		trimmed := cardano.NewAssets()
+		for _, name := range assets.Keys() {
+			if amount := assets.Get(name); amount > 0 {
+				trimmed.Set(name, amount)
+			}
+		}
+
+		if len(trimmed.Keys()) > 0 {
+			multiAsset.Set(policy, trimmed)
+		}
+	}
+
+	return cardano.NewValueWithAssets(value.Coin, multiAsset)
+}
+
+func sumUtxos(utxos []cardano.UTxO) *cardano.Value {
+	total := cardano.NewValue(0)
+	for _, utxo := range utxos {
+		total = total.Add(utxo.Amount)
+	}
+
+	return total
+}
+
+// sortUtxos sorts utxos by ADA amount (largest first) so that the selection is deterministic
+// across nodes.
+func sortUtxos(utxos []cardano.UTxO) []cardano.UTxO {
+	sorted := make([]cardano.UTxO, len(utxos))
+	copy(sorted, utxos)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Amount.Coin != sorted[j].Amount.Coin {
+			return sorted[i].Amount.Coin > sorted[j].Amount.Coin
+		}
+
+		if cmp := bytes.Compare(sorted[i].TxHash, sorted[j].TxHash); cmp != 0 {
+			return cmp < 0
+		}
+
+		return sorted[i].Index < sorted[j].Index
+	})
+
+	return sorted
+}
+
+// pickUtxo returns the index of the candidate that best covers the missing value: the one with
+// the most of the first missing native asset, or the first (largest) one if only ADA is missing.
+func pickUtxo(candidates []cardano.UTxO, missing *cardano.Value) int {
+	policy, asset, ok := firstAsset(missing)
+	if !ok {
+		if len(candidates) == 0 {
+			return -1
+		}
+		return 0
+	}
+
+	best := -1
+	bestAmount := cardano.BigNum(0)
+	for i, utxo := range candidates {
+		assets := utxo.Amount.MultiAsset.Get(policy)
+		if assets == nil {
+			continue
+		}
+
+		if amount := assets.Get(asset); amount > bestAmount {
+			best = i
+			bestAmount = amount
+		}
+	}
+
+	return best
+}
+
+// firstAsset returns the native asset with a non zero amount that comes first in byte order.
+func firstAsset(value *cardano.Value) (cardano.PolicyID, cardano.AssetName, bool) {
+	if value.MultiAsset == nil {
+		return cardano.PolicyID{}, cardano.AssetName{}, false
+	}
+
+	policies := value.MultiAsset.Keys()
+	sort.Slice(policies, func(i, j int) bool { return policies[i].String() < policies[j].String() })
+	for _, policy := range policies {
+		assets := value.MultiAsset.Get(policy)
+		names := assets.Keys()
+		sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+		for _, name := range names {
+			if assets.Get(name) > 0 {
+				return policy, name, true
+			}
+		}
+	}
+
+	return cardano.PolicyID{}, cardano.AssetName{}, false
+}
@@ -0,0 +1,121 @@
+package cardano
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/echovl/cardano-go"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUtxo(t *testing.T, hashByte string, index uint64, amount *cardano.Value) cardano.UTxO {
+	hash, err := cardano.NewHash32(strings.Repeat(hashByte, 32))
+	require.Nil(t, err)
+
+	return cardano.UTxO{TxHash: hash, Index: index, Amount: amount}
+}
+
+func newTestAssetValue(t *testing.T, coin cardano.Coin, amount cardano.BigNum) *cardano.Value {
+	hash, err := cardano.NewHash28(testPolicy1)
+	require.Nil(t, err)
+
+	assets := cardano.NewAssets().Set(cardano.NewAssetName("uUSD"), amount)
+	multiAsset := cardano.NewMultiAsset().Set(cardano.NewPolicyIDFromHash(hash), assets)
+
+	return cardano.NewValueWithAssets(coin, multiAsset)
+}
+
+func newTestParams() *cardano.ProtocolParams {
+	return &cardano.ProtocolParams{
+		MinFeeA:          44,
+		MinFeeB:          155381,
+		CoinsPerUTXOWord: 4310,
+	}
+}
+
+func TestEstimateTx(t *testing.T) {
+	addr, err := cardano.NewAddress(testTestnetAddress)
+	require.Nil(t, err)
+
+	t.Run("ada_only", func(t *testing.T) {
+		utxos := []cardano.UTxO{
+			newTestUtxo(t, "01", 0, cardano.NewValue(3_000_000)),
+			newTestUtxo(t, "02", 0, cardano.NewValue(10_000_000)),
+		}
+		outputs := []*cardano.TxOutput{cardano.NewTxOutput(addr, cardano.NewValue(5_000_000))}
+
+		result, err := estimateTx(newTestParams(), utxos, outputs, addr, 1000)
+		require.Nil(t, err)
+
+		tx := &cardano.Tx{}
+		require.Nil(t, tx.UnmarshalCBOR(result.Tx))
+		require.Empty(t, tx.WitnessSet.VKeyWitnessSet)
+
+		// Only the largest utxo is used.
+		require.Len(t, tx.Body.Inputs, 1)
+		require.Len(t, tx.Body.Outputs, 2)
+		require.Equal(t, cardano.Coin(result.Fee), tx.Body.Fee)
+		require.Equal(t, cardano.Coin(10_000_000-5_000_000)-tx.Body.Fee, tx.Body.Outputs[0].Amount.Coin)
+		require.Equal(t, uint64(1000), uint64(*tx.Body.TTL))
+
+		// The fee covers the transaction with a signature.
+		require.GreaterOrEqual(t, tx.Body.Fee, minFee(newTestParams(), tx))
+
+		txHash, err := tx.Hash()
+		require.Nil(t, err)
+		require.Equal(t, txHash.String(), result.TxHash)
+	})
+
+	t.Run("min_utxo", func(t *testing.T) {
+		utxos := []cardano.UTxO{newTestUtxo(t, "01", 0, cardano.NewValue(10_000_000))}
+		outputs := []*cardano.TxOutput{cardano.NewTxOutput(addr, cardano.NewValue(1))}
+
+		result, err := estimateTx(newTestParams(), utxos, outputs, addr, 1000)
+		require.Nil(t, err)
+
+		tx := &cardano.Tx{}
+		require.Nil(t, tx.UnmarshalCBOR(result.Tx))
+		require.Equal(t, cardano.Coin(result.MinUtxos[0]), tx.Body.Outputs[1].Amount.Coin)
+	})
+
+	t.Run("native_asset", func(t *testing.T) {
+		utxos := []cardano.UTxO{
+			newTestUtxo(t, "01", 0, cardano.NewValue(20_000_000)),
+			newTestUtxo(t, "02", 0, newTestAssetValue(t, 2_000_000, 100)),
+		}
+		outputs := []*cardano.TxOutput{cardano.NewTxOutput(addr, newTestAssetValue(t, 1_500_000, 40))}
+
+		result, err := estimateTx(newTestParams(), utxos, outputs, addr, 1000)
+		require.Nil(t, err)
+
+		tx := &cardano.Tx{}
+		require.Nil(t, tx.UnmarshalCBOR(result.Tx))
+		require.Len(t, tx.Body.Inputs, 2)
+
+		// The remaining tokens are sent back to the vault.
+		change := tx.Body.Outputs[0].Amount
+		require.Equal(t, 0, change.Cmp(newTestAssetValue(t, 22_000_000-1_500_000-tx.Body.Fee, 60)))
+	})
+
+	t.Run("dust_change", func(t *testing.T) {
+		utxos := []cardano.UTxO{newTestUtxo(t, "01", 0, cardano.NewValue(5_300_000))}
+		outputs := []*cardano.TxOutput{cardano.NewTxOutput(addr, cardano.NewValue(5_000_000))}
+
+		result, err := estimateTx(newTestParams(), utxos, outputs, addr, 1000)
+		require.Nil(t, err)
+
+		// The change is below the min-UTXO and is added to the fee.
+		tx := &cardano.Tx{}
+		require.Nil(t, tx.UnmarshalCBOR(result.Tx))
+		require.Len(t, tx.Body.Outputs, 1)
+		require.Equal(t, uint64(300_000), result.Fee)
+	})
+
+	t.Run("insufficient_balance", func(t *testing.T) {
+		utxos := []cardano.UTxO{newTestUtxo(t, "01", 0, cardano.NewValue(3_000_000))}
+		outputs := []*cardano.TxOutput{cardano.NewTxOutput(addr, newTestAssetValue(t, 2_000_000, 1))}
+
+		_, err := estimateTx(newTestParams(), utxos, outputs, addr, 1000)
+		require.NotNil(t, err)
+	})
+}
@@ -19,6 +19,7 @@ type MockCardanoClient struct {
 	AddressUTXOsFunc   func(ctx context.Context, address string, query providertypes.APIQueryParams) ([]cardano.UTxO, error)
 	BalanceFunc        func(address string, maxBlock int64) (*cardano.Value, error)
 	TipFunc            func(blockHeight uint64) (*cardano.NodeTip, error)
+	IsTxConfirmedFunc  func(hash string) (bool, error)
 }
 
 func (c *MockCardanoClient) IsHealthy() bool {
@@ -100,3 +101,11 @@ func (c *MockCardanoClient) Tip(blockHeight uint64) (*cardano.NodeTip, error) {
 
 	return nil, nil
 }
+
+func (c *MockCardanoClient) IsTxConfirmed(hash string) (bool, error) {
+	if c.IsTxConfirmedFunc != nil {
+		return c.IsTxConfirmedFunc(hash)
+	}
+
+	return false, nil
+}
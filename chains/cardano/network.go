@@ -0,0 +1,98 @@
+package cardano
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/echovl/cardano-go"
+	"github.com/sisu-network/deyes/config"
+)
+
+type Network string
+
+const (
+	NetworkMainnet Network = "mainnet"
+	NetworkPreprod Network = "preprod"
+	NetworkPreview Network = "preview"
+)
+
+// GetNetwork returns the Cardano network of a chain. If the network is not configured, it is
+// derived from the chain name for backward compatibility.
+func GetNetwork(cfg config.Chain) (Network, error) {
+	switch Network(cfg.CardanoNetwork) {
+	case NetworkMainnet, NetworkPreprod, NetworkPreview:
+		return Network(cfg.CardanoNetwork), nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown cardano network %s", cfg.CardanoNetwork)
+	}
+
+	switch cfg.Chain {
+	case "cardano-mainnet":
+		return NetworkMainnet, nil
+	case "cardano-testnet":
+		return NetworkPreprod, nil
+	}
+
+	return "", fmt.Errorf("cardano network is not set for chain %s", cfg.Chain)
+}
+
+func (n Network) IsMainnet() bool {
+	return n == NetworkMainnet
+}
+
+// BlockfrostUrl returns the base url of the Blockfrost API for this network.
+func (n Network) BlockfrostUrl() string {
+	return fmt.Sprintf("https://cardano-%s.blockfrost.io/api/v0", n)
+}
+
+func (n Network) BlockfrostSubmitUrl() string {
+	return n.BlockfrostUrl() + "/tx/submit"
+}
+
+// IsBlockfrostUrl returns true if the url is an endpoint of the Blockfrost API.
+func IsBlockfrostUrl(rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+	return host == "blockfrost.io" || strings.HasSuffix(host, ".blockfrost.io")
+}
+
+// ValidateAddress checks that an address is a valid Cardano address of this network.
+func (n Network) ValidateAddress(address string) error {
+	addr, err := cardano.NewAddress(address)
+	if err != nil {
+		return fmt.Errorf("invalid cardano address %s, err = %v", address, err)
+	}
+
+	if (addr.Network == cardano.Mainnet) != n.IsMainnet() {
+		return fmt.Errorf("address %s is not a %s address", address, n)
+	}
+
+	return nil
+}
+
+// GetSubmitUrls returns the endpoints used to submit transactions, in order of preference.
+func GetSubmitUrls(cfg config.Chain, network Network) []string {
+	urls := make([]string, 0)
+	switch cfg.ClientType {
+	case config.ClientTypeBlockFrost:
+		urls = append(urls, network.BlockfrostSubmitUrl())
+	case config.ClientTypeSelfHost:
+		if len(cfg.SyncDB.SubmitURL) > 0 {
+			urls = append(urls, cfg.SyncDB.SubmitURL)
+		}
+	}
+
+	for _, url := range cfg.CardanoSubmitUrls {
+		if len(url) > 0 {
+			urls = append(urls, url)
+		}
+	}
+
+	return urls
+}
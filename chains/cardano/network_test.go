@@ -0,0 +1,76 @@
+package cardano
+
+import (
+	"testing"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testMainnetAddress = "addr1vx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzers66hrl8"
+	testTestnetAddress = "addr_test1vqyqp03az6w8xuknzpfup3h7ghjwu26z7xa6gk7l9j7j2gs8zfwcy"
+)
+
+func TestGetNetwork(t *testing.T) {
+	network, err := GetNetwork(config.Chain{Chain: "cardano", CardanoNetwork: "mainnet"})
+	require.Nil(t, err)
+	require.Equal(t, NetworkMainnet, network)
+	require.Equal(t, "https://cardano-mainnet.blockfrost.io/api/v0", network.BlockfrostUrl())
+
+	network, err = GetNetwork(config.Chain{Chain: "cardano", CardanoNetwork: "preview"})
+	require.Nil(t, err)
+	require.Equal(t, "https://cardano-preview.blockfrost.io/api/v0/tx/submit", network.BlockfrostSubmitUrl())
+
+	// Default network from the chain name.
+	network, err = GetNetwork(config.Chain{Chain: "cardano-testnet"})
+	require.Nil(t, err)
+	require.Equal(t, NetworkPreprod, network)
+
+	_, err = GetNetwork(config.Chain{Chain: "cardano"})
+	require.NotNil(t, err)
+
+	_, err = GetNetwork(config.Chain{Chain: "cardano", CardanoNetwork: "testnet"})
+	require.NotNil(t, err)
+}
+
+func TestNetwork_ValidateAddress(t *testing.T) {
+	require.Nil(t, NetworkMainnet.ValidateAddress(testMainnetAddress))
+	require.NotNil(t, NetworkMainnet.ValidateAddress(testTestnetAddress))
+
+	require.Nil(t, NetworkPreprod.ValidateAddress(testTestnetAddress))
+	require.Nil(t, NetworkPreview.ValidateAddress(testTestnetAddress))
+	require.NotNil(t, NetworkPreprod.ValidateAddress(testMainnetAddress))
+
+	require.NotNil(t, NetworkPreprod.ValidateAddress("0x123"))
+}
+
+func TestGetSubmitUrls(t *testing.T) {
+	cfg := config.Chain{
+		ClientType:        config.ClientTypeBlockFrost,
+		CardanoSubmitUrls: []string{"http://localhost:8090/api/submit/tx"},
+	}
+	require.Equal(t, []string{
+		"https://cardano-mainnet.blockfrost.io/api/v0/tx/submit",
+		"http://localhost:8090/api/submit/tx",
+	}, GetSubmitUrls(cfg, NetworkMainnet))
+
+	cfg = config.Chain{
+		ClientType: config.ClientTypeSelfHost,
+		SyncDB:     config.SyncDbConfig{SubmitURL: "http://localhost:8090/api/submit/tx"},
+	}
+	require.Equal(t, []string{"http://localhost:8090/api/submit/tx"}, GetSubmitUrls(cfg, NetworkPreprod))
+
+	cfg = config.Chain{ClientType: config.ClientTypeOgmios}
+	require.Empty(t, GetSubmitUrls(cfg, NetworkPreprod))
+}
+
+func TestIsBlockfrostUrl(t *testing.T) {
+	require.True(t, IsBlockfrostUrl(NetworkMainnet.BlockfrostSubmitUrl()))
+	require.True(t, IsBlockfrostUrl(NetworkPreview.BlockfrostSubmitUrl()))
+
+	require.False(t, IsBlockfrostUrl("http://localhost:8090/api/submit/tx"))
+	require.False(t, IsBlockfrostUrl("https://submit.example.com/blockfrost.io/tx/submit"))
+	require.False(t, IsBlockfrostUrl("https://blockfrost.io.example.com/api/v0/tx/submit"))
+	require.False(t, IsBlockfrostUrl("http://cardano-mainnet.blockfrost.io/api/v0/tx/submit"))
+}
@@ -0,0 +1,913 @@
+package cardano
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/echovl/cardano-go"
+	"github.com/gorilla/websocket"
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/lib/log"
+)
+
+var _ Provider = (*OgmiosProvider)(nil)
+var _ TxSubmitter = (*OgmiosProvider)(nil)
+
+var (
+	// OgmiosCacheSize is the number of recent blocks indexed by the Ogmios provider.
+	OgmiosCacheSize = 1000
+	// OgmiosPipelineSize is the number of chain-sync requests sent in advance.
+	OgmiosPipelineSize = 50
+	// OgmiosReconnectDelay is the delay before reconnecting after a chain-sync error.
+	OgmiosReconnectDelay = time.Second * 5
+
+	// OgmiosSupportedVersion is the major version of Ogmios supported by the provider. Ogmios v6
+	// replaced the JSON-WSP protocol with JSON-RPC 2.0 and is not supported.
+	OgmiosSupportedVersion = "v5"
+
+	OgmiosBlockNotFound = fmt.Errorf("Block Not Found")
+	// OgmiosTxNotFound is returned for transactions that are not in the indexed blocks.
+	OgmiosTxNotFound = TxNotFound
+)
+
+type ogmiosIndexedBlock struct {
+	block    *providertypes.Block
+	txHashes []string
+}
+
+type ogmiosIndexedTx struct {
+	utxos    *providertypes.TransactionUTXOs
+	metadata []*providertypes.TransactionMetadata
+}
+
+// OgmiosProvider reads the chain directly from a cardano-node through Ogmios. Blocks are followed
+// with the chain-sync protocol and the most recent ones are indexed in memory. UTXOs, protocol
+// parameters and the tip are read with the local-state-query protocol.
+//
+// The provider uses the JSON-WSP protocol of Ogmios v5. The version of the server is checked before
+// each connection and other versions are refused.
+type OgmiosProvider struct {
+	url string
+
+	// Connection used for local-state-query and tx submission.
+	conn     *websocket.Conn
+	connLock *sync.Mutex
+
+	blocks       map[int]*ogmiosIndexedBlock
+	blockHeights []int
+	txs          map[string]*ogmiosIndexedTx
+	lock         *sync.RWMutex
+}
+
+func NewOgmiosProvider(cfg config.Chain) *OgmiosProvider {
+	p := newOgmiosProvider(cfg.OgmiosUrl)
+	go p.chainSync()
+
+	return p
+}
+
+func newOgmiosProvider(url string) *OgmiosProvider {
+	return &OgmiosProvider{
+		url:      url,
+		connLock: &sync.Mutex{},
+		blocks:   make(map[int]*ogmiosIndexedBlock),
+		txs:      make(map[string]*ogmiosIndexedTx),
+		lock:     &sync.RWMutex{},
+	}
+}
+
+func newOgmiosRequest(method string, args interface{}) *providertypes.OgmiosRequest {
+	return &providertypes.OgmiosRequest{
+		Type:        "jsonwsp/request",
+		Version:     "1.0",
+		ServiceName: "ogmios",
+		MethodName:  method,
+		Args:        args,
+	}
+}
+
+func readOgmiosResponse(conn *websocket.Conn, result interface{}) error {
+	response := &providertypes.OgmiosResponse{}
+	if err := conn.ReadJSON(response); err != nil {
+		return err
+	}
+
+	if response.Fault != nil {
+		return fmt.Errorf("ogmios fault %s: %s", response.Fault.Code, response.Fault.String)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+// dial checks the version of the Ogmios server and opens a websocket connection.
+func (p *OgmiosProvider) dial() (*websocket.Conn, error) {
+	version, err := p.getVersion()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get ogmios version: %w", err)
+	}
+
+	if !strings.HasPrefix(version, OgmiosSupportedVersion+".") {
+		return nil, fmt.Errorf("ogmios version %s is not supported, expected %s", version, OgmiosSupportedVersion)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(p.url, nil)
+
+	return conn, err
+}
+
+// getVersion returns the version of the Ogmios server from its health endpoint.
+func (p *OgmiosProvider) getVersion() (string, error) {
+	healthUrl, err := url.Parse(p.url)
+	if err != nil {
+		return "", err
+	}
+
+	switch healthUrl.Scheme {
+	case "wss":
+		healthUrl.Scheme = "https"
+	default:
+		healthUrl.Scheme = "http"
+	}
+	healthUrl.Path = strings.TrimSuffix(healthUrl.Path, "/") + "/health"
+
+	resp, err := http.Get(healthUrl.String())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ogmios health returns status %d", resp.StatusCode)
+	}
+
+	health := &providertypes.OgmiosHealth{}
+	if err := json.NewDecoder(resp.Body).Decode(health); err != nil {
+		return "", err
+	}
+
+	return health.Version, nil
+}
+
+// call sends a request on the query connection and waits for its response.
+func (p *OgmiosProvider) call(method string, args interface{}, result interface{}) error {
+	p.connLock.Lock()
+	defer p.connLock.Unlock()
+
+	if p.conn == nil {
+		conn, err := p.dial()
+		if err != nil {
+			return err
+		}
+		p.conn = conn
+	}
+
+	err := p.conn.WriteJSON(newOgmiosRequest(method, args))
+	if err == nil {
+		err = readOgmiosResponse(p.conn, result)
+	}
+
+	if err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); !ok {
+			// The connection may be broken. Reconnect on next call.
+			p.conn.Close()
+			p.conn = nil
+		}
+	}
+
+	return err
+}
+
+// query runs a local-state-query. Queries that are not available in the current era return a
+// string instead of an object.
+func (p *OgmiosProvider) query(query interface{}, result interface{}) error {
+	raw := json.RawMessage{}
+	if err := p.call("Query", map[string]interface{}{"query": query}, &raw); err != nil {
+		return err
+	}
+
+	var msg string
+	if err := json.Unmarshal(raw, &msg); err == nil {
+		return fmt.Errorf("ogmios query %v failed: %s", query, msg)
+	}
+
+	var eraMismatch struct {
+		EraMismatch interface{} `json:"eraMismatch"`
+	}
+	if err := json.Unmarshal(raw, &eraMismatch); err == nil && eraMismatch.EraMismatch != nil {
+		return fmt.Errorf("ogmios query %v failed: era mismatch %v", query, eraMismatch.EraMismatch)
+	}
+
+	return json.Unmarshal(raw, result)
+}
+
+///// Chain sync
+
+func (p *OgmiosProvider) chainSync() {
+	for {
+		if err := p.syncBlocks(); err != nil {
+			log.Errorf("Ogmios chain sync failed, err = %v. Reconnecting...", err)
+		}
+
+		time.Sleep(OgmiosReconnectDelay)
+	}
+}
+
+// syncBlocks follows the chain from the last indexed block, or from the node tip if nothing has
+// been indexed.
+func (p *OgmiosProvider) syncBlocks() error {
+	conn, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := p.findIntersect(conn); err != nil {
+		return err
+	}
+
+	for i := 0; i < OgmiosPipelineSize; i++ {
+		if err := conn.WriteJSON(newOgmiosRequest("RequestNext", nil)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		result := &providertypes.OgmiosRequestNextResult{}
+		if err := readOgmiosResponse(conn, result); err != nil {
+			return err
+		}
+
+		if err := p.processNext(result); err != nil {
+			return err
+		}
+
+		if err := conn.WriteJSON(newOgmiosRequest("RequestNext", nil)); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *OgmiosProvider) findIntersect(conn *websocket.Conn) error {
+	points := p.getIntersectPoints()
+	if len(points) == 0 {
+		// Find the tip of the node by intersecting with the origin.
+		result := &providertypes.OgmiosFindIntersectResult{}
+		if err := p.intersect(conn, []interface{}{"origin"}, result); err != nil {
+			return err
+		}
+
+		if result.IntersectionFound == nil {
+			return fmt.Errorf("cannot find ogmios tip")
+		}
+
+		tip := &providertypes.OgmiosTip{}
+		if err := json.Unmarshal(result.IntersectionFound.Tip, tip); err != nil {
+			// The chain is at origin.
+			return nil
+		}
+
+		points = []interface{}{&providertypes.OgmiosPoint{Slot: tip.Slot, Hash: tip.Hash}}
+	}
+
+	result := &providertypes.OgmiosFindIntersectResult{}
+	if err := p.intersect(conn, points, result); err != nil {
+		return err
+	}
+
+	if result.IntersectionFound == nil {
+		// None of our blocks is on the chain anymore. Start again from the tip.
+		log.Warn("Ogmios intersection not found, clearing block cache")
+		p.clearBlocks()
+		return fmt.Errorf("intersection not found")
+	}
+
+	return nil
+}
+
+func (p *OgmiosProvider) intersect(conn *websocket.Conn, points []interface{}, result interface{}) error {
+	args := map[string]interface{}{"points": points}
+	if err := conn.WriteJSON(newOgmiosRequest("FindIntersect", args)); err != nil {
+		return err
+	}
+
+	return readOgmiosResponse(conn, result)
+}
+
+// getIntersectPoints returns a few recent indexed blocks, most recent first.
+func (p *OgmiosProvider) getIntersectPoints() []interface{} {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	points := make([]interface{}, 0)
+	for i := len(p.blockHeights) - 1; i >= 0 && len(points) < 10; i-- {
+		block := p.blocks[p.blockHeights[i]].block
+		points = append(points, &providertypes.OgmiosPoint{Slot: uint64(block.Slot), Hash: block.Hash})
+	}
+
+	return points
+}
+
+func (p *OgmiosProvider) processNext(result *providertypes.OgmiosRequestNextResult) error {
+	if result.RollBackward != nil {
+		point := &providertypes.OgmiosPoint{}
+		if err := json.Unmarshal(result.RollBackward.Point, point); err != nil {
+			// Rolled back to origin.
+			point.Slot = 0
+		}
+
+		p.rollback(point.Slot)
+		return nil
+	}
+
+	if result.RollForward == nil {
+		return fmt.Errorf("unknown chain sync result")
+	}
+
+	for era, raw := range result.RollForward.Block {
+		if era == "byron" {
+			// Byron blocks do not contain any transaction we are interested in.
+			continue
+		}
+
+		block := &providertypes.OgmiosBlock{}
+		if err := json.Unmarshal(raw, block); err != nil {
+			return err
+		}
+
+		p.addBlock(block)
+	}
+
+	return nil
+}
+
+func (p *OgmiosProvider) addBlock(block *providertypes.OgmiosBlock) {
+	height := int(block.Header.BlockHeight)
+	indexed := &ogmiosIndexedBlock{
+		block: &providertypes.Block{
+			Height:        height,
+			Hash:          block.HeaderHash,
+			PreviousBlock: block.Header.PrevHash,
+			Slot:          int(block.Header.Slot),
+		},
+		txHashes: make([]string, 0, len(block.Body)),
+	}
+
+	txs := make(map[string]*ogmiosIndexedTx)
+	for _, tx := range block.Body {
+		indexed.txHashes = append(indexed.txHashes, tx.Id)
+		txs[tx.Id] = &ogmiosIndexedTx{
+			utxos:    convertOgmiosOutputs(tx),
+			metadata: convertOgmiosMetadata(tx),
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, ok := p.blocks[height]; ok {
+		// A block at the same height was replaced without a rollback.
+		p.removeBlock(height)
+	}
+
+	p.blocks[height] = indexed
+	p.blockHeights = append(p.blockHeights, height)
+	for hash, tx := range txs {
+		p.txs[hash] = tx
+	}
+
+	for len(p.blockHeights) > OgmiosCacheSize {
+		p.removeBlock(p.blockHeights[0])
+	}
+}
+
+// removeBlock removes a block from the index. The caller must hold the lock.
+func (p *OgmiosProvider) removeBlock(height int) {
+	block, ok := p.blocks[height]
+	if !ok {
+		return
+	}
+
+	for _, hash := range block.txHashes {
+		delete(p.txs, hash)
+	}
+	delete(p.blocks, height)
+
+	for i, h := range p.blockHeights {
+		if h == height {
+			p.blockHeights = append(p.blockHeights[:i], p.blockHeights[i+1:]...)
+			break
+		}
+	}
+}
+
+// rollback removes all blocks after a slot.
+func (p *OgmiosProvider) rollback(slot uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i := len(p.blockHeights) - 1; i >= 0; i-- {
+		height := p.blockHeights[i]
+		if uint64(p.blocks[height].block.Slot) <= slot {
+			break
+		}
+
+		log.Infof("Ogmios rollback: removing block %d at slot %d", height, p.blocks[height].block.Slot)
+		p.removeBlock(height)
+	}
+}
+
+func (p *OgmiosProvider) clearBlocks() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.blocks = make(map[int]*ogmiosIndexedBlock)
+	p.blockHeights = nil
+	p.txs = make(map[string]*ogmiosIndexedTx)
+}
+
+func convertOgmiosOutputs(tx *providertypes.OgmiosTx) *providertypes.TransactionUTXOs {
+	outputs := make([]providertypes.TransactionUTXOsOutput, 0, len(tx.Body.Outputs))
+	for _, out := range tx.Body.Outputs {
+		outputs = append(outputs, providertypes.TransactionUTXOsOutput{
+			Address: out.Address,
+			Amount:  convertOgmiosValue(out.Value),
+		})
+	}
+
+	return &providertypes.TransactionUTXOs{
+		Hash:    tx.Id,
+		Outputs: outputs,
+	}
+}
+
+func convertOgmiosValue(value providertypes.OgmiosValue) []providertypes.TxAmount {
+	coins := value.Coins
+	if coins == nil {
+		coins = big.NewInt(0)
+	}
+
+	amounts := []providertypes.TxAmount{{Unit: UnitLovelace, Quantity: coins.String()}}
+
+	units := make([]string, 0, len(value.Assets))
+	for unit := range value.Assets {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+
+	for _, unit := range units {
+		amounts = append(amounts, providertypes.TxAmount{
+			// Ogmios separates the policy id and the asset name with a dot.
+			Unit:     strings.Replace(unit, ".", "", 1),
+			Quantity: value.Assets[unit].String(),
+		})
+	}
+
+	return amounts
+}
+
+func convertOgmiosMetadata(tx *providertypes.OgmiosTx) []*providertypes.TransactionMetadata {
+	metadata := make([]*providertypes.TransactionMetadata, 0)
+	if tx.Metadata == nil || tx.Metadata.Body == nil {
+		return metadata
+	}
+
+	labels := make([]string, 0, len(tx.Metadata.Body.Blob))
+	for label := range tx.Metadata.Body.Blob {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	for _, label := range labels {
+		value, err := convertOgmiosMetadatum(tx.Metadata.Body.Blob[label])
+		if err != nil {
+			log.Warnf("Cannot convert metadata of tx %s, label = %s, err = %v", tx.Id, label, err)
+			continue
+		}
+
+		metadata = append(metadata, &providertypes.TransactionMetadata{
+			JsonMetadata: value,
+			Label:        label,
+		})
+	}
+
+	return metadata
+}
+
+// convertOgmiosMetadatum converts a metadatum in the detailed schema into the JSON format used by
+// Blockfrost and cardano-db-sync.
+func convertOgmiosMetadatum(raw json.RawMessage) (interface{}, error) {
+	metadatum := &providertypes.OgmiosMetadatum{}
+	if err := json.Unmarshal(raw, metadatum); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case metadatum.Int != nil:
+		return json.Number(metadatum.Int.String()), nil
+	case metadatum.String != nil:
+		return *metadatum.String, nil
+	case metadatum.Bytes != nil:
+		return "0x" + *metadatum.Bytes, nil
+	case metadatum.List != nil:
+		list := make([]interface{}, 0, len(metadatum.List))
+		for _, item := range metadatum.List {
+			value, err := convertOgmiosMetadatum(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case metadatum.Map != nil:
+		m := make(map[string]interface{})
+		for _, entry := range metadatum.Map {
+			key, err := convertOgmiosMetadatum(entry.K)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := convertOgmiosMetadatum(entry.V)
+			if err != nil {
+				return nil, err
+			}
+
+			m[fmt.Sprintf("%v", key)] = value
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("unknown metadatum %s", string(raw))
+}
+
+///// Provider
+
+func (p *OgmiosProvider) Health(ctx context.Context) (bool, error) {
+	if _, err := p.chainTip(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (p *OgmiosProvider) BlockLatest(ctx context.Context) (*providertypes.Block, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if len(p.blockHeights) == 0 {
+		return nil, OgmiosBlockNotFound
+	}
+
+	return p.blocks[p.blockHeights[len(p.blockHeights)-1]].block, nil
+}
+
+func (p *OgmiosProvider) Block(ctx context.Context, hashOrNumber string) (*providertypes.Block, error) {
+	block, err := p.getBlock(hashOrNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return block.block, nil
+}
+
+func (p *OgmiosProvider) getBlock(hashOrNumber string) (*ogmiosIndexedBlock, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if height, err := strconv.Atoi(hashOrNumber); err == nil {
+		if block, ok := p.blocks[height]; ok {
+			return block, nil
+		}
+
+		return nil, OgmiosBlockNotFound
+	}
+
+	for _, block := range p.blocks {
+		if block.block.Hash == hashOrNumber {
+			return block, nil
+		}
+	}
+
+	return nil, OgmiosBlockNotFound
+}
+
+func (p *OgmiosProvider) BlockTransactions(ctx context.Context, height string) ([]string, error) {
+	block, err := p.getBlock(height)
+	if err != nil {
+		return nil, err
+	}
+
+	return block.txHashes, nil
+}
+
+// AddressTransactions returns the transactions in block query.From that send to the address.
+func (p *OgmiosProvider) AddressTransactions(ctx context.Context, address string, query providertypes.APIQueryParams) ([]*providertypes.AddressTransactions, error) {
+	block, err := p.getBlock(query.From)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	res := make([]*providertypes.AddressTransactions, 0)
+	for _, hash := range block.txHashes {
+		tx, ok := p.txs[hash]
+		if !ok {
+			continue
+		}
+
+		for _, output := range tx.utxos.Outputs {
+			if output.Address == address {
+				res = append(res, &providertypes.AddressTransactions{TxHash: hash})
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (p *OgmiosProvider) BlockAddressOutputs(ctx context.Context, height string, addresses []string) ([]*providertypes.AddressOutput, error) {
+	block, err := p.getBlock(height)
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[string]bool)
+	for _, address := range addresses {
+		watched[address] = true
+	}
+
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	res := make([]*providertypes.AddressOutput, 0)
+	for _, hash := range block.txHashes {
+		tx, ok := p.txs[hash]
+		if !ok {
+			continue
+		}
+
+		for i, output := range tx.utxos.Outputs {
+			if watched[output.Address] {
+				res = append(res, &providertypes.AddressOutput{
+					TxHash:      hash,
+					OutputIndex: i,
+					Address:     output.Address,
+					Amount:      output.Amount,
+					Metadata:    tx.metadata,
+				})
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (p *OgmiosProvider) TransactionMetadata(ctx context.Context, hash string) ([]*providertypes.TransactionMetadata, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	tx, ok := p.txs[hash]
+	if !ok {
+		return nil, OgmiosTxNotFound
+	}
+
+	return tx.metadata, nil
+}
+
+func (p *OgmiosProvider) TransactionUTXOs(ctx context.Context, hash string) (*providertypes.TransactionUTXOs, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	tx, ok := p.txs[hash]
+	if !ok {
+		return nil, OgmiosTxNotFound
+	}
+
+	return tx.utxos, nil
+}
+
+func (p *OgmiosProvider) LatestEpochParameters(ctx context.Context) (*cardano.ProtocolParams, error) {
+	params := &providertypes.OgmiosProtocolParameters{}
+	if err := p.query("currentProtocolParameters", params); err != nil {
+		return nil, err
+	}
+
+	epoch, err := p.currentEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	coinsPerUtxo := params.CoinsPerUtxoByte
+	if coinsPerUtxo == 0 {
+		coinsPerUtxo = params.CoinsPerUtxoWord
+	}
+
+	return &cardano.ProtocolParams{
+		MinFeeA:            cardano.Coin(params.MinFeeCoefficient),
+		MinFeeB:            cardano.Coin(params.MinFeeConstant),
+		MaxBlockBodySize:   uint(params.MaxBlockBodySize),
+		MaxTxSize:          uint(params.MaxTxSize),
+		MaxBlockHeaderSize: uint(params.MaxBlockHeaderSize),
+		KeyDeposit:         cardano.Coin(params.StakeKeyDeposit),
+		PoolDeposit:        cardano.Coin(params.PoolDeposit),
+		MaxEpoch:           uint(epoch),
+		NOpt:               uint(params.DesiredNumberOfPools),
+		CoinsPerUTXOWord:   cardano.Coin(coinsPerUtxo),
+	}, nil
+}
+
+// AddressUTXOs returns the current utxos of an address. The node only knows the latest utxo set so
+// an error is returned if query.To is lower than the height of the node.
+func (p *OgmiosProvider) AddressUTXOs(ctx context.Context, address string, query providertypes.APIQueryParams) ([]cardano.UTxO, error) {
+	spender, err := cardano.NewAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][2]json.RawMessage, 0)
+	if err := p.query(map[string]interface{}{"utxo": []string{address}}, &result); err != nil {
+		return nil, err
+	}
+
+	if len(query.To) > 0 {
+		maxBlock, err := strconv.ParseUint(query.To, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		// The height is read after the utxos so the utxo set is not newer than this height.
+		var height uint64
+		if err := p.query("blockHeight", &height); err != nil {
+			return nil, err
+		}
+
+		if height > maxBlock {
+			return nil, fmt.Errorf("ogmios cannot get the utxos at block %d, the node is at block %d",
+				maxBlock, height)
+		}
+	}
+
+	utxos := make([]cardano.UTxO, 0, len(result))
+	for _, pair := range result {
+		txIn := &providertypes.OgmiosTxIn{}
+		if err := json.Unmarshal(pair[0], txIn); err != nil {
+			return nil, err
+		}
+
+		txOut := &providertypes.OgmiosTxOut{}
+		if err := json.Unmarshal(pair[1], txOut); err != nil {
+			return nil, err
+		}
+
+		txHash, err := cardano.NewHash32(txIn.TxId)
+		if err != nil {
+			return nil, err
+		}
+
+		amount, err := ogmiosValueToCardano(txOut.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		utxos = append(utxos, cardano.UTxO{
+			Spender: spender,
+			TxHash:  txHash,
+			Index:   txIn.Index,
+			Amount:  amount,
+		})
+	}
+
+	return utxos, nil
+}
+
+func ogmiosValueToCardano(value providertypes.OgmiosValue) (*cardano.Value, error) {
+	coins := uint64(0)
+	if value.Coins != nil {
+		coins = value.Coins.Uint64()
+	}
+
+	amount := cardano.NewValue(cardano.Coin(coins))
+	for unit, quantity := range value.Assets {
+		parts := strings.SplitN(unit, ".", 2)
+		policyBytes, err := hex.DecodeString(parts[0])
+		if err != nil {
+			return nil, err
+		}
+
+		assetName := []byte{}
+		if len(parts) == 2 {
+			assetName, err = hex.DecodeString(parts[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		policyID := cardano.NewPolicyIDFromHash(cardano.Hash28(policyBytes))
+		assets := amount.MultiAsset.Get(policyID)
+		if assets == nil {
+			assets = cardano.NewAssets()
+			amount.MultiAsset.Set(policyID, assets)
+		}
+		assets.Set(cardano.NewAssetName(string(assetName)), cardano.BigNum(quantity.Uint64()))
+	}
+
+	return amount, nil
+}
+
+// Tip returns the block at blockHeight, or the latest block if blockHeight is higher than the tip.
+func (p *OgmiosProvider) Tip(blockHeight uint64) (*cardano.NodeTip, error) {
+	epoch, err := p.currentEpoch()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := p.getBlock(fmt.Sprintf("%d", blockHeight))
+	if err == nil {
+		return &cardano.NodeTip{
+			Block: uint64(block.block.Height),
+			Epoch: epoch,
+			Slot:  uint64(block.block.Slot),
+		}, nil
+	}
+
+	tip, err := p.chainTip()
+	if err != nil {
+		return nil, err
+	}
+
+	var height uint64
+	if err := p.query("blockHeight", &height); err != nil {
+		return nil, err
+	}
+
+	if blockHeight > height {
+		blockHeight = height
+	}
+
+	return &cardano.NodeTip{
+		Block: blockHeight,
+		Epoch: epoch,
+		Slot:  tip.Slot,
+	}, nil
+}
+
+func (p *OgmiosProvider) chainTip() (*providertypes.OgmiosPoint, error) {
+	tip := &providertypes.OgmiosPoint{}
+	if err := p.query("chainTip", tip); err != nil {
+		return nil, err
+	}
+
+	return tip, nil
+}
+
+func (p *OgmiosProvider) currentEpoch() (uint64, error) {
+	var epoch uint64
+	err := p.query("currentEpoch", &epoch)
+
+	return epoch, err
+}
+
+// SubmitTx implements TxSubmitter.
+func (p *OgmiosProvider) SubmitTx(ctx context.Context, tx []byte) (string, error) {
+	raw := json.RawMessage{}
+	if err := p.call("SubmitTx", map[string]interface{}{"submit": hex.EncodeToString(tx)}, &raw); err != nil {
+		return "", err
+	}
+
+	// Ogmios before v5.5 returns "SubmitSuccess" without the tx id.
+	var msg string
+	if err := json.Unmarshal(raw, &msg); err == nil {
+		if msg == "SubmitSuccess" {
+			return "", nil
+		}
+		return "", NewSubmitTxError(msg)
+	}
+
+	result := &providertypes.OgmiosSubmitTxResult{}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return "", err
+	}
+
+	if result.SubmitSuccess == nil {
+		return "", NewSubmitTxError(string(result.SubmitFail))
+	}
+
+	return result.SubmitSuccess.TxId, nil
+}
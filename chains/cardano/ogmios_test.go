@@ -0,0 +1,362 @@
+package cardano
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+const (
+	testOgmiosAddress = "addr_test1vqyqp03az6w8xuknzpfup3h7ghjwu26z7xa6gk7l9j7j2gs8zfwcy"
+	testOgmiosTxHash  = "bc82779c18b98f0f5628b0cae12af618020e5388258d3bcce936c380583298dc"
+)
+
+type mockOgmiosServer struct {
+	t      *testing.T
+	server *httptest.Server
+
+	lock sync.Mutex
+	// Version returned by the health endpoint.
+	version string
+	// Results of RequestNext, served in order.
+	nextResults []string
+	// Results of other requests keyed by method name (and query name for Query).
+	results map[string]string
+	// Submitted transactions.
+	submitted []string
+}
+
+func newMockOgmiosServer(t *testing.T) *mockOgmiosServer {
+	s := &mockOgmiosServer{
+		t:       t,
+		version: "v5.6.0",
+		results: make(map[string]string),
+	}
+
+	upgrader := websocket.Upgrader{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+
+			w.Write([]byte(`{"version":"` + s.version + `"}`))
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		writeLock := &sync.Mutex{}
+		write := func(method, result string) error {
+			writeLock.Lock()
+			defer writeLock.Unlock()
+
+			response := `{"type":"jsonwsp/response","version":"1.0","servicename":"ogmios","methodname":"` +
+				method + `","result":` + result + `}`
+			return conn.WriteMessage(websocket.TextMessage, []byte(response))
+		}
+
+		// Like Ogmios, RequestNext is answered when the next block is available.
+		pending := atomic.Int32{}
+		done := make(chan bool)
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(time.Millisecond * 10):
+				}
+
+				for pending.Load() > 0 {
+					result, ok := s.popNext()
+					if !ok {
+						break
+					}
+
+					pending.Dec()
+					if err := write("RequestNext", result); err != nil {
+						return
+					}
+				}
+			}
+		}()
+
+		for {
+			request := struct {
+				MethodName string                 `json:"methodname"`
+				Args       map[string]interface{} `json:"args"`
+			}{}
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+
+			if request.MethodName == "RequestNext" {
+				pending.Inc()
+				continue
+			}
+
+			result, ok := s.getResult(request.MethodName, request.Args)
+			if !ok {
+				continue
+			}
+
+			if err := write(request.MethodName, result); err != nil {
+				return
+			}
+		}
+	}))
+
+	return s
+}
+
+func (s *mockOgmiosServer) url() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+func (s *mockOgmiosServer) getResult(method string, args map[string]interface{}) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch method {
+	case "FindIntersect":
+		points := args["points"].([]interface{})
+		if points[0] == "origin" {
+			return `{"IntersectionFound":{"point":"origin","tip":{"slot":1000,"hash":"tiphash","blockNo":99}}}`, true
+		}
+		return `{"IntersectionFound":{"point":{"slot":1000,"hash":"tiphash"},"tip":{"slot":1000,"hash":"tiphash","blockNo":99}}}`, true
+
+	case "Query":
+		bz, err := json.Marshal(args["query"])
+		require.Nil(s.t, err)
+		result, ok := s.results[string(bz)]
+		return result, ok
+
+	case "SubmitTx":
+		s.submitted = append(s.submitted, args["submit"].(string))
+		return `{"SubmitSuccess":{"txId":"` + testOgmiosTxHash + `"}}`, true
+	}
+
+	return "", false
+}
+
+func (s *mockOgmiosServer) popNext() (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.nextResults) == 0 {
+		return "", false
+	}
+
+	result := s.nextResults[0]
+	s.nextResults = s.nextResults[1:]
+
+	return result, true
+}
+
+func (s *mockOgmiosServer) addNext(results ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.nextResults = append(s.nextResults, results...)
+}
+
+func ogmiosRollForward(height, slot int, hash string, txs string) string {
+	block := map[string]interface{}{
+		"body":       json.RawMessage(txs),
+		"header":     map[string]interface{}{"blockHeight": height, "slot": slot},
+		"headerHash": hash,
+	}
+	bz, _ := json.Marshal(map[string]interface{}{
+		"RollForward": map[string]interface{}{
+			"block": map[string]interface{}{"babbage": block},
+			"tip":   map[string]interface{}{"slot": slot, "hash": hash, "blockNo": height},
+		},
+	})
+
+	return string(bz)
+}
+
+func ogmiosRollBackward(slot int, hash string) string {
+	return fmt.Sprintf(`{"RollBackward":{"point":{"slot":%d,"hash":"%s"},"tip":{"slot":1000,"hash":"tiphash","blockNo":99}}}`,
+		slot, hash)
+}
+
+func TestOgmiosProvider_ChainSync(t *testing.T) {
+	server := newMockOgmiosServer(t)
+	defer server.server.Close()
+
+	tx := `[{
+		"id": "` + testOgmiosTxHash + `",
+		"body": {
+			"inputs": [{"txId": "` + testOgmiosTxHash + `", "index": 1}],
+			"outputs": [{
+				"address": "` + testOgmiosAddress + `",
+				"value": {"coins": 1500000, "assets": {"` + testPolicy1 + `.74555344": 100}}
+			}]
+		},
+		"metadata": {
+			"hash": "metadatahash",
+			"body": {"blob": {"721": {"map": [
+				{"k": {"string": "chain"}, "v": {"string": "ganache1"}},
+				{"k": {"string": "recipient"}, "v": {"string": "0x123"}},
+				{"k": {"string": "native_ada"}, "v": {"int": 1}}
+			]}}}
+		}
+	}]`
+
+	server.addNext(
+		ogmiosRollBackward(1000, "tiphash"),
+		ogmiosRollForward(100, 1010, "hash100", tx),
+		ogmiosRollForward(101, 1020, "hash101", "[]"),
+	)
+
+	provider := newOgmiosProvider(server.url())
+	go provider.chainSync()
+
+	ctx := context.Background()
+	require.Eventually(t, func() bool {
+		block, err := provider.BlockLatest(ctx)
+		return err == nil && block.Height == 101
+	}, time.Second*5, time.Millisecond*20)
+
+	block, err := provider.Block(ctx, "100")
+	require.Nil(t, err)
+	require.Equal(t, "hash100", block.Hash)
+	require.Equal(t, 1010, block.Slot)
+
+	block, err = provider.Block(ctx, "hash101")
+	require.Nil(t, err)
+	require.Equal(t, 101, block.Height)
+
+	txs, err := provider.BlockTransactions(ctx, "100")
+	require.Nil(t, err)
+	require.Equal(t, []string{testOgmiosTxHash}, txs)
+
+	addressTxs, err := provider.AddressTransactions(ctx, testOgmiosAddress, providertypes.APIQueryParams{From: "100"})
+	require.Nil(t, err)
+	require.Len(t, addressTxs, 1)
+
+	utxos, err := provider.TransactionUTXOs(ctx, testOgmiosTxHash)
+	require.Nil(t, err)
+	require.Len(t, utxos.Outputs, 1)
+	require.Equal(t, []providertypes.TxAmount{
+		{Unit: UnitLovelace, Quantity: "1500000"},
+		{Unit: testPolicy1 + "74555344", Quantity: "100"},
+	}, utxos.Outputs[0].Amount)
+
+	// Metadata is converted to the json format of Blockfrost.
+	client := NewDefaultCardanoClient(provider, nil, "")
+	metadata, err := client.GetTransactionMetadata(testOgmiosTxHash)
+	require.Nil(t, err)
+	require.Equal(t, "ganache1", metadata.Chain)
+	require.Equal(t, "0x123", metadata.Recipient)
+	require.Equal(t, 1, metadata.NativeAda)
+
+	// Deposits to the vault are read from the block outputs.
+	deposits, err := client.NewTxs(100, testOgmiosAddress)
+	require.Nil(t, err)
+	require.Len(t, deposits, 1)
+	require.Equal(t, testOgmiosTxHash, deposits[0].Hash)
+	require.Equal(t, 0, deposits[0].Index)
+	require.Equal(t, utxos.Outputs[0].Amount, deposits[0].Amount)
+	require.Equal(t, metadata, deposits[0].Metadata)
+
+	// Rollback block 101 and replace it with another block.
+	server.addNext(
+		ogmiosRollBackward(1010, "hash100"),
+		ogmiosRollForward(101, 1025, "hash101b", "[]"),
+	)
+
+	require.Eventually(t, func() bool {
+		block, err := provider.BlockLatest(ctx)
+		return err == nil && block.Hash == "hash101b"
+	}, time.Second*5, time.Millisecond*20)
+
+	_, err = provider.Block(ctx, "hash101")
+	require.Equal(t, OgmiosBlockNotFound, err)
+}
+
+func TestOgmiosProvider_Query(t *testing.T) {
+	server := newMockOgmiosServer(t)
+	defer server.server.Close()
+
+	server.results[`"currentProtocolParameters"`] = `{"minFeeCoefficient":44,"minFeeConstant":155381,
+		"maxBlockBodySize":90112,"maxBlockHeaderSize":1100,"maxTxSize":16384,"stakeKeyDeposit":2000000,
+		"poolDeposit":500000000,"desiredNumberOfPools":500,"coinsPerUtxoByte":4310}`
+	server.results[`"currentEpoch"`] = `42`
+	server.results[`"chainTip"`] = `{"slot":1000,"hash":"tiphash"}`
+	server.results[`"blockHeight"`] = `99`
+	server.results[`{"utxo":["`+testOgmiosAddress+`"]}`] = `[[
+		{"txId":"` + testOgmiosTxHash + `","index":2},
+		{"address":"` + testOgmiosAddress + `","value":{"coins":2000000,"assets":{"` + testPolicy1 + `.74555344":5}}}
+	]]`
+
+	provider := newOgmiosProvider(server.url())
+	ctx := context.Background()
+
+	healthy, err := provider.Health(ctx)
+	require.Nil(t, err)
+	require.True(t, healthy)
+
+	params, err := provider.LatestEpochParameters(ctx)
+	require.Nil(t, err)
+	require.Equal(t, uint64(44), uint64(params.MinFeeA))
+	require.Equal(t, uint64(155381), uint64(params.MinFeeB))
+	require.Equal(t, uint64(4310), uint64(params.CoinsPerUTXOWord))
+	require.Equal(t, uint(42), params.MaxEpoch)
+
+	utxos, err := provider.AddressUTXOs(ctx, testOgmiosAddress, providertypes.APIQueryParams{})
+	require.Nil(t, err)
+	require.Len(t, utxos, 1)
+	require.Equal(t, uint64(2), utxos[0].Index)
+	require.Equal(t, uint64(2000000), uint64(utxos[0].Amount.Coin))
+	require.Equal(t, 1, len(utxos[0].Amount.MultiAsset.Keys()))
+
+	// The node only has the utxos at its tip.
+	utxos, err = provider.AddressUTXOs(ctx, testOgmiosAddress, providertypes.APIQueryParams{To: "99"})
+	require.Nil(t, err)
+	require.Len(t, utxos, 1)
+	_, err = provider.AddressUTXOs(ctx, testOgmiosAddress, providertypes.APIQueryParams{To: "98"})
+	require.NotNil(t, err)
+
+	// Block 150 is not indexed, the tip of the node is returned.
+	tip, err := provider.Tip(150)
+	require.Nil(t, err)
+	require.Equal(t, uint64(99), tip.Block)
+	require.Equal(t, uint64(1000), tip.Slot)
+	require.Equal(t, uint64(42), tip.Epoch)
+
+	txHash, err := provider.SubmitTx(ctx, []byte{1, 2, 3})
+	require.Nil(t, err)
+	require.Equal(t, testOgmiosTxHash, txHash)
+	require.Equal(t, []string{"010203"}, server.submitted)
+}
+
+func TestOgmiosProvider_UnsupportedVersion(t *testing.T) {
+	server := newMockOgmiosServer(t)
+	defer server.server.Close()
+
+	server.version = "v6.0.0 (a1b2c3d4)"
+	server.results[`"chainTip"`] = `{"slot":1000,"hash":"tiphash"}`
+
+	provider := newOgmiosProvider(server.url())
+	healthy, err := provider.Health(context.Background())
+	require.NotNil(t, err)
+	require.False(t, healthy)
+	require.Contains(t, err.Error(), "not supported")
+}
@@ -0,0 +1,116 @@
+package cardano
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/echovl/cardano-go"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+var (
+	// DefaultUtxoReservationTime is how long utxos stay reserved for a pending transaction when the
+	// caller does not set the expiry.
+	DefaultUtxoReservationTime = 10 * time.Minute
+)
+
+// UtxoId returns the id of a utxo in the db.
+func UtxoId(txHash string, index uint64) string {
+	return fmt.Sprintf("%s#%d", txHash, index)
+}
+
+// filterAvailableUtxos saves the utxos of an address and removes those that are spent or reserved
+// by a pending transaction. Spent utxos that are no longer returned by the node are deleted.
+func (w *Watcher) filterAvailableUtxos(addr string, utxos []cardano.UTxO) ([]cardano.UTxO, error) {
+	saved := make([]*types.CardanoUtxo, 0, len(utxos))
+	current := make(map[string]bool, len(utxos))
+	for _, utxo := range utxos {
+		serialized, err := utxo.Amount.MarshalCBOR()
+		if err != nil {
+			return nil, err
+		}
+
+		id := UtxoId(utxo.TxHash.String(), utxo.Index)
+		current[id] = true
+		saved = append(saved, &types.CardanoUtxo{
+			Id:         id,
+			Chain:      w.cfg.Chain,
+			Address:    addr,
+			TxHash:     utxo.TxHash.String(),
+			Index:      int(utxo.Index),
+			Serialized: serialized,
+		})
+	}
+
+	if err := w.db.SaveUtxos(w.cfg.Chain, saved); err != nil {
+		return nil, err
+	}
+
+	states, err := w.db.GetUtxos(w.cfg.Chain, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	unavailable := make(map[string]bool)
+	pruned := make([]string, 0)
+	for _, state := range states {
+		if !state.IsAvailable(now) {
+			unavailable[state.Id] = true
+		}
+
+		if state.Spent && !current[state.Id] {
+			pruned = append(pruned, state.Id)
+		}
+	}
+
+	if len(pruned) > 0 {
+		if err := w.db.DeleteUtxos(w.cfg.Chain, pruned); err != nil {
+			return nil, err
+		}
+	}
+
+	available := make([]cardano.UTxO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !unavailable[UtxoId(utxo.TxHash.String(), utxo.Index)] {
+			available = append(available, utxo)
+		}
+	}
+
+	return available, nil
+}
+
+// ReserveUtxos reserves utxos for a pending transaction so that they are not returned to other
+// transfers. The reservation is released when the transaction fails or expires and the utxos are
+// marked spent when the transaction is confirmed.
+func (w *Watcher) ReserveUtxos(txHash string, ids []string, expiry time.Duration) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no utxo to reserve")
+	}
+
+	if expiry <= 0 {
+		expiry = DefaultUtxoReservationTime
+	}
+
+	now := time.Now()
+	if err := w.db.ReserveUtxos(w.cfg.Chain, txHash, ids, now.Add(expiry).Unix(), now.Unix()); err != nil {
+		return err
+	}
+
+	// Confirm the transaction when it is included in a block.
+	w.TrackTx(txHash)
+
+	return nil
+}
+
+// ReleaseUtxos removes the reservation of a transaction that failed.
+func (w *Watcher) ReleaseUtxos(txHash string) error {
+	return w.db.ReleaseUtxos(w.cfg.Chain, txHash)
+}
+
+func (w *Watcher) setUtxosSpent(txHash string, spent bool) {
+	if err := w.db.SetUtxosSpent(w.cfg.Chain, txHash, spent); err != nil {
+		log.Errorf("%s: cannot set utxos of tx %s spent = %v, err = %v", w.cfg.Chain, txHash, spent, err)
+	}
+}
@@ -0,0 +1,78 @@
+package cardano
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/echovl/cardano-go"
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestDb(t *testing.T) database.Database {
+	db := database.NewDb(&config.Deyes{InMemory: true, DbHost: "localhost"})
+	require.Nil(t, db.Init())
+
+	return db
+}
+
+func TestWatcher_ReserveUtxos(t *testing.T) {
+	utxos := []cardano.UTxO{
+		newTestUtxo(t, "01", 0, cardano.NewValue(3_000_000)),
+		newTestUtxo(t, "02", 1, cardano.NewValue(5_000_000)),
+	}
+	client := &MockCardanoClient{}
+	client.AddressUTXOsFunc = func(ctx context.Context, addr string, query providertypes.APIQueryParams) ([]cardano.UTxO, error) {
+		return utxos, nil
+	}
+
+	db := getTestDb(t)
+	defer db.Close()
+
+	watcher := NewWatcher(config.Chain{Chain: "cardano-testnet"}, db, nil, nil,
+		make(chan *chainstypes.TrackUpdate, 10), client)
+	watcher.vault = testTestnetAddress
+
+	available, err := watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Len(t, available, 2)
+
+	id := UtxoId(utxos[0].TxHash.String(), 0)
+	require.Nil(t, watcher.ReserveUtxos("tx1", []string{id}, time.Minute))
+	_, ok := watcher.txTrackCache.Get("tx1")
+	require.True(t, ok)
+
+	// The reserved utxo is not available for another transfer.
+	available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Equal(t, []cardano.UTxO{utxos[1]}, available)
+	require.NotNil(t, watcher.ReserveUtxos("tx2", []string{id}, time.Minute))
+
+	// The utxo is available again after the transaction fails.
+	require.Nil(t, watcher.ReleaseUtxos("tx1"))
+	available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Len(t, available, 2)
+
+	// Spent utxos are not available.
+	require.Nil(t, watcher.ReserveUtxos("tx2", []string{id}, time.Minute))
+	watcher.setUtxosSpent("tx2", true)
+	require.Nil(t, watcher.ReleaseUtxos("tx2"))
+	available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Equal(t, []cardano.UTxO{utxos[1]}, available)
+
+	// The spent utxo is deleted when the node no longer returns it.
+	utxos = utxos[1:]
+	available, err = watcher.CardanoUtxos(testTestnetAddress, 100)
+	require.Nil(t, err)
+	require.Equal(t, utxos, available)
+	saved, err := db.GetUtxos("cardano-testnet", testTestnetAddress)
+	require.Nil(t, err)
+	require.Len(t, saved, 1)
+	require.Equal(t, UtxoId(utxos[0].TxHash.String(), 1), saved[0].Id)
+}
@@ -0,0 +1,106 @@
+package cardano
+
+import (
+	"strconv"
+	"time"
+
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+var (
+	// MaxRollbackDepth is the number of recent blocks kept to detect forks. This is the security
+	// parameter k of Cardano, blocks older than this cannot be rolled back.
+	MaxRollbackDepth = 2160
+)
+
+// scannedBlock is a block processed by the watcher and the transactions reported from it.
+type scannedBlock struct {
+	block *providertypes.Block
+	// Deposits reported to Sisu.
+	txs []*types.Tx
+	// Hashes of tracked transactions confirmed in this block.
+	trackedHashes []string
+}
+
+func (w *Watcher) addScannedBlock(scanned *scannedBlock) {
+	w.recentBlocks = append(w.recentBlocks, scanned)
+	if len(w.recentBlocks) > MaxRollbackDepth {
+		w.recentBlocks = w.recentBlocks[len(w.recentBlocks)-MaxRollbackDepth:]
+	}
+}
+
+// handleRollback checks that a new block builds on the last scanned block. If it does not, the
+// scanned blocks that are no longer on the chain are reverted and the watcher restarts scanning
+// from the common ancestor. It returns true if the block must not be processed.
+func (w *Watcher) handleRollback(block *providertypes.Block) bool {
+	if len(w.recentBlocks) == 0 || len(block.PreviousBlock) == 0 {
+		return false
+	}
+
+	last := w.recentBlocks[len(w.recentBlocks)-1]
+	if block.PreviousBlock == last.block.Hash {
+		return false
+	}
+
+	log.Warnf("%s: fork detected at block %d, previous block = %s, last scanned block = %s",
+		w.cfg.Chain, block.Height, block.PreviousBlock, last.block.Hash)
+
+	// Find the most recent scanned block that is still on the chain.
+	forkIndex := len(w.recentBlocks)
+	for forkIndex > 0 {
+		scanned := w.recentBlocks[forkIndex-1]
+		canonical, err := w.client.GetBlock(strconv.Itoa(scanned.block.Height))
+		if err != nil {
+			log.Errorf("%s: cannot get block %d to resolve fork, err = %v", w.cfg.Chain,
+				scanned.block.Height, err)
+			time.Sleep(time.Duration(w.blockTime) * time.Millisecond)
+			return true
+		}
+
+		if canonical.Hash == scanned.block.Hash {
+			break
+		}
+
+		forkIndex--
+	}
+
+	if forkIndex == 0 {
+		log.Errorf("%s: fork is deeper than %d blocks", w.cfg.Chain, len(w.recentBlocks))
+	}
+
+	reverted := w.recentBlocks[forkIndex:]
+	w.recentBlocks = w.recentBlocks[:forkIndex]
+	w.lastBlockHeight.Store(int32(reverted[0].block.Height - 1))
+
+	// Revert the most recent blocks first.
+	for i := len(reverted) - 1; i >= 0; i-- {
+		w.revertBlock(reverted[i])
+	}
+
+	return true
+}
+
+// revertBlock tells Sisu that the deposits of a block are no longer valid. Tracked transactions
+// confirmed in the block are tracked again so that they are confirmed on the new branch.
+func (w *Watcher) revertBlock(scanned *scannedBlock) {
+	log.Warnf("%s: reverting block %d with hash %s, reported txs = %d", w.cfg.Chain,
+		scanned.block.Height, scanned.block.Hash, len(scanned.txs))
+
+	for _, hash := range scanned.trackedHashes {
+		w.setUtxosSpent(hash, false)
+		w.TrackTx(hash)
+	}
+
+	if len(scanned.txs) == 0 {
+		return
+	}
+
+	w.revertedCh <- &types.RevertedTxs{
+		Chain:     w.cfg.Chain,
+		Block:     int64(scanned.block.Height),
+		BlockHash: scanned.block.Hash,
+		Arr:       scanned.txs,
+	}
+}
@@ -0,0 +1,81 @@
+package cardano
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBlock(height int, hash, previous string) *providertypes.Block {
+	return &providertypes.Block{Height: height, Hash: hash, PreviousBlock: previous}
+}
+
+func TestWatcher_HandleRollback(t *testing.T) {
+	canonical := map[int]string{10: "h10", 11: "h11b", 12: "h12b"}
+	client := &MockCardanoClient{}
+	client.GetBlockFunc = func(hashOrNumber string) (*providertypes.Block, error) {
+		height, err := strconv.Atoi(hashOrNumber)
+		require.Nil(t, err)
+
+		hash, ok := canonical[height]
+		if !ok {
+			return nil, fmt.Errorf("Not Found")
+		}
+
+		return newTestBlock(height, hash, ""), nil
+	}
+
+	txsCh := make(chan *types.Txs, 10)
+	revertedCh := make(chan *types.RevertedTxs, 10)
+	cfg := config.Chain{Chain: "cardano-testnet"}
+	db := getTestDb(t)
+	defer db.Close()
+	watcher := NewWatcher(cfg, db, txsCh, revertedCh, make(chan *chainstypes.TrackUpdate, 10), client)
+
+	deposit := &types.Tx{Hash: "deposit", OutputIndex: 1}
+	watcher.addScannedBlock(&scannedBlock{block: newTestBlock(10, "h10", "h9")})
+	watcher.addScannedBlock(&scannedBlock{block: newTestBlock(11, "h11", "h10"), txs: []*types.Tx{deposit}})
+	watcher.addScannedBlock(&scannedBlock{block: newTestBlock(12, "h12", "h11"), trackedHashes: []string{"tracked"}})
+	watcher.lastBlockHeight.Store(12)
+
+	// The next block builds on the last scanned block.
+	require.False(t, watcher.handleRollback(newTestBlock(13, "h13", "h12")))
+	require.Len(t, watcher.recentBlocks, 3)
+
+	// Blocks 11 and 12 are replaced.
+	require.True(t, watcher.handleRollback(newTestBlock(13, "h13b", "h12b")))
+	require.Len(t, watcher.recentBlocks, 1)
+	require.Equal(t, int32(10), watcher.lastBlockHeight.Load())
+
+	// Deposits of block 11 are reverted. They are not sent as new deposits.
+	require.Len(t, txsCh, 0)
+	require.Len(t, revertedCh, 1)
+	txs := <-revertedCh
+	require.Equal(t, int64(11), txs.Block)
+	require.Equal(t, "h11", txs.BlockHash)
+	require.Equal(t, []*types.Tx{deposit}, txs.Arr)
+
+	// The tracked tx is tracked again.
+	_, ok := watcher.txTrackCache.Get("tracked")
+	require.True(t, ok)
+}
+
+func TestWatcher_AddScannedBlock(t *testing.T) {
+	maxDepth := MaxRollbackDepth
+	MaxRollbackDepth = 3
+	defer func() { MaxRollbackDepth = maxDepth }()
+
+	watcher := NewWatcher(config.Chain{Chain: "cardano-testnet"}, nil, nil, nil, nil, &MockCardanoClient{})
+	for i := 1; i <= 5; i++ {
+		watcher.addScannedBlock(&scannedBlock{block: newTestBlock(i, fmt.Sprintf("h%d", i), "")})
+	}
+
+	require.Len(t, watcher.recentBlocks, 3)
+	require.Equal(t, 3, watcher.recentBlocks[0].block.Height)
+}
@@ -0,0 +1,98 @@
+package cardano
+
+import (
+	"strings"
+
+	"github.com/sisu-network/deyes/types"
+)
+
+const (
+	// Max length of the node error kept in a SubmitTxError.
+	maxSubmitErrorLength = 512
+)
+
+// SubmitTxError is a transaction rejected by the submit API or the node.
+type SubmitTxError struct {
+	Code types.DispatchError
+	// Reason is the name of the ledger rule that rejected the transaction, or empty if the error
+	// is unknown.
+	Reason string
+	// AlreadySubmitted is true if the transaction is already in the mempool or on chain.
+	AlreadySubmitted bool
+	message          string
+}
+
+func (e *SubmitTxError) Error() string {
+	return e.message
+}
+
+// submitErrorRules maps ledger errors to dispatch errors. The patterns are in lower case and match
+// both the submit API format (e.g. "BadInputsUTxO") and the Ogmios format (e.g. "badInputs").
+var submitErrorRules = []struct {
+	patterns []string
+	reason   string
+	code     types.DispatchError
+}{
+	{[]string{"badinputs"}, "BadInputsUTxO", types.ErrInputsSpent},
+	{[]string{"valuenotconserved"}, "ValueNotConserved", types.ErrValueNotConserved},
+	{[]string{"feetoosmall"}, "FeeTooSmall", types.ErrInsufficientFee},
+	{[]string{"outsidevalidityinterval", "outsideofvalidityinterval"}, "OutsideValidityInterval", types.ErrTxExpired},
+}
+
+var alreadySubmittedPatterns = []string{
+	"already in mempool",
+	"alreadyinmempool",
+	"already been included",
+	"already submitted",
+}
+
+// NewSubmitTxError classifies the error body returned when submitting a transaction.
+func NewSubmitTxError(message string) *SubmitTxError {
+	if len(message) > maxSubmitErrorLength {
+		message = message[:maxSubmitErrorLength]
+	}
+
+	err := &SubmitTxError{Code: types.ErrSubmitTx, message: message}
+	lower := strings.ToLower(message)
+
+	for _, pattern := range alreadySubmittedPatterns {
+		if strings.Contains(lower, pattern) {
+			err.Code = types.ErrNil
+			err.Reason = "AlreadySubmitted"
+			err.AlreadySubmitted = true
+			return err
+		}
+	}
+
+	for _, rule := range submitErrorRules {
+		for _, pattern := range rule.patterns {
+			if strings.Contains(lower, pattern) {
+				err.Code = rule.code
+				err.Reason = rule.reason
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// IsKnown returns true if the node rejected the transaction for a known reason. Other endpoints
+// would reject it for the same reason.
+func (e *SubmitTxError) IsKnown() bool {
+	return e.Code != types.ErrSubmitTx
+}
+
+// IsAlreadySubmitted returns true if the error says that the transaction is already submitted.
+func IsAlreadySubmitted(err error) bool {
+	submitErr, ok := err.(*SubmitTxError)
+	return ok && submitErr.AlreadySubmitted
+}
+
+// IsRejected returns true if the node definitely rejected the transaction for a known reason, so
+// it will not be included in a block. Transport errors and unknown errors return false. Spent
+// inputs also return false since the same transaction may have been included by another node.
+func IsRejected(err error) bool {
+	submitErr, ok := err.(*SubmitTxError)
+	return ok && submitErr.IsKnown() && !submitErr.AlreadySubmitted && submitErr.Code != types.ErrInputsSpent
+}
@@ -15,7 +15,7 @@ import (
 	providertypes "github.com/sisu-network/deyes/chains/cardano/types"
 	"github.com/sisu-network/deyes/config"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var _ Provider = (*SyncDB)(nil)
@@ -71,26 +71,30 @@ func (s *SyncDB) Block(ctx context.Context, number string) (*providertypes.Block
 		return nil, err
 	}
 
-	rows, err := s.DB.Query("select encode(hash, 'hex'), block_no, slot_no, epoch_no from block where block_no = $1", num)
+	query := "select encode(b.hash, 'hex'), b.block_no, b.slot_no, b.epoch_no, encode(p.hash, 'hex') from block b" +
+		" left join block p on p.id = b.previous_id where b.block_no = $1"
+	rows, err := s.DB.Query(query, num)
 	if err != nil {
 		return nil, err
 	}
 
+	defer rows.Close()
 	rows.Next()
 	var (
 		blockNumber, slot, epoch sql.NullInt64
-		hash                     sql.NullString
+		hash, previousHash       sql.NullString
 	)
 
-	if err := rows.Scan(&hash, &blockNumber, &slot, &epoch); err != nil {
+	if err := rows.Scan(&hash, &blockNumber, &slot, &epoch, &previousHash); err != nil {
 		return nil, err
 	}
 
 	return &providertypes.Block{
-		Height: int(blockNumber.Int64),
-		Hash:   hash.String,
-		Slot:   int(slot.Int64),
-		Epoch:  int(epoch.Int64),
+		Height:        int(blockNumber.Int64),
+		Hash:          hash.String,
+		PreviousBlock: previousHash.String,
+		Slot:          int(slot.Int64),
+		Epoch:         int(epoch.Int64),
 	}, nil
 }
 
@@ -173,6 +177,11 @@ func (s *SyncDB) TransactionUTXOs(ctx context.Context, hash string) (*providerty
 		return nil, err
 	}
 
+	// A transaction has at least one output.
+	if len(res.Ids) == 0 {
+		return nil, TxNotFound
+	}
+
 	outputs := make([]providertypes.TransactionUTXOsOutput, 0)
 
 	for index, id := range res.Ids {
@@ -281,18 +290,7 @@ func (s *SyncDB) TransactionMetadata(_ context.Context, hash string) ([]*provide
 			return nil, err
 		}
 
-		m := map[string]interface{}{}
-		if err := json.Unmarshal([]byte(metadata.String), &m); err == nil {
-			res = append(res, &providertypes.TransactionMetadata{
-				JsonMetadata: m,
-				Label:        label.String,
-			})
-		} else {
-			res = append(res, &providertypes.TransactionMetadata{
-				JsonMetadata: metadata.String,
-				Label:        label.String,
-			})
-		}
+		res = append(res, parseSyncDBMetadata(label, metadata))
 	}
 
 	return res, nil
@@ -668,3 +666,130 @@ func buildQueryFromIntArray(arr []int64) string {
 	res = "(" + res + ")"
 	return res
 }
+
+// addressOutputRow is a row of the BlockAddressOutputs query. An output appears in one row for
+// each pair of native asset and metadata label of the transaction.
+type addressOutputRow struct {
+	txHash, address             string
+	index                       int
+	value                       int64
+	policy, assetName, quantity sql.NullString
+	metadataLabel, metadataJson sql.NullString
+}
+
+// BlockAddressOutputs gets the outputs to the addresses in a block with their native assets and
+// the metadata of their transactions in a single query.
+func (s *SyncDB) BlockAddressOutputs(_ context.Context, height string, addresses []string) ([]*providertypes.AddressOutput, error) {
+	h, err := strconv.Atoi(height)
+	if err != nil {
+		return nil, err
+	}
+
+	query := "SELECT encode(tx.hash, 'hex'), o.index, o.address, o.value, encode(ma.policy, 'hex'), encode(ma.name, 'hex')," +
+		" mto.quantity, m.key, m.json FROM block b" +
+		" JOIN tx ON tx.block_id = b.id" +
+		" JOIN tx_out o ON o.tx_id = tx.id" +
+		" LEFT JOIN ma_tx_out mto ON mto.tx_out_id = o.id" +
+		" LEFT JOIN multi_asset ma ON ma.id = mto.ident" +
+		" LEFT JOIN tx_metadata m ON m.tx_id = tx.id" +
+		" WHERE b.block_no = $1 AND o.address = ANY($2)" +
+		" ORDER BY tx.block_index, o.index, ma.id, m.key"
+	rows, err := s.DB.Query(query, h, pq.Array(addresses))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	outputs := make([]*providertypes.AddressOutput, 0)
+	for rows.Next() {
+		row := addressOutputRow{}
+		if err := rows.Scan(&row.txHash, &row.index, &row.address, &row.value, &row.policy, &row.assetName,
+			&row.quantity, &row.metadataLabel, &row.metadataJson); err != nil {
+			return nil, err
+		}
+
+		outputs = appendAddressOutputRow(outputs, &row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The lovelace amount is the last one like in TransactionUTXOs.
+	for _, output := range outputs {
+		output.Amount = append(output.Amount[1:], output.Amount[0])
+	}
+
+	return outputs, nil
+}
+
+// appendAddressOutputRow merges a row into the outputs. Rows of the same output must be
+// consecutive. The lovelace amount is the first amount of an output.
+func appendAddressOutputRow(outputs []*providertypes.AddressOutput, row *addressOutputRow) []*providertypes.AddressOutput {
+	var output *providertypes.AddressOutput
+	if len(outputs) > 0 {
+		last := outputs[len(outputs)-1]
+		if last.TxHash == row.txHash && last.OutputIndex == row.index {
+			output = last
+		}
+	}
+
+	if output == nil {
+		output = &providertypes.AddressOutput{
+			TxHash:      row.txHash,
+			OutputIndex: row.index,
+			Address:     row.address,
+			Amount: []providertypes.TxAmount{
+				{Quantity: strconv.FormatInt(row.value, 10), Unit: UnitLovelace},
+			},
+			Metadata: make([]*providertypes.TransactionMetadata, 0),
+		}
+		outputs = append(outputs, output)
+	}
+
+	if row.policy.Valid {
+		unit := row.policy.String + row.assetName.String
+		found := false
+		for _, amount := range output.Amount {
+			if amount.Unit == unit {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			output.Amount = append(output.Amount, providertypes.TxAmount{Quantity: row.quantity.String, Unit: unit})
+		}
+	}
+
+	if row.metadataLabel.Valid {
+		found := false
+		for _, metadata := range output.Metadata {
+			if metadata.Label == row.metadataLabel.String {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			output.Metadata = append(output.Metadata, parseSyncDBMetadata(row.metadataLabel, row.metadataJson))
+		}
+	}
+
+	return outputs
+}
+
+func parseSyncDBMetadata(label, metadata sql.NullString) *providertypes.TransactionMetadata {
+	m := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(metadata.String), &m); err == nil {
+		return &providertypes.TransactionMetadata{
+			JsonMetadata: m,
+			Label:        label.String,
+		}
+	}
+
+	return &providertypes.TransactionMetadata{
+		JsonMetadata: metadata.String,
+		Label:        label.String,
+	}
+}
@@ -2,6 +2,7 @@ package cardano
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"testing"
 
@@ -41,3 +42,41 @@ func TestBuildQueryFromString(t *testing.T) {
 	str := buildQueryFromIntArray(arr)
 	require.Equal(t, "(1,2,3,4)", str)
 }
+
+func TestAppendAddressOutputRow(t *testing.T) {
+	t.Parallel()
+
+	valid := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+	metadata := valid(`{"chain":"ganache1"}`)
+
+	// Output 0 of tx1 has 2 assets and the tx has 1 metadata label, output 1 of tx1 has no asset.
+	rows := []*addressOutputRow{
+		{txHash: "tx1", index: 0, address: "addr", value: 100, policy: valid("aa"), assetName: valid("01"),
+			quantity: valid("5"), metadataLabel: valid("0"), metadataJson: metadata},
+		{txHash: "tx1", index: 0, address: "addr", value: 100, policy: valid("aa"), assetName: valid("02"),
+			quantity: valid("6"), metadataLabel: valid("0"), metadataJson: metadata},
+		{txHash: "tx1", index: 1, address: "addr", value: 200, metadataLabel: valid("0"), metadataJson: metadata},
+		{txHash: "tx2", index: 0, address: "addr", value: 300},
+	}
+
+	outputs := make([]*providertypes.AddressOutput, 0)
+	for _, row := range rows {
+		outputs = appendAddressOutputRow(outputs, row)
+	}
+
+	require.Len(t, outputs, 3)
+	require.Equal(t, []providertypes.TxAmount{
+		{Quantity: "100", Unit: UnitLovelace},
+		{Quantity: "5", Unit: "aa01"},
+		{Quantity: "6", Unit: "aa02"},
+	}, outputs[0].Amount)
+	require.Len(t, outputs[0].Metadata, 1)
+	require.Equal(t, map[string]interface{}{"chain": "ganache1"}, outputs[0].Metadata[0].JsonMetadata)
+
+	require.Equal(t, 1, outputs[1].OutputIndex)
+	require.Equal(t, []providertypes.TxAmount{{Quantity: "200", Unit: UnitLovelace}}, outputs[1].Amount)
+	require.Len(t, outputs[1].Metadata, 1)
+
+	require.Equal(t, "tx2", outputs[2].TxHash)
+	require.Empty(t, outputs[2].Metadata)
+}
@@ -164,9 +164,9 @@ func testWatcher() {
 
 	provider := chainscardano.NewBlockfrostProvider(chainCfg)
 	txsCh := make(chan *types.Txs)
-	watcher := chainscardano.NewWatcher(chainCfg, dbInstance, txsCh,
+	watcher := chainscardano.NewWatcher(chainCfg, dbInstance, txsCh, make(chan *types.RevertedTxs, 3),
 		make(chan *chainstypes.TrackUpdate, 3),
-		chainscardano.NewDefaultCardanoClient(provider, blockfrost.CardanoTestNet+"/tx/submit", projectId))
+		chainscardano.NewDefaultCardanoClient(provider, []string{blockfrost.CardanoTestNet + "/tx/submit"}, projectId))
 	watcher.Start()
 	watcher.SetVault("addr_test1vrfcqffcl8h6j45ndq658qdwdxy2nhpqewv5dlxlmaatducz6k63t", "")
 
@@ -316,7 +316,7 @@ func testBlockfrostClient() {
 
 	provider := chainscardano.NewBlockfrostProvider(chainCfg)
 	client := chainscardano.NewDefaultCardanoClient(
-		provider, blockfrost.CardanoTestNet+"/tx/submit", projectId,
+		provider, []string{blockfrost.CardanoTestNet + "/tx/submit"}, projectId,
 	)
 
 	txsIn, err := client.NewTxs(3654812, "addr_test1vpa9x6a7r4cwg6r052yj25usa2gkxarps8zecfmtx4p7erqwtfq45")
@@ -496,7 +496,7 @@ func testWatcherSyncDB() {
 
 	syncDB := chainscardano.NewSyncDBConnector(db)
 
-	cardanoClient := chainscardano.NewDefaultCardanoClient(syncDB, "", "")
+	cardanoClient := chainscardano.NewDefaultCardanoClient(syncDB, nil, "")
 	txs, err := cardanoClient.NewTxs(229733, "addr_test1qrfut328td5krhgjk7eh6k9uh5ek6egk2xf0wxqxjaqq2jrtw0ge2rnesr84874t3cz5gft6y9rck5qzlmdf0ygtredsddxavz")
 	if err != nil {
 		panic(err)
@@ -526,7 +526,7 @@ func testDbSyncSubmitTx() {
 	}
 
 	txBuilder := constructTx(syncDB, sender)
-	client := chainscardano.NewDefaultCardanoClient(syncDB, cfg.Chains["cardano-testnet"].SyncDB.SubmitURL, "")
+	client := chainscardano.NewDefaultCardanoClient(syncDB, []string{cfg.Chains["cardano-testnet"].SyncDB.SubmitURL}, "")
 
 	txBuilder.Sign(getKey(os.Getenv("USER"), os.Getenv("PASSWORD"), os.Getenv("MNEMONIC")).PrvKey())
 
@@ -0,0 +1,35 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	// PolicyIdLength is the length of a hex encoded policy id (28 bytes).
+	PolicyIdLength = 56
+	// MaxAssetNameLength is the maximum length of a hex encoded asset name (32 bytes).
+	MaxAssetNameLength = 64
+)
+
+// Asset is a native asset in a transaction output.
+type Asset struct {
+	Unit      string `json:"unit"`
+	PolicyId  string `json:"policy_id"`
+	AssetName string `json:"asset_name"` // hex encoded
+	Quantity  string `json:"quantity"`
+}
+
+// ParseUnit splits the unit of a native asset (policy id concatenated with hex asset name) into
+// policy id and asset name.
+func ParseUnit(unit string) (string, string, error) {
+	if len(unit) < PolicyIdLength || len(unit) > PolicyIdLength+MaxAssetNameLength {
+		return "", "", fmt.Errorf("invalid asset unit length %d", len(unit))
+	}
+
+	if _, err := hex.DecodeString(unit); err != nil {
+		return "", "", fmt.Errorf("asset unit %s is not hex encoded", unit)
+	}
+
+	return unit[:PolicyIdLength], unit[PolicyIdLength:], nil
+}
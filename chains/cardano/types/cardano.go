@@ -10,6 +10,9 @@ type Block struct {
 	// Hash of the block
 	Hash string `json:"hash"`
 
+	// Hash of the previous block
+	PreviousBlock string `json:"previous_block"`
+
 	// Epoch number
 	Epoch int `json:"epoch"`
 
@@ -42,6 +45,23 @@ type TransactionUTXOs struct {
 	Outputs []TransactionUTXOsOutput `json:"outputs"`
 }
 
+// AddressOutput is a transaction output to a watched address with the metadata of its
+// transaction.
+type AddressOutput struct {
+	// Transaction hash
+	TxHash string `json:"tx_hash"`
+
+	// Output index in the transaction
+	OutputIndex int `json:"output_index"`
+
+	// Output address
+	Address string     `json:"address"`
+	Amount  []TxAmount `json:"amount"`
+
+	// Metadata of the transaction, ordered by label
+	Metadata []*TransactionMetadata `json:"metadata"`
+}
+
 type TxAmount struct {
 	// The quantity of the unit
 	Quantity string `json:"quantity"`
@@ -0,0 +1,148 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+// Types of the Ogmios JSON-WSP protocol (v5).
+
+type OgmiosRequest struct {
+	Type        string      `json:"type"`
+	Version     string      `json:"version"`
+	ServiceName string      `json:"servicename"`
+	MethodName  string      `json:"methodname"`
+	Args        interface{} `json:"args,omitempty"`
+}
+
+type OgmiosHealth struct {
+	Version string `json:"version"`
+}
+
+type OgmiosFault struct {
+	Code   string `json:"code"`
+	String string `json:"string"`
+}
+
+type OgmiosResponse struct {
+	Type       string          `json:"type"`
+	MethodName string          `json:"methodname"`
+	Result     json.RawMessage `json:"result"`
+	Fault      *OgmiosFault    `json:"fault"`
+}
+
+type OgmiosPoint struct {
+	Slot uint64 `json:"slot"`
+	Hash string `json:"hash"`
+}
+
+type OgmiosTip struct {
+	Slot    uint64 `json:"slot"`
+	Hash    string `json:"hash"`
+	BlockNo uint64 `json:"blockNo"`
+}
+
+type OgmiosFindIntersectResult struct {
+	IntersectionFound *struct {
+		Point json.RawMessage `json:"point"`
+		Tip   json.RawMessage `json:"tip"`
+	} `json:"IntersectionFound"`
+	IntersectionNotFound *struct {
+		Tip json.RawMessage `json:"tip"`
+	} `json:"IntersectionNotFound"`
+}
+
+type OgmiosRequestNextResult struct {
+	RollForward *struct {
+		// The block is keyed by its era (byron, shelley, allegra, mary, alonzo, babbage).
+		Block map[string]json.RawMessage `json:"block"`
+		Tip   json.RawMessage            `json:"tip"`
+	} `json:"RollForward"`
+	RollBackward *struct {
+		// Either "origin" or an OgmiosPoint.
+		Point json.RawMessage `json:"point"`
+		Tip   json.RawMessage `json:"tip"`
+	} `json:"RollBackward"`
+}
+
+type OgmiosBlockHeader struct {
+	BlockHeight uint64 `json:"blockHeight"`
+	Slot        uint64 `json:"slot"`
+	PrevHash    string `json:"prevHash"`
+}
+
+type OgmiosBlock struct {
+	Body       []*OgmiosTx       `json:"body"`
+	Header     OgmiosBlockHeader `json:"header"`
+	HeaderHash string            `json:"headerHash"`
+}
+
+type OgmiosTx struct {
+	Id       string             `json:"id"`
+	Body     OgmiosTxBody       `json:"body"`
+	Metadata *OgmiosTxAuxiliary `json:"metadata"`
+}
+
+type OgmiosTxBody struct {
+	Inputs  []*OgmiosTxIn  `json:"inputs"`
+	Outputs []*OgmiosTxOut `json:"outputs"`
+}
+
+type OgmiosTxIn struct {
+	TxId  string `json:"txId"`
+	Index uint64 `json:"index"`
+}
+
+type OgmiosTxOut struct {
+	Address string      `json:"address"`
+	Value   OgmiosValue `json:"value"`
+}
+
+type OgmiosValue struct {
+	Coins *big.Int `json:"coins"`
+	// Assets are keyed by "policyId.assetName" or "policyId" if the asset name is empty.
+	Assets map[string]*big.Int `json:"assets"`
+}
+
+type OgmiosTxAuxiliary struct {
+	Hash string `json:"hash"`
+	Body *struct {
+		// Metadatum keyed by label in the detailed schema.
+		Blob map[string]json.RawMessage `json:"blob"`
+	} `json:"body"`
+}
+
+// OgmiosMetadatum is a transaction metadatum in the detailed schema. Only one field is set.
+type OgmiosMetadatum struct {
+	Int    *big.Int          `json:"int"`
+	String *string           `json:"string"`
+	Bytes  *string           `json:"bytes"`
+	List   []json.RawMessage `json:"list"`
+	Map    []struct {
+		K json.RawMessage `json:"k"`
+		V json.RawMessage `json:"v"`
+	} `json:"map"`
+}
+
+type OgmiosProtocolParameters struct {
+	MinFeeCoefficient    uint64 `json:"minFeeCoefficient"`
+	MinFeeConstant       uint64 `json:"minFeeConstant"`
+	MaxBlockBodySize     uint64 `json:"maxBlockBodySize"`
+	MaxBlockHeaderSize   uint64 `json:"maxBlockHeaderSize"`
+	MaxTxSize            uint64 `json:"maxTxSize"`
+	StakeKeyDeposit      uint64 `json:"stakeKeyDeposit"`
+	PoolDeposit          uint64 `json:"poolDeposit"`
+	PoolRetirementEpoch  uint64 `json:"poolRetirementEpochBound"`
+	DesiredNumberOfPools uint64 `json:"desiredNumberOfPools"`
+	// Babbage
+	CoinsPerUtxoByte uint64 `json:"coinsPerUtxoByte"`
+	// Alonzo
+	CoinsPerUtxoWord uint64 `json:"coinsPerUtxoWord"`
+}
+
+type OgmiosSubmitTxResult struct {
+	SubmitSuccess *struct {
+		TxId string `json:"txId"`
+	} `json:"SubmitSuccess"`
+	SubmitFail json.RawMessage `json:"SubmitFail"`
+}
@@ -30,10 +30,15 @@ type Watcher struct {
 	cfg             config.Chain
 	db              database.Database
 	txsCh           chan *types.Txs
+	revertedCh      chan *types.RevertedTxs
 	client          CardanoClient
 	blockTime       int
 	lastBlockHeight atomic.Int32
 	vault           string
+	whitelist       *assetWhitelist
+	network         Network
+	// Recent scanned blocks, used to detect rollbacks. Only accessed by scanBlocks.
+	recentBlocks []*scannedBlock
 
 	txTrackCh    chan *chainstypes.TrackUpdate
 	lock         *sync.RWMutex
@@ -41,16 +46,24 @@ type Watcher struct {
 }
 
 func NewWatcher(cfg config.Chain, db database.Database, txsCh chan *types.Txs,
-	txTrackCh chan *chainstypes.TrackUpdate, client CardanoClient) *Watcher {
+	revertedCh chan *types.RevertedTxs, txTrackCh chan *chainstypes.TrackUpdate, client CardanoClient) *Watcher {
+	network, err := GetNetwork(cfg)
+	if err != nil {
+		panic(err)
+	}
+
 	return &Watcher{
 		cfg:          cfg,
 		db:           db,
 		txsCh:        txsCh,
+		revertedCh:   revertedCh,
 		blockTime:    cfg.BlockTime,
 		txTrackCh:    txTrackCh,
 		lock:         &sync.RWMutex{},
 		client:       client,
 		txTrackCache: lru.New(1000),
+		whitelist:    newAssetWhitelist(cfg.CardanoAssetWhitelist),
+		network:      network,
 	}
 }
 
@@ -68,6 +81,10 @@ func (w *Watcher) init() {
 	}
 
 	if len(vaults) > 0 {
+		if err := w.network.ValidateAddress(vaults[0]); err != nil {
+			panic(fmt.Errorf("Saved vault for chain %s is invalid, err = %v", w.cfg.Chain, err))
+		}
+
 		w.vault = vaults[0]
 		log.Infof("Saved gateway in the db for chain %s is %s", w.cfg.Chain, w.vault)
 	} else {
@@ -109,6 +126,10 @@ func (w *Watcher) scanBlocks() {
 			continue
 		}
 
+		if w.handleRollback(block) {
+			continue
+		}
+
 		// Process each address in the interested addr.
 		txArr := make([]*types.Tx, 0)
 		txsIn, err := w.client.NewTxs(block.Height, w.vault)
@@ -123,6 +144,9 @@ func (w *Watcher) scanBlocks() {
 		w.lastBlockHeight.Store(int32(block.Height))
 		w.blockTime = w.blockTime - w.cfg.AdjustTime/4
 
+		scanned := &scannedBlock{block: block}
+		w.addScannedBlock(scanned)
+
 		if len(w.vault) == 0 {
 			log.Verbose("Gateway is still empty")
 			continue
@@ -131,6 +155,10 @@ func (w *Watcher) scanBlocks() {
 		log.Verbose("Filtered txs sizes = ", len(txsIn), " on chain ", w.cfg.Chain)
 
 		for _, txIn := range txsIn {
+			if _, ok := w.txTrackCache.Get(txIn.Hash); !ok {
+				parseDeposit(txIn, w.whitelist)
+			}
+
 			bz, err := json.Marshal(txIn)
 			if err != nil {
 				log.Error("Cannot serialize utxo, err = ", err)
@@ -148,6 +176,8 @@ func (w *Watcher) scanBlocks() {
 					BlockHeight: int64(block.Height),
 					Result:      chainstypes.TrackResultConfirmed,
 				}
+				scanned.trackedHashes = append(scanned.trackedHashes, txIn.Hash)
+				w.setUtxosSpent(txIn.Hash, true)
 
 				continue
 			}
@@ -161,6 +191,8 @@ func (w *Watcher) scanBlocks() {
 			})
 		}
 
+		scanned.txs = txArr
+
 		if len(txArr) > 0 {
 			txs := types.Txs{
 				Chain:     w.cfg.Chain,
@@ -199,6 +231,11 @@ func (w *Watcher) getNextBlock() (*providertypes.Block, error) {
 }
 
 func (w *Watcher) SetVault(addr string, token string) {
+	if err := w.network.ValidateAddress(addr); err != nil {
+		log.Errorf("Cannot set vault for chain %s, err = %v", w.cfg.Chain, err)
+		return
+	}
+
 	w.lock.Lock()
 	defer w.lock.Unlock()
 
@@ -220,12 +257,34 @@ func (w *Watcher) ProtocolParams() (*cardanogo.ProtocolParams, error) {
 }
 
 func (w *Watcher) CardanoUtxos(addr string, maxBlock uint64) ([]cardanogo.UTxO, error) {
-	return w.client.AddressUTXOs(context.Background(), addr, providertypes.APIQueryParams{
+	if err := w.network.ValidateAddress(addr); err != nil {
+		return nil, err
+	}
+
+	utxos, err := w.client.AddressUTXOs(context.Background(), addr, providertypes.APIQueryParams{
 		To: fmt.Sprint(maxBlock),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	w.lock.RLock()
+	isVault := addr == w.vault
+	w.lock.RUnlock()
+
+	// Vault utxos reserved by pending transactions cannot be used for new transfers.
+	if isVault {
+		return w.filterAvailableUtxos(addr, utxos)
+	}
+
+	return utxos, nil
 }
 
 func (w *Watcher) Balance(address string, maxBlock int64) (*cardanogo.Value, error) {
+	if err := w.network.ValidateAddress(address); err != nil {
+		return nil, err
+	}
+
 	return w.client.Balance(address, maxBlock)
 }
 
@@ -235,5 +294,37 @@ func (w *Watcher) Tip(maxBlock uint64) (*cardanogo.NodeTip, error) {
 }
 
 func (w *Watcher) SubmitTx(tx *cardanogo.Tx) (*cardanogo.Hash32, error) {
-	return w.client.SubmitTx(tx)
+	hash, err := w.client.SubmitTx(tx)
+	if err != nil {
+		txHash, hashErr := tx.Hash()
+		if hashErr != nil {
+			return nil, err
+		}
+
+		if IsAlreadySubmitted(err) {
+			return &txHash, nil
+		}
+
+		// A transaction that is resubmitted after it was confirmed is rejected because its inputs
+		// are spent.
+		if submitErr, ok := err.(*SubmitTxError); ok && submitErr.Code == types.ErrInputsSpent {
+			confirmed, confirmErr := w.client.IsTxConfirmed(txHash.String())
+			if confirmErr != nil {
+				log.Warnf("Failed to check if cardano tx %s is confirmed, err = %v", txHash, confirmErr)
+			} else if confirmed {
+				log.Infof("Cardano tx %s is already confirmed", txHash)
+				return &txHash, nil
+			}
+		}
+
+		// The utxos reserved for this transaction can be used by other transactions. If the result of
+		// the submission is unknown, the transaction may still be included so the reservation is kept
+		// until it expires. Spent inputs keep their reservation too since they are marked as spent
+		// when the transaction that spends them is confirmed.
+		if IsRejected(err) {
+			w.ReleaseUtxos(txHash.String())
+		}
+	}
+
+	return hash, err
 }
@@ -0,0 +1,126 @@
+package cosmos
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sisu-network/deyes/chains/cosmos/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/lib/log"
+	"go.uber.org/atomic"
+)
+
+const (
+	MinWaitTime = 500 // 500ms
+)
+
+type BlockHeightExceededError struct {
+	ChainHeight int64
+}
+
+func NewBlockHeightExceededError(chainHeight int64) error {
+	return &BlockHeightExceededError{
+		ChainHeight: chainHeight,
+	}
+}
+
+func (e *BlockHeightExceededError) Error() string {
+	return fmt.Sprintf("Our block height is higher than chain's height. Chain height = %d", e.ChainHeight)
+}
+
+type defaultBlockFetcher struct {
+	blockHeight int64
+	blockTime   int
+	cfg         config.Chain
+	client      Client
+	blockCh     chan *types.Block
+	done        atomic.Bool
+}
+
+func newBlockFetcher(cfg config.Chain, blockCh chan *types.Block, client Client) *defaultBlockFetcher {
+	return &defaultBlockFetcher{
+		blockCh:   blockCh,
+		cfg:       cfg,
+		client:    client,
+		blockTime: cfg.BlockTime,
+	}
+}
+
+func (bf *defaultBlockFetcher) start() {
+	bf.setBlockHeight()
+	bf.scanBlocks()
+}
+
+func (bf *defaultBlockFetcher) stop() {
+	bf.done.Store(true)
+}
+
+func (bf *defaultBlockFetcher) setBlockHeight() {
+	for {
+		number, err := bf.client.BlockNumber()
+		if err != nil {
+			log.Errorf("cannot get latest block number for chain %s. Sleeping for a few seconds", bf.cfg.Chain)
+			time.Sleep(time.Second * 5)
+			continue
+		}
+
+		bf.blockHeight = number
+		break
+	}
+
+	log.Info("Watching from block ", bf.blockHeight, " for chain ", bf.cfg.Chain)
+}
+
+func (bf *defaultBlockFetcher) scanBlocks() {
+	for {
+		if bf.done.Load() {
+			return
+		}
+
+		log.Verbose("Block time on chain ", bf.cfg.Chain, " is ", bf.blockTime)
+
+		block, err := bf.tryGetBlock()
+		if err != nil || block == nil {
+			if _, ok := err.(*BlockHeightExceededError); !ok {
+				log.Errorf("Cannot get block at height %d for chain %s, err = %v", bf.blockHeight,
+					bf.cfg.Chain, err)
+			}
+
+			bf.blockTime = bf.blockTime + bf.cfg.AdjustTime
+			time.Sleep(time.Duration(bf.blockTime) * time.Millisecond)
+			continue
+		}
+
+		bf.blockCh <- block
+		bf.blockHeight++
+
+		if bf.blockTime-bf.cfg.AdjustTime/4 > MinWaitTime {
+			bf.blockTime = bf.blockTime - bf.cfg.AdjustTime/4
+		}
+		time.Sleep(time.Duration(bf.blockTime) * time.Millisecond)
+	}
+}
+
+// tryGetBlock returns the block at the current height if it has been committed.
+func (bf *defaultBlockFetcher) tryGetBlock() (*types.Block, error) {
+	number, err := bf.client.BlockNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	if number < bf.blockHeight {
+		return nil, NewBlockHeightExceededError(number)
+	}
+
+	block, err := bf.client.BlockByHeight(bf.blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	if number-bf.blockHeight > 5 {
+		// We are behind, fetch the next blocks faster.
+		bf.blockTime = MinWaitTime
+	}
+
+	return block, nil
+}
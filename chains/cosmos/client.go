@@ -0,0 +1,147 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/sisu-network/deyes/chains/cosmos/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/lib/log"
+	"github.com/ybbus/jsonrpc/v3"
+)
+
+var (
+	RpcTimeOut = time.Second * 10
+)
+
+// Client is a wrapper around CometBFT RPC so that we can mock it in watcher tests.
+type Client interface {
+	BlockNumber() (int64, error)
+	BlockByHeight(height int64) (*types.Block, error)
+	BroadcastTxSync(tx []byte) (*types.BroadcastTxResult, error)
+}
+
+type defaultClient struct {
+	chain   string
+	rpcs    []string
+	clients []jsonrpc.RPCClient
+}
+
+func NewCosmosClient(cfg config.Chain) Client {
+	clients := make([]jsonrpc.RPCClient, 0, len(cfg.Rpcs))
+	for _, url := range cfg.Rpcs {
+		clients = append(clients, jsonrpc.NewClient(url))
+	}
+
+	return &defaultClient{
+		chain:   cfg.Chain,
+		rpcs:    cfg.Rpcs,
+		clients: clients,
+	}
+}
+
+// call executes a CometBFT RPC method on a random healthy rpc. If the rpc fails with a transport
+// error, the next rpc is tried.
+func (c *defaultClient) call(method string, params interface{}, result interface{}) error {
+	if len(c.clients) == 0 {
+		return fmt.Errorf("no rpc configured for chain %s", c.chain)
+	}
+
+	start := rand.Intn(len(c.clients))
+	var err error
+	for i := 0; i < len(c.clients); i++ {
+		index := (start + i) % len(c.clients)
+
+		ctx, cancel := context.WithTimeout(context.Background(), RpcTimeOut)
+		var response *jsonrpc.RPCResponse
+		if params == nil {
+			response, err = c.clients[index].Call(ctx, method)
+		} else {
+			response, err = c.clients[index].Call(ctx, method, params)
+		}
+		cancel()
+
+		if err != nil {
+			if _, ok := err.(*jsonrpc.RPCError); ok {
+				// The node processed our request and returned an error, no need to try other rpcs.
+				return err
+			}
+
+			log.Warnf("Failed to call %s on rpc %s, err = %v", method, c.rpcs[index], err)
+			continue
+		}
+
+		return response.GetObject(result)
+	}
+
+	return err
+}
+
+func (c *defaultClient) BlockNumber() (int64, error) {
+	status := &types.StatusResult{}
+	if err := c.call("status", nil, status); err != nil {
+		return 0, err
+	}
+
+	return status.Height()
+}
+
+func (c *defaultClient) BlockByHeight(height int64) (*types.Block, error) {
+	params := map[string]string{
+		"height": strconv.FormatInt(height, 10),
+	}
+
+	blockResult := &types.BlockResult{}
+	if err := c.call("block", params, blockResult); err != nil {
+		return nil, err
+	}
+
+	block := &types.Block{
+		Height: height,
+		Hash:   blockResult.BlockId.Hash,
+		Time:   blockResult.Block.Header.Time,
+		Txs:    make([][]byte, 0, len(blockResult.Block.Data.Txs)),
+	}
+
+	for _, encoded := range blockResult.Block.Data.Txs {
+		bz, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tx in block %d, err = %v", height, err)
+		}
+		block.Txs = append(block.Txs, bz)
+	}
+
+	if len(block.Txs) == 0 {
+		return block, nil
+	}
+
+	results := &types.BlockResultsResult{}
+	if err := c.call("block_results", params, results); err != nil {
+		return nil, err
+	}
+
+	if len(results.TxsResults) != len(block.Txs) {
+		return nil, fmt.Errorf("tx results length does not match txs length at block %d, %d vs %d",
+			height, len(results.TxsResults), len(block.Txs))
+	}
+	block.TxResults = results.TxsResults
+
+	return block, nil
+}
+
+func (c *defaultClient) BroadcastTxSync(tx []byte) (*types.BroadcastTxResult, error) {
+	params := map[string]string{
+		"tx": base64.StdEncoding.EncodeToString(tx),
+	}
+
+	result := &types.BroadcastTxResult{}
+	if err := c.call("broadcast_tx_sync", params, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
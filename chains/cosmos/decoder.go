@@ -0,0 +1,188 @@
+package cosmos
+
+import (
+	"fmt"
+
+	"github.com/sisu-network/deyes/chains/cosmos/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// We only need a handful of messages from the Cosmos SDK and IBC modules. Instead of pulling the
+// whole SDK as a dependency, we decode the protobuf wire format of these messages directly.
+
+// forEachField iterates through all top level fields of a protobuf encoded message.
+func forEachField(bz []byte, f func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		bz = bz[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(bz)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(bz)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, bz)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		bz = bz[n:]
+
+		if err := f(num, typ, value, varint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DecodeTx decodes a raw transaction (TxRaw) and returns its body.
+func DecodeTx(bz []byte) (*types.Tx, error) {
+	var bodyBytes []byte
+	err := forEachField(bz, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if num == 1 && typ == protowire.BytesType {
+			bodyBytes = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tx raw, err = %v", err)
+	}
+
+	tx := &types.Tx{}
+	err = forEachField(bodyBytes, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			msg, err := decodeAny(value)
+			if err != nil {
+				return err
+			}
+			tx.Messages = append(tx.Messages, msg)
+		case num == 2 && typ == protowire.BytesType:
+			tx.Memo = string(value)
+		case num == 3 && typ == protowire.VarintType:
+			tx.TimeoutHeight = varint
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode tx body, err = %v", err)
+	}
+
+	return tx, nil
+}
+
+func decodeAny(bz []byte) (*types.Any, error) {
+	ret := &types.Any{}
+	err := forEachField(bz, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+
+		switch num {
+		case 1:
+			ret.TypeUrl = string(value)
+		case 2:
+			ret.Value = value
+		}
+		return nil
+	})
+
+	return ret, err
+}
+
+func decodeCoin(bz []byte) (types.Coin, error) {
+	coin := types.Coin{}
+	err := forEachField(bz, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+
+		switch num {
+		case 1:
+			coin.Denom = string(value)
+		case 2:
+			coin.Amount = string(value)
+		}
+		return nil
+	})
+
+	return coin, err
+}
+
+func DecodeMsgSend(bz []byte) (*types.MsgSend, error) {
+	msg := &types.MsgSend{}
+	err := forEachField(bz, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+
+		switch num {
+		case 1:
+			msg.FromAddress = string(value)
+		case 2:
+			msg.ToAddress = string(value)
+		case 3:
+			coin, err := decodeCoin(value)
+			if err != nil {
+				return err
+			}
+			msg.Amount = append(msg.Amount, coin)
+		}
+		return nil
+	})
+
+	return msg, err
+}
+
+func DecodeMsgRecvPacket(bz []byte) (*types.MsgRecvPacket, error) {
+	msg := &types.MsgRecvPacket{}
+	err := forEachField(bz, func(num protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+
+		switch num {
+		case 1:
+			packet, err := decodePacket(value)
+			if err != nil {
+				return err
+			}
+			msg.Packet = packet
+		case 4:
+			msg.Signer = string(value)
+		}
+		return nil
+	})
+
+	return msg, err
+}
+
+func decodePacket(bz []byte) (*types.Packet, error) {
+	packet := &types.Packet{}
+	err := forEachField(bz, func(num protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			packet.Sequence = varint
+		case num == 2 && typ == protowire.BytesType:
+			packet.SourcePort = string(value)
+		case num == 3 && typ == protowire.BytesType:
+			packet.SourceChannel = string(value)
+		case num == 4 && typ == protowire.BytesType:
+			packet.DestinationPort = string(value)
+		case num == 5 && typ == protowire.BytesType:
+			packet.DestinationChannel = string(value)
+		case num == 6 && typ == protowire.BytesType:
+			packet.Data = value
+		}
+		return nil
+	})
+
+	return packet, err
+}
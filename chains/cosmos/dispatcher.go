@@ -0,0 +1,94 @@
+package cosmos
+
+import (
+	"github.com/sisu-network/deyes/chains"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+// ABCI error codes of the Cosmos SDK ("sdk" codespace).
+const (
+	CodeOK                = 0
+	CodeTxDecode          = 2
+	CodeInsufficientFunds = 5
+	CodeInsufficientFee   = 13
+	CodeTxInMempoolCache  = 19
+	CodeWrongSequence     = 32
+)
+
+const CodespaceSdk = "sdk"
+
+type CosmosDispatcher struct {
+	chain  string
+	client Client
+}
+
+func NewDispatcher(chain string, client Client) chains.Dispatcher {
+	return &CosmosDispatcher{
+		chain:  chain,
+		client: client,
+	}
+}
+
+func (d *CosmosDispatcher) Start() {
+}
+
+func (d *CosmosDispatcher) Dispatch(request *types.DispatchedTxRequest) *types.DispatchedTxResult {
+	result, err := d.client.BroadcastTxSync(request.Tx)
+	if err != nil {
+		log.Errorf("Failed to broadcast cosmos tx, err = %v", err)
+		return types.NewDispatchTxError(request, types.ErrSubmitTx)
+	}
+
+	txHash := result.Hash
+	if len(txHash) == 0 {
+		txHash = request.TxHash
+	}
+
+	dispatchErr := abciCodeToDispatchError(result.Codespace, result.Code)
+	if dispatchErr != types.ErrNil {
+		log.Errorf("Cosmos tx %s is rejected, codespace = %s, code = %d, log = %s", txHash,
+			result.Codespace, result.Code, result.Log)
+		return &types.DispatchedTxResult{
+			Success: false,
+			Err:     dispatchErr,
+			Chain:   request.Chain,
+			TxHash:  txHash,
+		}
+	}
+
+	log.Verbose("Cosmos tx is dispatched successfully, hash = ", txHash)
+
+	return &types.DispatchedTxResult{
+		Success: true,
+		Chain:   request.Chain,
+		TxHash:  txHash,
+	}
+}
+
+func abciCodeToDispatchError(codespace string, code uint32) types.DispatchError {
+	if code == CodeOK {
+		return types.ErrNil
+	}
+
+	if codespace != CodespaceSdk {
+		return types.ErrSubmitTx
+	}
+
+	switch code {
+	case CodeTxInMempoolCache:
+		// Another node has submitted the same transaction. This is counted as a successful
+		// submission.
+		return types.ErrNil
+	case CodeTxDecode:
+		return types.ErrMarshal
+	case CodeInsufficientFunds:
+		return types.ErrNotEnoughBalance
+	case CodeInsufficientFee:
+		return types.ErrInsufficientFee
+	case CodeWrongSequence:
+		return types.ErrNonceNotMatched
+	default:
+		return types.ErrSubmitTx
+	}
+}
@@ -0,0 +1,51 @@
+package cosmos
+
+import (
+	"testing"
+
+	cosmostypes "github.com/sisu-network/deyes/chains/cosmos/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCosmosDispatcher_Dispatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      uint32
+		codespace string
+		success   bool
+		err       types.DispatchError
+	}{
+		{name: "ok", code: 0, success: true, err: types.ErrNil},
+		{name: "already_in_mempool", code: CodeTxInMempoolCache, codespace: CodespaceSdk, success: true, err: types.ErrNil},
+		{name: "insufficient_funds", code: CodeInsufficientFunds, codespace: CodespaceSdk, err: types.ErrNotEnoughBalance},
+		{name: "insufficient_fee", code: CodeInsufficientFee, codespace: CodespaceSdk, err: types.ErrInsufficientFee},
+		{name: "wrong_sequence", code: CodeWrongSequence, codespace: CodespaceSdk, err: types.ErrNonceNotMatched},
+		{name: "other_codespace", code: 5, codespace: "wasm", err: types.ErrSubmitTx},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &MockCosmosClient{
+				BroadcastTxSyncFunc: func(tx []byte) (*cosmostypes.BroadcastTxResult, error) {
+					return &cosmostypes.BroadcastTxResult{
+						Code:      tc.code,
+						Codespace: tc.codespace,
+						Hash:      cosmostypes.TxHash(tx),
+					}, nil
+				},
+			}
+
+			txBz := []byte("tx")
+			dispatcher := NewDispatcher("cosmos-testnet", client)
+			result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+				Chain: "cosmos-testnet",
+				Tx:    txBz,
+			})
+
+			require.Equal(t, tc.success, result.Success)
+			require.Equal(t, tc.err, result.Err)
+			require.Equal(t, cosmostypes.TxHash(txBz), result.TxHash)
+		})
+	}
+}
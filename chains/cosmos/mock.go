@@ -0,0 +1,35 @@
+package cosmos
+
+import (
+	"github.com/sisu-network/deyes/chains/cosmos/types"
+)
+
+type MockCosmosClient struct {
+	BlockNumberFunc     func() (int64, error)
+	BlockByHeightFunc   func(height int64) (*types.Block, error)
+	BroadcastTxSyncFunc func(tx []byte) (*types.BroadcastTxResult, error)
+}
+
+func (c *MockCosmosClient) BlockNumber() (int64, error) {
+	if c.BlockNumberFunc != nil {
+		return c.BlockNumberFunc()
+	}
+
+	return 0, nil
+}
+
+func (c *MockCosmosClient) BlockByHeight(height int64) (*types.Block, error) {
+	if c.BlockByHeightFunc != nil {
+		return c.BlockByHeightFunc(height)
+	}
+
+	return nil, nil
+}
+
+func (c *MockCosmosClient) BroadcastTxSync(tx []byte) (*types.BroadcastTxResult, error) {
+	if c.BroadcastTxSyncFunc != nil {
+		return c.BroadcastTxSyncFunc(tx)
+	}
+
+	return nil, nil
+}
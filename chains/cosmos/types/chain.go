@@ -0,0 +1,13 @@
+package types
+
+var (
+	CosmosChains = map[string]bool{
+		"cosmos-devnet":  true,
+		"cosmos-testnet": true,
+		"cosmos-mainnet": true,
+	}
+)
+
+func IsCosmosChain(chain string) bool {
+	return CosmosChains[chain]
+}
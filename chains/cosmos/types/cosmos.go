@@ -0,0 +1,127 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	TypeUrlMsgSend       = "/cosmos.bank.v1beta1.MsgSend"
+	TypeUrlMsgRecvPacket = "/ibc.core.channel.v1.MsgRecvPacket"
+
+	DepositTypeSend       = "send"
+	DepositTypeIbcReceive = "ibc_receive"
+)
+
+// Block is a CometBFT block together with the execution results of its transactions.
+type Block struct {
+	Height    int64
+	Hash      string
+	Time      string
+	Txs       [][]byte
+	TxResults []*TxResult
+}
+
+type Coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type Any struct {
+	TypeUrl string
+	Value   []byte
+}
+
+// Tx is the decoded body of a raw Cosmos SDK transaction.
+type Tx struct {
+	Messages      []*Any
+	Memo          string
+	TimeoutHeight uint64
+}
+
+type MsgSend struct {
+	FromAddress string
+	ToAddress   string
+	Amount      []Coin
+}
+
+type Packet struct {
+	Sequence           uint64
+	SourcePort         string
+	SourceChannel      string
+	DestinationPort    string
+	DestinationChannel string
+	Data               []byte
+}
+
+type MsgRecvPacket struct {
+	Packet *Packet
+	Signer string
+}
+
+// FungibleTokenPacketData is the ICS-20 packet payload. It is JSON encoded in the packet data.
+type FungibleTokenPacketData struct {
+	Denom    string `json:"denom"`
+	Amount   string `json:"amount"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+	Memo     string `json:"memo,omitempty"`
+}
+
+// ReceivedDenom returns the denom of the voucher minted (or unescrowed) on the receiving chain for
+// this ICS-20 packet.
+func (p *Packet) ReceivedDenom(denom string) string {
+	prefix := fmt.Sprintf("%s/%s/", p.SourcePort, p.SourceChannel)
+	if strings.HasPrefix(denom, prefix) {
+		// The token is returning to its origin chain.
+		unprefixed := denom[len(prefix):]
+		if strings.Contains(unprefixed, "/") {
+			return IbcDenom(unprefixed)
+		}
+
+		return unprefixed
+	}
+
+	return IbcDenom(fmt.Sprintf("%s/%s/%s", p.DestinationPort, p.DestinationChannel, denom))
+}
+
+// IbcDenom returns the "ibc/{hash}" denom of a denomination trace path.
+func IbcDenom(path string) string {
+	hash := sha256.Sum256([]byte(path))
+	return "ibc/" + strings.ToUpper(hex.EncodeToString(hash[:]))
+}
+
+// Deposit is a structured transfer to the vault found in a Cosmos transaction.
+type Deposit struct {
+	Type     string `json:"type"`
+	MsgIndex int    `json:"msg_index"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   []Coin `json:"amount"`
+	// Memo is used as routing metadata. For IBC receives it is the memo of the packet, otherwise it
+	// is the memo of the transaction.
+	Memo string `json:"memo"`
+
+	// IBC only
+	SourcePort         string `json:"source_port,omitempty"`
+	SourceChannel      string `json:"source_channel,omitempty"`
+	DestinationPort    string `json:"destination_port,omitempty"`
+	DestinationChannel string `json:"destination_channel,omitempty"`
+	Sequence           uint64 `json:"sequence,omitempty"`
+}
+
+// Transaction is the data model of a Cosmos transaction that we send to Sisu.
+type Transaction struct {
+	Hash     string     `json:"hash"`
+	Height   int64      `json:"height"`
+	Memo     string     `json:"memo"`
+	Deposits []*Deposit `json:"deposits"`
+}
+
+// TxHash returns the CometBFT hash of a raw transaction.
+func TxHash(bz []byte) string {
+	hash := sha256.Sum256(bz)
+	return strings.ToUpper(hex.EncodeToString(hash[:]))
+}
@@ -0,0 +1,113 @@
+package types
+
+import (
+	"encoding/base64"
+	"strconv"
+	"unicode"
+)
+
+type StatusResult struct {
+	SyncInfo struct {
+		LatestBlockHeight string `json:"latest_block_height"`
+		LatestBlockHash   string `json:"latest_block_hash"`
+		CatchingUp        bool   `json:"catching_up"`
+	} `json:"sync_info"`
+}
+
+func (s *StatusResult) Height() (int64, error) {
+	return strconv.ParseInt(s.SyncInfo.LatestBlockHeight, 10, 64)
+}
+
+type BlockResult struct {
+	BlockId struct {
+		Hash string `json:"hash"`
+	} `json:"block_id"`
+	Block struct {
+		Header struct {
+			ChainId string `json:"chain_id"`
+			Height  string `json:"height"`
+			Time    string `json:"time"`
+		} `json:"header"`
+		Data struct {
+			// Base64 encoded raw transactions.
+			Txs []string `json:"txs"`
+		} `json:"data"`
+	} `json:"block"`
+}
+
+type BlockResultsResult struct {
+	Height     string      `json:"height"`
+	TxsResults []*TxResult `json:"txs_results"`
+}
+
+type TxResult struct {
+	Code      uint32  `json:"code"`
+	Codespace string  `json:"codespace"`
+	Log       string  `json:"log"`
+	Events    []Event `json:"events"`
+}
+
+type Event struct {
+	Type       string           `json:"type"`
+	Attributes []EventAttribute `json:"attributes"`
+}
+
+type EventAttribute struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Decoded returns the key and value of the attribute. Tendermint 0.34 nodes return base64 encoded
+// attributes while CometBFT 0.37+ nodes return plain strings.
+func (a EventAttribute) Decoded() (string, string) {
+	key, ok := decodeBase64Text(a.Key)
+	if !ok {
+		return a.Key, a.Value
+	}
+
+	if len(a.Value) == 0 {
+		return key, ""
+	}
+
+	value, ok := decodeBase64Text(a.Value)
+	if !ok {
+		return a.Key, a.Value
+	}
+
+	return key, value
+}
+
+// Get returns the value of the first attribute with the given key in this event.
+func (e Event) Get(key string) (string, bool) {
+	for _, attr := range e.Attributes {
+		k, v := attr.Decoded()
+		if k == key {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+func decodeBase64Text(s string) (string, bool) {
+	bz, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(bz) == 0 {
+		return "", false
+	}
+
+	for _, r := range string(bz) {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+
+	return string(bz), true
+}
+
+type BroadcastTxResult struct {
+	Code      uint32 `json:"code"`
+	Data      string `json:"data"`
+	Log       string `json:"log"`
+	Codespace string `json:"codespace"`
+	Hash      string `json:"hash"`
+}
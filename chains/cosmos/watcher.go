@@ -0,0 +1,311 @@
+package cosmos
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/sisu-network/deyes/chains"
+	cosmostypes "github.com/sisu-network/deyes/chains/cosmos/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+const (
+	TxTrackCacheSize = 1_000
+)
+
+type Watcher struct {
+	cfg          config.Chain
+	client       Client
+	db           database.Database
+	vault        string
+	txTrackCache *lru.Cache
+	lock         *sync.RWMutex
+	txsCh        chan *types.Txs
+	txTrackCh    chan *chainstypes.TrackUpdate
+	doneCh       chan bool
+
+	// Block fetcher
+	blockCh      chan *cosmostypes.Block
+	blockFetcher *defaultBlockFetcher
+}
+
+func NewWatcher(db database.Database, cfg config.Chain, txsCh chan *types.Txs,
+	txTrackCh chan *chainstypes.TrackUpdate, client Client) chains.Watcher {
+	blockCh := make(chan *cosmostypes.Block)
+
+	return &Watcher{
+		blockCh:      blockCh,
+		blockFetcher: newBlockFetcher(cfg, blockCh, client),
+		db:           db,
+		cfg:          cfg,
+		txsCh:        txsCh,
+		txTrackCh:    txTrackCh,
+		client:       client,
+		lock:         &sync.RWMutex{},
+		txTrackCache: lru.New(TxTrackCacheSize),
+		doneCh:       make(chan bool),
+	}
+}
+
+func (w *Watcher) init() {
+	vaults, err := w.db.GetVaults(w.cfg.Chain)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(vaults) > 0 {
+		w.vault = vaults[0]
+		log.Infof("Saved gateway in the db for chain %s is %s", w.cfg.Chain, w.vault)
+	} else {
+		log.Infof("Vault for chain %s is not set yet", w.cfg.Chain)
+	}
+}
+
+func (w *Watcher) SetVault(addr string, token string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	log.Verbosef("Setting vault for chain %s with address %s", w.cfg.Chain, addr)
+	err := w.db.SetVault(w.cfg.Chain, addr, token)
+	if err == nil {
+		w.vault = strings.ToLower(addr)
+	} else {
+		log.Error("Failed to save vault")
+	}
+}
+
+func (w *Watcher) getVault() string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.vault
+}
+
+func (w *Watcher) Start() {
+	log.Infof("Starting Watcher for chain %s", w.cfg.Chain)
+	w.init()
+
+	go w.blockFetcher.start()
+	go w.waitForBlock()
+}
+
+func (w *Watcher) Stop() {
+	w.blockFetcher.stop()
+	w.doneCh <- true
+}
+
+func (w *Watcher) waitForBlock() {
+	for {
+		select {
+		case <-w.doneCh:
+			return
+
+		case block := <-w.blockCh:
+			log.Info(w.cfg.Chain, " Block length = ", len(block.Txs))
+			w.processBlock(block)
+		}
+	}
+}
+
+func (w *Watcher) processBlock(block *cosmostypes.Block) {
+	txArr := make([]*types.Tx, 0)
+	vault := w.getVault()
+
+	for i, bz := range block.Txs {
+		hash := cosmostypes.TxHash(bz)
+		var txResult *cosmostypes.TxResult
+		if i < len(block.TxResults) {
+			txResult = block.TxResults[i]
+		}
+
+		if _, ok := w.txTrackCache.Get(hash); ok {
+			log.Verbose("Confirming cosmos tx with hash = ", hash)
+
+			result := chainstypes.TrackResultConfirmed
+			if txResult == nil || txResult.Code != 0 {
+				result = chainstypes.TrackResultFailure
+			}
+
+			// This is a transaction that we are tracking. Inform Sisu about this.
+			w.txTrackCh <- &chainstypes.TrackUpdate{
+				Chain:       w.cfg.Chain,
+				Bytes:       bz,
+				Hash:        hash,
+				BlockHeight: block.Height,
+				Result:      result,
+			}
+
+			continue
+		}
+
+		if len(vault) == 0 || txResult == nil || txResult.Code != 0 {
+			continue
+		}
+
+		tx, err := DecodeTx(bz)
+		if err != nil {
+			log.Errorf("Failed to decode cosmos tx %s, err = %v", hash, err)
+			continue
+		}
+
+		deposits := w.extractDeposits(tx, txResult, vault)
+		if len(deposits) == 0 {
+			continue
+		}
+
+		log.Infof("Found %d deposit(s) to the vault in cosmos tx %s", len(deposits), hash)
+		serialized, err := json.Marshal(&cosmostypes.Transaction{
+			Hash:     hash,
+			Height:   block.Height,
+			Memo:     tx.Memo,
+			Deposits: deposits,
+		})
+		if err != nil {
+			log.Errorf("Failed to marshal cosmos transaction, err = %v", err)
+			continue
+		}
+
+		txArr = append(txArr, &types.Tx{
+			Hash:       hash,
+			Serialized: serialized,
+			From:       deposits[0].From,
+			To:         vault,
+			Success:    true,
+		})
+	}
+
+	if len(txArr) > 0 {
+		w.txsCh <- &types.Txs{
+			Chain:     w.cfg.Chain,
+			Block:     block.Height,
+			BlockHash: block.Hash,
+			Arr:       txArr,
+		}
+	}
+}
+
+// extractDeposits returns all the transfers in a transaction whose recipient is the vault. An
+// outgoing IBC MsgTransfer is not a deposit even if its receiver is the vault: the funds leave this
+// chain and the transfer is credited by the MsgRecvPacket on the destination chain.
+func (w *Watcher) extractDeposits(tx *cosmostypes.Tx, txResult *cosmostypes.TxResult, vault string) []*cosmostypes.Deposit {
+	deposits := make([]*cosmostypes.Deposit, 0)
+
+	for i, msg := range tx.Messages {
+		switch msg.TypeUrl {
+		case cosmostypes.TypeUrlMsgSend:
+			send, err := DecodeMsgSend(msg.Value)
+			if err != nil {
+				log.Errorf("Failed to decode MsgSend, err = %v", err)
+				continue
+			}
+
+			if !strings.EqualFold(send.ToAddress, vault) {
+				continue
+			}
+
+			deposits = append(deposits, &cosmostypes.Deposit{
+				Type:     cosmostypes.DepositTypeSend,
+				MsgIndex: i,
+				From:     send.FromAddress,
+				To:       send.ToAddress,
+				Amount:   send.Amount,
+				Memo:     tx.Memo,
+			})
+
+		case cosmostypes.TypeUrlMsgRecvPacket:
+			deposit := w.extractIbcReceive(msg, txResult, vault)
+			if deposit != nil {
+				deposit.MsgIndex = i
+				deposits = append(deposits, deposit)
+			}
+		}
+	}
+
+	return deposits
+}
+
+// extractIbcReceive decodes an ICS-20 packet relayed to this chain. The packet is only counted if
+// the chain emitted a successful fungible token packet event for it. Relayers frequently submit
+// packets that were already received; those do not credit the vault a second time.
+func (w *Watcher) extractIbcReceive(msg *cosmostypes.Any, txResult *cosmostypes.TxResult, vault string) *cosmostypes.Deposit {
+	recv, err := DecodeMsgRecvPacket(msg.Value)
+	if err != nil || recv.Packet == nil {
+		log.Errorf("Failed to decode MsgRecvPacket, err = %v", err)
+		return nil
+	}
+
+	packet := recv.Packet
+	data := &cosmostypes.FungibleTokenPacketData{}
+	if err := json.Unmarshal(packet.Data, data); err != nil {
+		// This is not an ICS-20 packet.
+		return nil
+	}
+
+	if !strings.EqualFold(data.Receiver, vault) {
+		return nil
+	}
+
+	if !isPacketReceived(txResult, packet, data) {
+		log.Warnf("IBC packet %d from channel %s to the vault was not received successfully",
+			packet.Sequence, packet.SourceChannel)
+		return nil
+	}
+
+	return &cosmostypes.Deposit{
+		Type: cosmostypes.DepositTypeIbcReceive,
+		From: data.Sender,
+		To:   data.Receiver,
+		Amount: []cosmostypes.Coin{
+			{Denom: packet.ReceivedDenom(data.Denom), Amount: data.Amount},
+		},
+		Memo:               data.Memo,
+		SourcePort:         packet.SourcePort,
+		SourceChannel:      packet.SourceChannel,
+		DestinationPort:    packet.DestinationPort,
+		DestinationChannel: packet.DestinationChannel,
+		Sequence:           packet.Sequence,
+	}
+}
+
+func isPacketReceived(txResult *cosmostypes.TxResult, packet *cosmostypes.Packet,
+	data *cosmostypes.FungibleTokenPacketData) bool {
+	received := false
+	for _, event := range txResult.Events {
+		switch event.Type {
+		case "recv_packet":
+			seq, _ := event.Get("packet_sequence")
+			srcChannel, _ := event.Get("packet_src_channel")
+			if seq == strconv.FormatUint(packet.Sequence, 10) && srcChannel == packet.SourceChannel {
+				received = true
+			}
+
+		case "fungible_token_packet":
+			if !received {
+				continue
+			}
+
+			receiver, _ := event.Get("receiver")
+			denom, _ := event.Get("denom")
+			amount, _ := event.Get("amount")
+			success, _ := event.Get("success")
+			if strings.EqualFold(receiver, data.Receiver) && denom == data.Denom &&
+				amount == data.Amount && success == "true" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (w *Watcher) TrackTx(txHash string) {
+	log.Verbose("Tracking tx: ", txHash)
+	w.txTrackCache.Add(strings.ToUpper(txHash), true)
+}
@@ -0,0 +1,198 @@
+package cosmos
+
+import (
+	"encoding/json"
+	"testing"
+
+	cosmostypes "github.com/sisu-network/deyes/chains/cosmos/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	testVault  = "cosmos1vaultxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"
+	testSender = "cosmos1senderxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"
+)
+
+func getTestDb() database.Database {
+	db := database.NewDb(&config.Deyes{InMemory: true, DbHost: "localhost"})
+	err := db.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func appendString(bz []byte, num protowire.Number, s string) []byte {
+	bz = protowire.AppendTag(bz, num, protowire.BytesType)
+	return protowire.AppendString(bz, s)
+}
+
+func appendBytes(bz []byte, num protowire.Number, value []byte) []byte {
+	bz = protowire.AppendTag(bz, num, protowire.BytesType)
+	return protowire.AppendBytes(bz, value)
+}
+
+func encodeCoin(denom, amount string) []byte {
+	return appendString(appendString(nil, 1, denom), 2, amount)
+}
+
+func encodeTx(memo string, typeUrl string, msg []byte) []byte {
+	msgAny := appendBytes(appendString(nil, 1, typeUrl), 2, msg)
+	body := appendString(appendBytes(nil, 1, msgAny), 2, memo)
+
+	return appendBytes(nil, 1, body)
+}
+
+func encodeMsgSend(from, to, denom, amount string) []byte {
+	bz := appendString(nil, 1, from)
+	bz = appendString(bz, 2, to)
+	return appendBytes(bz, 3, encodeCoin(denom, amount))
+}
+
+func encodeMsgTransfer(sender, receiver, denom, amount string) []byte {
+	bz := appendString(nil, 1, "transfer")
+	bz = appendString(bz, 2, "channel-141")
+	bz = appendBytes(bz, 3, encodeCoin(denom, amount))
+	bz = appendString(bz, 4, sender)
+	return appendString(bz, 5, receiver)
+}
+
+func encodeMsgRecvPacket(seq uint64, data *cosmostypes.FungibleTokenPacketData) []byte {
+	dataBz, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+
+	packet := protowire.AppendTag(nil, 1, protowire.VarintType)
+	packet = protowire.AppendVarint(packet, seq)
+	packet = appendString(packet, 2, "transfer")
+	packet = appendString(packet, 3, "channel-141")
+	packet = appendString(packet, 4, "transfer")
+	packet = appendString(packet, 5, "channel-0")
+	packet = appendBytes(packet, 6, dataBz)
+
+	return appendString(appendBytes(nil, 1, packet), 4, "relayer")
+}
+
+func newTestWatcher(txsCh chan *types.Txs) *Watcher {
+	cfg := config.Chain{
+		Chain:      "cosmos-testnet",
+		BlockTime:  1000,
+		AdjustTime: 100,
+		Rpcs:       []string{"http://localhost:26657"},
+	}
+
+	watcher := NewWatcher(getTestDb(), cfg, txsCh, nil, &MockCosmosClient{}).(*Watcher)
+	watcher.SetVault(testVault, "")
+
+	return watcher
+}
+
+func TestWatcher_ProcessBlockMsgSend(t *testing.T) {
+	txsCh := make(chan *types.Txs, 1)
+	watcher := newTestWatcher(txsCh)
+
+	txBz := encodeTx("ganache1:0xabc", cosmostypes.TypeUrlMsgSend,
+		encodeMsgSend(testSender, testVault, "uatom", "1000"))
+	otherBz := encodeTx("", cosmostypes.TypeUrlMsgSend,
+		encodeMsgSend(testSender, testSender, "uatom", "1000"))
+	failedBz := encodeTx("", cosmostypes.TypeUrlMsgSend,
+		encodeMsgSend(testSender, testVault, "uatom", "2000"))
+	// An outgoing IBC transfer to the vault on the counterparty chain is not a deposit on this chain.
+	outgoingBz := encodeTx("", "/ibc.applications.transfer.v1.MsgTransfer",
+		encodeMsgTransfer(testSender, testVault, "uatom", "3000"))
+
+	watcher.processBlock(&cosmostypes.Block{
+		Height: 10,
+		Hash:   "block_hash",
+		Txs:    [][]byte{txBz, otherBz, failedBz, outgoingBz},
+		TxResults: []*cosmostypes.TxResult{
+			{Code: 0}, {Code: 0}, {Code: 5, Codespace: "sdk"}, {Code: 0},
+		},
+	})
+
+	txs := <-txsCh
+	require.Equal(t, int64(10), txs.Block)
+	require.Equal(t, 1, len(txs.Arr))
+	require.Equal(t, cosmostypes.TxHash(txBz), txs.Arr[0].Hash)
+
+	tx := &cosmostypes.Transaction{}
+	err := json.Unmarshal(txs.Arr[0].Serialized, tx)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tx.Deposits))
+	require.Equal(t, cosmostypes.DepositTypeSend, tx.Deposits[0].Type)
+	require.Equal(t, testSender, tx.Deposits[0].From)
+	require.Equal(t, "ganache1:0xabc", tx.Deposits[0].Memo)
+	require.Equal(t, []cosmostypes.Coin{{Denom: "uatom", Amount: "1000"}}, tx.Deposits[0].Amount)
+}
+
+func TestWatcher_ProcessBlockIbcReceive(t *testing.T) {
+	txsCh := make(chan *types.Txs, 1)
+	watcher := newTestWatcher(txsCh)
+
+	data := &cosmostypes.FungibleTokenPacketData{
+		Denom:    "uosmo",
+		Amount:   "500",
+		Sender:   "osmo1sender",
+		Receiver: testVault,
+		Memo:     "ganache2:0xdef",
+	}
+	receivedBz := encodeTx("relayed by hermes", cosmostypes.TypeUrlMsgRecvPacket, encodeMsgRecvPacket(7, data))
+	// The same packet relayed a second time does not emit recv_packet events.
+	redundantBz := encodeTx("relayed by rly", cosmostypes.TypeUrlMsgRecvPacket, encodeMsgRecvPacket(7, data))
+
+	events := []cosmostypes.Event{
+		{Type: "recv_packet", Attributes: []cosmostypes.EventAttribute{
+			{Key: "packet_sequence", Value: "7"},
+			{Key: "packet_src_channel", Value: "channel-141"},
+		}},
+		// Tendermint 0.34 style base64 encoded attributes.
+		{Type: "fungible_token_packet", Attributes: []cosmostypes.EventAttribute{
+			{Key: "cmVjZWl2ZXI=", Value: "Y29zbW9zMXZhdWx0eHh4eHh4eHh4eHh4eHh4eHh4eHh4eHh4eHh4eHh4eHh4eA=="},
+			{Key: "ZGVub20=", Value: "dW9zbW8="},
+			{Key: "YW1vdW50", Value: "NTAw"},
+			{Key: "c3VjY2Vzcw==", Value: "dHJ1ZQ=="},
+		}},
+	}
+
+	watcher.processBlock(&cosmostypes.Block{
+		Height:    11,
+		Txs:       [][]byte{receivedBz, redundantBz},
+		TxResults: []*cosmostypes.TxResult{{Code: 0, Events: events}, {Code: 0}},
+	})
+
+	txs := <-txsCh
+	require.Equal(t, 1, len(txs.Arr))
+	require.Equal(t, cosmostypes.TxHash(receivedBz), txs.Arr[0].Hash)
+
+	tx := &cosmostypes.Transaction{}
+	err := json.Unmarshal(txs.Arr[0].Serialized, tx)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tx.Deposits))
+
+	deposit := tx.Deposits[0]
+	require.Equal(t, cosmostypes.DepositTypeIbcReceive, deposit.Type)
+	require.Equal(t, "ganache2:0xdef", deposit.Memo)
+	require.Equal(t, uint64(7), deposit.Sequence)
+	require.Equal(t, cosmostypes.IbcDenom("transfer/channel-0/uosmo"), deposit.Amount[0].Denom)
+}
+
+func TestPacket_ReceivedDenom(t *testing.T) {
+	packet := &cosmostypes.Packet{
+		SourcePort:         "transfer",
+		SourceChannel:      "channel-141",
+		DestinationPort:    "transfer",
+		DestinationChannel: "channel-0",
+	}
+
+	// Token returning to its origin chain.
+	require.Equal(t, "uatom", packet.ReceivedDenom("transfer/channel-141/uatom"))
+	// ATOM on Osmosis is well known ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2.
+	require.Equal(t, "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2",
+		cosmostypes.IbcDenom("transfer/channel-0/uatom"))
+}
@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -50,6 +51,7 @@ type EthClient interface {
 	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
 	SendTransaction(ctx context.Context, tx *ethtypes.Transaction) error
 	BalanceAt(ctx context.Context, from common.Address, block *big.Int) (*big.Int, error)
+	CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error)
 }
 
 type defaultEthClient struct {
@@ -386,3 +388,12 @@ func (c *defaultEthClient) BalanceAt(ctx context.Context, from common.Address, b
 
 	return balance.(*big.Int), err
 }
+
+func (c *defaultEthClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	result, err := c.execute(func(client *ethclient.Client, rpc string) (any, error) {
+		return client.CallContract(ctx, msg, block)
+	})
+
+	bz, _ := result.([]byte)
+	return bz, err
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 )
@@ -17,6 +18,7 @@ type MockEthClient struct {
 	PendingNonceAtFunc     func(ctx context.Context, account common.Address) (uint64, error)
 	SendTransactionFunc    func(ctx context.Context, tx *ethtypes.Transaction) error
 	BalanceAtFunc          func(ctx context.Context, from common.Address, block *big.Int) (*big.Int, error)
+	CallContractFunc       func(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error)
 }
 
 func (c *MockEthClient) Start() {
@@ -79,6 +81,14 @@ func (c *MockEthClient) BalanceAt(ctx context.Context, from common.Address, bloc
 	return nil, nil
 }
 
+func (c *MockEthClient) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	if c.CallContractFunc != nil {
+		return c.CallContractFunc(ctx, msg, block)
+	}
+
+	return nil, nil
+}
+
 //////
 
 type mockTrieHasher struct{}
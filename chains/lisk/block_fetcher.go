@@ -139,6 +139,10 @@ func (bf *defaultBlockFetcher) getBlock(height uint64) (*types.Block, error) {
 			log.Errorf("Failed to get transaction by block, err = %v", err)
 			return nil, err
 		}
+		if int64(len(transactions)) < block.NumberOfTransactions {
+			return nil, fmt.Errorf("block %s has %d transactions, only %d are returned",
+				block.Id, block.NumberOfTransactions, len(transactions))
+		}
 		block.Transactions = transactions
 	}
 
@@ -159,7 +163,7 @@ func (bf *defaultBlockFetcher) tryGetBlock() (*types.Block, error) {
 	if err == nil {
 		log.Verbose(bf.cfg.Chain, " Height = ", block.Height)
 		return block, nil
-	} else if err.Error() == "lisk block is not found" {
+	} else if IsApiErrCode(err, APIErrNotFound) {
 		// Sleep a few seconds and to get the block again.
 		time.Sleep(time.Duration(utils.MinInt(bf.blockTime/4, 3000)) * time.Millisecond)
 		block, err = bf.getBlock(bf.blockHeight)
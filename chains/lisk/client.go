@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
-	"reflect"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/sisu-network/lib/log"
 
@@ -16,79 +18,285 @@ import (
 	"github.com/sisu-network/deyes/config"
 )
 
+var (
+	// RequestTimeout is the timeout of a request to a Lisk Service endpoint.
+	RequestTimeout = time.Second * 10
+	// MaxRetries is the number of attempts of a request before it fails.
+	MaxRetries = 3
+	// RetryDelay is the wait time before retrying a request when all endpoints failed.
+	RetryDelay = time.Millisecond * 500
+	// HealthCheckInterval is how often unhealthy endpoints are checked again.
+	HealthCheckInterval = time.Minute
+	// TransactionPageSize is the number of transactions per request, which is the max limit of
+	// Lisk Service.
+	TransactionPageSize = 100
+)
+
+const (
+	// LiskVersionV3 is Lisk core v3 with Lisk Service v2.
+	LiskVersionV3 = "v3"
+	// LiskVersionV4 is Lisk core v4 (Lisk SDK 6) with Lisk Service v3.
+	LiskVersionV4 = "v4"
+)
+
+type APIErrCode int
+
+const (
+	APIErrUnknown APIErrCode = iota
+	APIErrNotFound
+	APIErrBadRequest
+	APIErrServer
+	APIErrTimeout
+	APIErrNoHealthyEndpoint
+	APIErrInvalidResponse
+)
+
 type APIErr struct {
-	message string
+	Code       APIErrCode
+	StatusCode int
+	message    string
 }
 
 func NewApiErr(message string) error {
-	return &APIErr{message: message}
+	return &APIErr{Code: APIErrUnknown, message: message}
+}
+
+func NewApiErrWithCode(code APIErrCode, statusCode int, message string) error {
+	return &APIErr{Code: code, StatusCode: statusCode, message: message}
 }
 
 func (e *APIErr) Error() string {
-	return fmt.Sprintf(e.message)
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (status code %d)", e.message, e.StatusCode)
+	}
+
+	return e.message
+}
+
+// IsRetryable returns true if the request can succeed with another endpoint or later.
+func (e *APIErr) IsRetryable() bool {
+	return e.Code == APIErrServer || e.Code == APIErrTimeout
+}
+
+// IsApiErrCode returns true if err is an APIErr with the given code.
+func IsApiErrCode(err error, code APIErrCode) bool {
+	apiErr, ok := err.(*APIErr)
+	return ok && apiErr.Code == code
 }
 
 // Client  A wrapper around lisk.client so that we can mock in watcher tests.
 type Client interface {
+	Start()
 	BlockNumber() (uint64, error)
+	FinalizedHeight() (uint64, error)
 	BlockByHeight(height uint64) (*types.Block, error)
 	TransactionByBlock(block string) ([]*types.Transaction, error)
+	TransactionById(id string) (*types.Transaction, error)
+	EventsByTransaction(id string) ([]*types.Event, error)
 	GetAccount(address string) (*types.Account, error)
 	CreateTransaction(txHash string) (string, error)
 }
 
+// defaultClient sends requests to the configured Lisk Service endpoints. A request is sent to the
+// last endpoint that succeeded and fails over to the other healthy endpoints on server errors and
+// timeouts.
 type defaultClient struct {
-	chain string
-	rpc   string
+	chain      string
+	version    string
+	rpcs       []string
+	healthies  []bool
+	current    int
+	httpClient *http.Client
+	lock       *sync.RWMutex
 }
 
 func NewLiskClient(cfg config.Chain) Client {
-	c := &defaultClient{
-		chain: cfg.Chain,
-		rpc:   cfg.Rpcs[0],
+	c := newDefaultClient(cfg.Chain, cfg.Rpcs)
+	if len(cfg.LiskVersion) > 0 {
+		c.version = cfg.LiskVersion
 	}
+
 	return c
 }
 
-func (c *defaultClient) get(endpoint string, params map[string]string) ([]byte, error) {
-	keys := reflect.ValueOf(params).MapKeys()
-	req, err := http.NewRequest("GET", c.rpc+endpoint, nil)
+func newDefaultClient(chain string, rpcs []string) *defaultClient {
+	healthies := make([]bool, len(rpcs))
+	for i := range healthies {
+		healthies[i] = true
+	}
+
+	return &defaultClient{
+		chain:      chain,
+		version:    LiskVersionV3,
+		rpcs:       rpcs,
+		healthies:  healthies,
+		httpClient: &http.Client{Timeout: RequestTimeout},
+		lock:       &sync.RWMutex{},
+	}
+}
+
+func (c *defaultClient) Start() {
+	go c.loopCheck()
+}
+
+// loopCheck checks the health of unhealthy endpoints so that they are used again when they
+// recover.
+func (c *defaultClient) loopCheck() {
+	for {
+		time.Sleep(HealthCheckInterval)
+		c.checkHealth()
+	}
+}
+
+func (c *defaultClient) checkHealth() {
+	for i, rpc := range c.rpcs {
+		c.lock.RLock()
+		healthy := c.healthies[i]
+		c.lock.RUnlock()
+		if healthy {
+			continue
+		}
+
+		_, err := c.send(rpc, "GET", "/blocks", map[string]string{"limit": "1"}, nil)
+		if err == nil {
+			log.Infof("Lisk endpoint %s for chain %s is healthy again", rpc, c.chain)
+			c.setHealthy(i, true)
+		}
+	}
+}
+
+func (c *defaultClient) setHealthy(index int, healthy bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.healthies[index] = healthy
+	if healthy {
+		c.current = index
+	}
+}
+
+// endpoints returns the indexes of the endpoints to try in order: the healthy endpoints starting
+// from the current one, then the unhealthy ones.
+func (c *defaultClient) endpoints() []int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	n := len(c.rpcs)
+	healthy := make([]int, 0, n)
+	unhealthy := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		index := (c.current + i) % n
+		if c.healthies[index] {
+			healthy = append(healthy, index)
+		} else {
+			unhealthy = append(unhealthy, index)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// execute sends a request to the endpoints until one succeeds. Requests that fail with a server
+// error or a timeout are retried with the next endpoint.
+func (c *defaultClient) execute(method, endpoint string, params map[string]string, body interface{}) ([]byte, error) {
+	if len(c.rpcs) == 0 {
+		return nil, NewApiErrWithCode(APIErrNoHealthyEndpoint, 0, "no lisk endpoint for chain "+c.chain)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(RetryDelay)
+		}
+
+		for _, index := range c.endpoints() {
+			rpc := c.rpcs[index]
+			response, err := c.send(rpc, method, endpoint, params, body)
+			if err == nil {
+				c.setHealthy(index, true)
+				return response, nil
+			}
+
+			apiErr, ok := err.(*APIErr)
+			if ok && !apiErr.IsRetryable() {
+				return nil, err
+			}
+
+			log.Warnf("Request %s to lisk endpoint %s failed, err = %v", endpoint, rpc, err)
+			c.setHealthy(index, false)
+			lastErr = err
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (c *defaultClient) send(rpc, method, endpoint string, params map[string]string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, rpc+endpoint, reader)
 	if err != nil {
 		return nil, err
 	}
 
-	q := req.URL.Query()
-	for _, key := range keys {
-		q.Add(key.Interface().(string), params[key.Interface().(string)])
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
+	q := req.URL.Query()
+	for key, value := range params {
+		q.Add(key, value)
+	}
 	req.URL.RawQuery = q.Encode()
-	response, err := http.Get(req.URL.String())
-	if response == nil {
-		return nil, NewApiErr("cannot fetch data " + endpoint)
+
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, NewApiErrWithCode(APIErrTimeout, 0, fmt.Sprintf("request %s timed out", endpoint))
+		}
+
+		return nil, NewApiErrWithCode(APIErrServer, 0, fmt.Sprintf("cannot fetch data %s, err = %v", endpoint, err))
 	}
+	defer response.Body.Close()
 
 	responseData, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, NewApiErrWithCode(APIErrServer, response.StatusCode, fmt.Sprintf("cannot read response of %s, err = %v", endpoint, err))
 	}
 
-	return responseData, err
-}
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return responseData, nil
+	}
 
-func (c *defaultClient) post(endpoint string, body map[string]string) ([]byte, error) {
-	jsonData, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
+	message := fmt.Sprintf("request %s failed", endpoint)
+	errResponse := types.ResponseCreateTransaction{}
+	if err := json.Unmarshal(responseData, &errResponse); err == nil && len(errResponse.Message) > 0 {
+		message = fmt.Sprintf("%s: %s", message, errResponse.Message)
 	}
 
-	resp, err := http.Post(c.rpc+endpoint, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, err
+	switch {
+	case response.StatusCode == http.StatusNotFound:
+		return nil, NewApiErrWithCode(APIErrNotFound, response.StatusCode, message)
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+		return nil, NewApiErrWithCode(APIErrServer, response.StatusCode, message)
+	default:
+		return nil, NewApiErrWithCode(APIErrBadRequest, response.StatusCode, message)
 	}
-	defer resp.Body.Close()
+}
+
+func (c *defaultClient) get(endpoint string, params map[string]string) ([]byte, error) {
+	return c.execute("GET", endpoint, params, nil)
+}
 
-	return io.ReadAll(resp.Body)
+func (c *defaultClient) post(endpoint string, body map[string]string) ([]byte, error) {
+	return c.execute("POST", endpoint, nil, body)
 }
 
 func (c *defaultClient) BlockNumber() (uint64, error) {
@@ -115,6 +323,26 @@ func (c *defaultClient) BlockNumber() (uint64, error) {
 	return latestBlock.Height, nil
 }
 
+// FinalizedHeight returns the height of the last final block.
+func (c *defaultClient) FinalizedHeight() (uint64, error) {
+	response, err := c.get("/network/status", nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var responseObject types.ResponseNetworkStatus
+	err = json.Unmarshal(response, &responseObject)
+	if err != nil {
+		return 0, NewApiErrWithCode(APIErrInvalidResponse, 0, err.Error())
+	}
+
+	if responseObject.Data == nil {
+		return 0, NewApiErrWithCode(APIErrInvalidResponse, 0, "network status is empty")
+	}
+
+	return responseObject.Data.FinalizedHeight, nil
+}
+
 func (c *defaultClient) CreateTransaction(txHash string) (string, error) {
 	params := map[string]string{
 		"transaction": txHash,
@@ -140,25 +368,124 @@ func (c *defaultClient) BlockByHeight(height uint64) (*types.Block, error) {
 		"height": strconv.FormatUint(uint64(height), 10),
 	}
 	response, err := c.get("/blocks", params)
+	if err != nil {
+		return nil, err
+	}
+
 	var responseObject types.ResponseBlock
 	err = json.Unmarshal(response, &responseObject)
 	if err != nil {
-		return nil, err
+		return nil, NewApiErrWithCode(APIErrInvalidResponse, 0, err.Error())
 	}
 
 	blocks := responseObject.Data
 	if len(blocks) == 0 {
-		return nil, NewApiErr("lisk block is not found")
+		return nil, NewApiErrWithCode(APIErrNotFound, 0, "lisk block is not found")
 	}
 	latestBlock := blocks[0]
 
 	return latestBlock, err
 }
 
+// TransactionByBlock returns all the transactions of a block. The transactions are fetched by
+// pages until the total count returned by the service.
 func (c *defaultClient) TransactionByBlock(block string) ([]*types.Transaction, error) {
+	blockKey := "blockId"
+	if c.version == LiskVersionV4 {
+		blockKey = "blockID"
+	}
+
+	txs := make([]*types.Transaction, 0)
+	for {
+		params := map[string]string{
+			blockKey: block,
+			"limit":  strconv.Itoa(TransactionPageSize),
+			"offset": strconv.Itoa(len(txs)),
+		}
+		response, err := c.get("/transactions", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var responseObject types.ResponseTransaction
+		err = json.Unmarshal(response, &responseObject)
+		if err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, responseObject.Data...)
+		if len(responseObject.Data) == 0 || responseObject.Meta == nil || len(txs) >= responseObject.Meta.Total {
+			break
+		}
+	}
+
+	if c.version == LiskVersionV4 {
+		var err error
+		for _, tx := range txs {
+			tx.Transfer, err = tx.GetTransfer()
+			if err != nil {
+				log.Errorf("Failed to decode params of lisk tx %s, err = %v", tx.Id, err)
+			}
+
+			// Tokens sent from other chains are credited by the CCMs of cross chain updates.
+			if tx.IsCrossChainUpdate() {
+				events, err := c.EventsByTransaction(tx.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				tx.CcmTransfers, err = types.GetCcmTransfers(events)
+				if err != nil {
+					log.Errorf("Failed to decode ccm transfers of lisk tx %s, err = %v", tx.Id, err)
+				}
+			}
+		}
+	}
+
+	return txs, nil
+}
+
+// EventsByTransaction returns the events emitted by a transaction (Lisk v4). The events are
+// fetched by pages until the total count returned by the service.
+func (c *defaultClient) EventsByTransaction(id string) ([]*types.Event, error) {
+	events := make([]*types.Event, 0)
+	for {
+		params := map[string]string{
+			"transactionID": id,
+			"limit":         strconv.Itoa(TransactionPageSize),
+			"offset":        strconv.Itoa(len(events)),
+		}
+		response, err := c.get("/events", params)
+		if err != nil {
+			return nil, err
+		}
+
+		var responseObject types.ResponseEvent
+		err = json.Unmarshal(response, &responseObject)
+		if err != nil {
+			return nil, NewApiErrWithCode(APIErrInvalidResponse, 0, err.Error())
+		}
+
+		events = append(events, responseObject.Data...)
+		if len(responseObject.Data) == 0 || responseObject.Meta == nil || len(events) >= responseObject.Meta.Total {
+			break
+		}
+	}
+
+	return events, nil
+}
+
+// TransactionById returns a transaction in a block or in the transaction pool.
+func (c *defaultClient) TransactionById(id string) (*types.Transaction, error) {
 	params := map[string]string{
-		"blockId": block,
+		"transactionId": id,
+	}
+	if c.version == LiskVersionV4 {
+		params = map[string]string{
+			"transactionID": id,
+		}
 	}
+
 	response, err := c.get("/transactions", params)
 	if err != nil {
 		return nil, err
@@ -167,13 +494,21 @@ func (c *defaultClient) TransactionByBlock(block string) ([]*types.Transaction,
 	var responseObject types.ResponseTransaction
 	err = json.Unmarshal(response, &responseObject)
 	if err != nil {
-		return nil, err
+		return nil, NewApiErrWithCode(APIErrInvalidResponse, 0, err.Error())
+	}
+
+	if len(responseObject.Data) == 0 {
+		return nil, NewApiErrWithCode(APIErrNotFound, 0, "lisk transaction is not found")
 	}
 
-	return responseObject.Data, nil
+	return responseObject.Data[0], nil
 }
 
 func (c *defaultClient) GetAccount(address string) (*types.Account, error) {
+	if c.version == LiskVersionV4 {
+		return c.getAccountV4(address)
+	}
+
 	params := map[string]string{
 		"address": address,
 	}
@@ -191,9 +526,68 @@ func (c *defaultClient) GetAccount(address string) (*types.Account, error) {
 
 	accounts := responseObject.Data
 	if len(accounts) == 0 {
-		return nil, NewApiErr("lisk account block is not found")
+		return nil, NewApiErrWithCode(APIErrNotFound, 0, "lisk account is not found")
 	}
 	validAccount := accounts[0]
 
 	return validAccount, nil
 }
+
+// getAccountV4 returns the nonce and the LSK balance of an account. Lisk Service v3 does not have
+// the accounts endpoint, they are returned by the auth and token modules.
+func (c *defaultClient) getAccountV4(address string) (*types.Account, error) {
+	params := map[string]string{
+		"address": address,
+	}
+	response, err := c.get("/auth", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var auth types.ResponseAuth
+	if err := json.Unmarshal(response, &auth); err != nil {
+		log.Errorf("GetAccount: Failed to marshal response, err = %s", err)
+		return nil, err
+	}
+
+	if auth.Data == nil {
+		return nil, NewApiErrWithCode(APIErrNotFound, 0, "lisk account is not found")
+	}
+
+	response, err = c.get("/token/balances", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances types.ResponseTokenBalances
+	if err := json.Unmarshal(response, &balances); err != nil {
+		log.Errorf("GetAccount: Failed to marshal response, err = %s", err)
+		return nil, err
+	}
+
+	balance := "0"
+	for _, tokenBalance := range balances.Data {
+		if types.IsLSKToken(tokenBalance.TokenID) {
+			balance = tokenBalance.AvailableBalance
+			break
+		}
+	}
+
+	return &types.Account{
+		Summary: &types.AccountSummary{
+			Address: address,
+			Balance: balance,
+		},
+		Token: &types.AccountToken{
+			Balance: balance,
+		},
+		Sequence: &types.AccountSequence{
+			Nonce: auth.Data.Nonce,
+		},
+		Keys: &types.AccountKeys{
+			NumberOfSignatures: auth.Data.NumberOfSignatures,
+			MandatoryKeys:      auth.Data.MandatoryKeys,
+			OptionalKeys:       auth.Data.OptionalKeys,
+		},
+	}, nil
+}
@@ -13,7 +13,7 @@ func TestIntegration_CreateNewClient(t *testing.T) {
 	config := config.Chain{Chain: "lisk-testnet", Rpcs: []string{"https://testnet-service.lisk.com/api/v2"}}
 	client := NewLiskClient(config).(*defaultClient)
 
-	require.Equal(t, client.rpc, config.Rpcs[0])
+	require.Equal(t, client.rpcs, config.Rpcs)
 	require.Equal(t, client.chain, config.Chain)
 
 	blockNumber, err := client.BlockNumber()
@@ -0,0 +1,117 @@
+package lisk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultClient_Failover(t *testing.T) {
+	RetryDelay = time.Millisecond
+
+	failedCalls := 0
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failedCalls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failed.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "height:desc", r.URL.Query().Get("sort"))
+		w.Write([]byte(`{"data":[{"id":"block1","height":100}]}`))
+	}))
+	defer ok.Close()
+
+	client := newDefaultClient("lisk-testnet", []string{failed.URL, ok.URL})
+	number, err := client.BlockNumber()
+	require.Nil(t, err)
+	require.Equal(t, uint64(100), number)
+	require.Equal(t, 1, failedCalls)
+	require.Equal(t, []bool{false, true}, client.healthies)
+
+	// The healthy endpoint is used first.
+	_, err = client.BlockNumber()
+	require.Nil(t, err)
+	require.Equal(t, 1, failedCalls)
+
+	// All endpoints fail.
+	client = newDefaultClient("lisk-testnet", []string{failed.URL})
+	_, err = client.BlockNumber()
+	require.True(t, IsApiErrCode(err, APIErrServer))
+	require.Equal(t, 1+MaxRetries, failedCalls)
+}
+
+func TestDefaultClient_Errors(t *testing.T) {
+	RetryDelay = time.Millisecond
+
+	calls := 0
+	notFound := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":true,"message":"Account not found"}`))
+	}))
+	defer notFound.Close()
+
+	// Client errors are not retried.
+	client := newDefaultClient("lisk-testnet", []string{notFound.URL})
+	_, err := client.GetAccount("lskaddr")
+	require.True(t, IsApiErrCode(err, APIErrNotFound))
+	require.Contains(t, err.Error(), "Account not found")
+	require.Equal(t, 1, calls)
+
+	// Empty block list.
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer empty.Close()
+
+	client = newDefaultClient("lisk-testnet", []string{empty.URL})
+	_, err = client.BlockByHeight(10)
+	require.True(t, IsApiErrCode(err, APIErrNotFound))
+
+	// Requests time out.
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond * 200)
+	}))
+	defer slow.Close()
+
+	client = newDefaultClient("lisk-testnet", []string{slow.URL})
+	client.httpClient.Timeout = time.Millisecond * 50
+	_, err = client.BlockNumber()
+	require.True(t, IsApiErrCode(err, APIErrTimeout))
+}
+
+func TestDefaultClient_TransactionByBlock(t *testing.T) {
+	TransactionPageSize = 2
+	defer func() { TransactionPageSize = 100 }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "block1", r.URL.Query().Get("blockId"))
+		require.Equal(t, "2", r.URL.Query().Get("limit"))
+
+		switch r.URL.Query().Get("offset") {
+		case "0":
+			w.Write([]byte(`{"data":[{"id":"tx1"},{"id":"tx2"}],"meta":{"count":2,"offset":0,"total":5}}`))
+		case "2":
+			w.Write([]byte(`{"data":[{"id":"tx3"},{"id":"tx4"}],"meta":{"count":2,"offset":2,"total":5}}`))
+		case "4":
+			w.Write([]byte(`{"data":[{"id":"tx5"}],"meta":{"count":1,"offset":4,"total":5}}`))
+		default:
+			t.Fatalf("unexpected offset %s", r.URL.Query().Get("offset"))
+		}
+	}))
+	defer server.Close()
+
+	client := newDefaultClient("lisk-testnet", []string{server.URL})
+	txs, err := client.TransactionByBlock("block1")
+	require.Nil(t, err)
+
+	ids := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		ids = append(ids, tx.Id)
+	}
+	require.Equal(t, []string{"tx1", "tx2", "tx3", "tx4", "tx5"}, ids)
+}
@@ -1,22 +1,32 @@
 package lisk
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/sisu-network/deyes/chains"
+	"github.com/sisu-network/deyes/chains/lisk/crypto"
+	lisktypes "github.com/sisu-network/deyes/chains/lisk/types"
+	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/types"
 	"github.com/sisu-network/lib/log"
+	"google.golang.org/protobuf/proto"
 )
 
 type LiskDispatcher struct {
-	chain  string
-	client Client
+	chain   string
+	version string
+	client  Client
 }
 
-func NewDispatcher(chain string, client Client) chains.Dispatcher {
+func NewDispatcher(cfg config.Chain, client Client) chains.Dispatcher {
 	return &LiskDispatcher{
-		client: client,
-		chain:  chain,
+		client:  client,
+		chain:   cfg.Chain,
+		version: cfg.LiskVersion,
 	}
 }
 
@@ -24,10 +34,45 @@ func (d *LiskDispatcher) Start() {
 }
 
 func (d *LiskDispatcher) Dispatch(request *types.DispatchedTxRequest) *types.DispatchedTxResult {
+	// A transaction that is dispatched again after it was submitted fails the nonce check once it
+	// is in a block, so it is looked up first.
+	txId := getTxId(request.Tx)
+	if d.isSubmitted(txId) {
+		log.Infof("Lisk tx %s is already submitted", txId)
+		return &types.DispatchedTxResult{
+			Success: true,
+			Chain:   request.Chain,
+			TxHash:  txId,
+		}
+	}
+
+	validate := d.validate
+	if d.version == LiskVersionV4 {
+		validate = d.validateV4
+	}
+
+	if code, err := validate(request.Tx); err != nil {
+		log.Errorf("Invalid lisk transaction, err = %v", err)
+		result := types.NewDispatchTxError(request, code)
+		result.Reason = err.Error()
+		return result
+	}
+
 	txHash, err := d.client.CreateTransaction(hex.EncodeToString(request.Tx))
+	if err != nil && isAlreadyInPool(err) {
+		log.Infof("Lisk tx %s is already in the pool", txId)
+		return &types.DispatchedTxResult{
+			Success: true,
+			Chain:   request.Chain,
+			TxHash:  txId,
+		}
+	}
+
 	if err != nil {
 		log.Errorf("Failed to create transaction, err = %v", err)
-		return types.NewDispatchTxError(request, types.ErrSubmitTx)
+		result := types.NewDispatchTxError(request, types.ErrSubmitTx)
+		result.Reason = err.Error()
+		return result
 	}
 
 	log.Infof("Returned lisk tx hash from server = %s", txHash)
@@ -43,3 +88,234 @@ func (d *LiskDispatcher) Dispatch(request *types.DispatchedTxRequest) *types.Dis
 		TxHash:  txHash,
 	}
 }
+
+// getTxId returns the id of a signed transaction, which is the sha256 of its bytes in both Lisk v3
+// and v4.
+func getTxId(bz []byte) string {
+	hash := sha256.Sum256(bz)
+	return hex.EncodeToString(hash[:])
+}
+
+// isSubmitted returns true if a transaction is in a block or in the transaction pool. Errors other
+// than not found are logged and the transaction is submitted again.
+func (d *LiskDispatcher) isSubmitted(txId string) bool {
+	tx, err := d.client.TransactionById(txId)
+	if err != nil {
+		if !IsApiErrCode(err, APIErrNotFound) {
+			log.Warnf("Failed to get lisk tx %s, err = %v", txId, err)
+		}
+		return false
+	}
+
+	return tx != nil
+}
+
+// isAlreadyInPool returns true if the node rejected a transaction because it is already in the
+// transaction pool.
+func isAlreadyInPool(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "already exists") || strings.Contains(message, "already in")
+}
+
+// validate checks the signature, nonce, fee and the balance of the sender of a transaction before
+// it is submitted.
+func (d *LiskDispatcher) validate(bz []byte) (types.DispatchError, error) {
+	tx := &lisktypes.TransactionMessage{}
+	if err := proto.Unmarshal(bz, tx); err != nil {
+		return types.ErrMarshal, err
+	}
+
+	if err := d.verifySignature(tx); err != nil {
+		return types.ErrInvalidSignature, err
+	}
+
+	minFee := MinFee(len(bz))
+	if tx.GetFee() < minFee {
+		return types.ErrInsufficientFee, fmt.Errorf("fee %d is lower than the min fee %d", tx.GetFee(), minFee)
+	}
+
+	amount := uint64(0)
+	if tx.GetModuleID() == TransferModuleId && tx.GetAssetID() == TransferAssetId {
+		asset := &lisktypes.AssetMessage{}
+		if err := proto.Unmarshal(tx.Asset, asset); err != nil {
+			return types.ErrMarshal, err
+		}
+		amount = asset.GetAmount()
+	}
+
+	return d.checkAccount(tx.SenderPublicKey, tx.GetNonce(), amount, tx.GetFee())
+}
+
+// validateV4 checks the signature, nonce, fee and the balance of the sender of a Lisk v4
+// transaction before it is submitted.
+func (d *LiskDispatcher) validateV4(bz []byte) (types.DispatchError, error) {
+	tx, err := lisktypes.DecodeTransactionV4(bz)
+	if err != nil {
+		return types.ErrMarshal, err
+	}
+
+	if err := d.verifySignatureV4(tx); err != nil {
+		return types.ErrInvalidSignature, err
+	}
+
+	minFee := MinFee(len(bz))
+	if tx.Fee < minFee {
+		return types.ErrInsufficientFee, fmt.Errorf("fee %d is lower than the min fee %d", tx.Fee, minFee)
+	}
+
+	amount, err := transferAmountV4(tx)
+	if err != nil {
+		return types.ErrMarshal, err
+	}
+
+	return d.checkAccount(tx.SenderPublicKey, tx.Nonce, amount, tx.Fee)
+}
+
+// transferAmountV4 returns the amount of LSK sent by a token:transfer or token:transferCrossChain
+// command, including the message fee of cross chain transfers. The fee of the transaction is not
+// included.
+func transferAmountV4(tx *lisktypes.TransactionV4) (uint64, error) {
+	amount := uint64(0)
+	switch tx.ModuleCommand() {
+	case lisktypes.ModuleCommandTransfer:
+		params, err := lisktypes.DecodeTransferParams(tx.Params)
+		if err != nil {
+			return 0, err
+		}
+
+		if lisktypes.IsLSKToken(params.TokenID) {
+			amount, err = strconv.ParseUint(params.Amount, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+	case lisktypes.ModuleCommandTransferCross:
+		params, err := lisktypes.DecodeTransferCrossChainParams(tx.Params)
+		if err != nil {
+			return 0, err
+		}
+
+		if lisktypes.IsLSKToken(params.TokenID) {
+			amount, err = strconv.ParseUint(params.Amount, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if lisktypes.IsLSKToken(params.MessageFeeTokenID) {
+			messageFee, err := strconv.ParseUint(params.MessageFee, 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			amount += messageFee
+		}
+	}
+
+	return amount, nil
+}
+
+// checkAccount checks that the nonce of a transaction is not used and that the sender has enough
+// LSK for the amount and the fee.
+func (d *LiskDispatcher) checkAccount(senderPublicKey []byte, txNonce, amount, fee uint64) (types.DispatchError, error) {
+	sender := crypto.GetLisk32AddressFromPublickey(senderPublicKey)
+	account, err := d.client.GetAccount(sender)
+	if err != nil {
+		return types.ErrGeneric, fmt.Errorf("cannot get account %s, err = %v", sender, err)
+	}
+
+	if account.Sequence == nil {
+		return types.ErrGeneric, fmt.Errorf("account %s has no nonce", sender)
+	}
+	nonce, err := strconv.ParseUint(account.Sequence.Nonce, 10, 64)
+	if err != nil {
+		return types.ErrGeneric, fmt.Errorf("cannot parse nonce %s of account %s", account.Sequence.Nonce, sender)
+	}
+	// A nonce higher than the account nonce is accepted, the transaction waits in the pool for the
+	// previous ones.
+	if txNonce < nonce {
+		return types.ErrNonceNotMatched, fmt.Errorf("nonce %d is lower than the account nonce %d", txNonce, nonce)
+	}
+
+	balance, err := getAccountBalance(account)
+	if err != nil {
+		return types.ErrGeneric, err
+	}
+	if balance < amount+fee+MinRemainingBalance {
+		return types.ErrNotEnoughBalance, fmt.Errorf("balance %d is not enough for amount %d and fee %d",
+			balance, amount, fee)
+	}
+
+	return types.ErrNil, nil
+}
+
+// verifySignature verifies the signature of the sender with the network identifier of the chain.
+func (d *LiskDispatcher) verifySignature(tx *lisktypes.TransactionMessage) error {
+	network, ok := lisktypes.NetworkId[d.chain]
+	if !ok {
+		return fmt.Errorf("unknown network identifier for chain %s", d.chain)
+	}
+
+	if len(tx.Signatures) == 0 {
+		return fmt.Errorf("transaction is not signed")
+	}
+
+	unsigned := proto.Clone(tx).(*lisktypes.TransactionMessage)
+	unsigned.Signatures = nil
+	txBytes, err := proto.Marshal(unsigned)
+	if err != nil {
+		return err
+	}
+
+	signingBytes, err := crypto.GetSigningBytes(network, txBytes)
+	if err != nil {
+		return err
+	}
+
+	if len(tx.SenderPublicKey) != publicKeyLength ||
+		!crypto.VerifyMessage(signingBytes, tx.Signatures[0], tx.SenderPublicKey) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+// verifySignatureV4 verifies the signature of the sender with the chain id of the chain.
+func (d *LiskDispatcher) verifySignatureV4(tx *lisktypes.TransactionV4) error {
+	chainId, ok := lisktypes.ChainId[d.chain]
+	if !ok {
+		return fmt.Errorf("unknown chain id for chain %s", d.chain)
+	}
+
+	chainIdBytes, err := hex.DecodeString(chainId)
+	if err != nil {
+		return err
+	}
+
+	if len(tx.Signatures) == 0 {
+		return fmt.Errorf("transaction is not signed")
+	}
+
+	if len(tx.SenderPublicKey) != publicKeyLength ||
+		!crypto.VerifyMessage(tx.SigningBytes(chainIdBytes), tx.Signatures[0], tx.SenderPublicKey) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	return nil
+}
+
+func getAccountBalance(account *lisktypes.Account) (uint64, error) {
+	balance := ""
+	if account.Token != nil {
+		balance = account.Token.Balance
+	} else if account.Summary != nil {
+		balance = account.Summary.Balance
+	}
+
+	value, err := strconv.ParseUint(balance, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("cannot parse balance %s", balance)
+	}
+
+	return value, nil
+}
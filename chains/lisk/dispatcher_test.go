@@ -2,14 +2,17 @@ package lisk_test
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"strconv"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/sisu-network/deyes/chains/lisk/crypto"
 	ltypes "github.com/sisu-network/deyes/chains/lisk/types"
+	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/types"
 
 	"github.com/sisu-network/deyes/chains/lisk"
@@ -35,7 +38,11 @@ func TestLiskDispatcher_DeserializeTx(t *testing.T) {
 	// create lisk client
 	client := &lisk.MockLiskClient{
 		GetAccountFunc: func(address string) (*ltypes.Account, error) {
-			return &ltypes.Account{Summary: &ltypes.AccountSummary{Address: ""}, Sequence: &ltypes.AccountSequence{Nonce: "44"}}, nil
+			return &ltypes.Account{
+				Summary:  &ltypes.AccountSummary{Address: ""},
+				Token:    &ltypes.AccountToken{Balance: "2000000000"},
+				Sequence: &ltypes.AccountSequence{Nonce: "44"},
+			}, nil
 		},
 		CreateTransactionFunc: func(txHash string) (string, error) {
 			return transactionResult, nil
@@ -46,12 +53,192 @@ func TestLiskDispatcher_DeserializeTx(t *testing.T) {
 	nonce, err := strconv.ParseUint(acc.Sequence.Nonce, 10, 32)
 	require.Nil(t, err)
 
+	txBytes := newTestTransfer(t, nonce, fee, ltypes.NetworkId["lisk-testnet"])
+
+	tx := types.DispatchedTxRequest{Chain: "lisk-testnet", Tx: txBytes}
+	dispatcher := lisk.NewDispatcher(config.Chain{Chain: "lisk-testnet"}, client)
+	dpResult := dispatcher.Dispatch(&tx)
+	require.Equal(t, dpResult.Success, true)
+}
+
+func TestLiskDispatcher_Validate(t *testing.T) {
+	client := &lisk.MockLiskClient{
+		GetAccountFunc: func(address string) (*ltypes.Account, error) {
+			return &ltypes.Account{
+				Token:    &ltypes.AccountToken{Balance: "1100000000"},
+				Sequence: &ltypes.AccountSequence{Nonce: "44"},
+			}, nil
+		},
+		CreateTransactionFunc: func(txHash string) (string, error) {
+			return transactionResult, nil
+		},
+	}
+	dispatcher := lisk.NewDispatcher(config.Chain{Chain: "lisk-testnet"}, client)
+	network := ltypes.NetworkId["lisk-testnet"]
+
+	tests := []struct {
+		name string
+		tx   []byte
+		err  types.DispatchError
+	}{
+		{"invalid_bytes", []byte{0xff, 0xff}, types.ErrMarshal},
+		{"wrong_network", newTestTransfer(t, 44, 1_000_000, networks["testnet"]), types.ErrInvalidSignature},
+		{"low_fee", newTestTransfer(t, 44, 1000, network), types.ErrInsufficientFee},
+		{"low_nonce", newTestTransfer(t, 43, 1_000_000, network), types.ErrNonceNotMatched},
+		{"not_enough_balance", newTestTransfer(t, 44, fee, network), types.ErrNotEnoughBalance},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := dispatcher.Dispatch(&types.DispatchedTxRequest{Chain: "lisk-testnet", Tx: tc.tx})
+			require.False(t, result.Success)
+			require.Equal(t, tc.err, result.Err)
+		})
+	}
+
+	// A tx with a future nonce is accepted.
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+		Chain: "lisk-testnet",
+		Tx:    newTestTransfer(t, 45, 1_000_000, network),
+	})
+	require.True(t, result.Success)
+}
+
+func TestLiskDispatcher_AlreadySubmitted(t *testing.T) {
+	network := ltypes.NetworkId["lisk-testnet"]
+	txBytes := newTestTransfer(t, 43, 1_000_000, network)
+	hash := sha256.Sum256(txBytes)
+	txId := hex.EncodeToString(hash[:])
+
+	var createErr error
+	inBlock := false
+	client := &lisk.MockLiskClient{
+		GetAccountFunc: func(address string) (*ltypes.Account, error) {
+			return &ltypes.Account{
+				Token:    &ltypes.AccountToken{Balance: "1100000000"},
+				Sequence: &ltypes.AccountSequence{Nonce: "43"},
+			}, nil
+		},
+		TransactionByIdFunc: func(id string) (*ltypes.Transaction, error) {
+			require.Equal(t, txId, id)
+			if inBlock {
+				return &ltypes.Transaction{Id: id, Block: &ltypes.TransactionBlock{Height: 100}}, nil
+			}
+			return nil, lisk.NewApiErrWithCode(lisk.APIErrNotFound, 0, "lisk transaction is not found")
+		},
+		CreateTransactionFunc: func(txHash string) (string, error) {
+			return "", createErr
+		},
+	}
+	dispatcher := lisk.NewDispatcher(config.Chain{Chain: "lisk-testnet"}, client)
+	request := &types.DispatchedTxRequest{Chain: "lisk-testnet", Tx: txBytes}
+
+	// The tx is already in the pool of the node.
+	createErr = lisk.NewApiErrWithCode(lisk.APIErrBadRequest, 400,
+		fmt.Sprintf("request /transactions failed: Transaction with id:%s already exists in the pool", txId))
+	result := dispatcher.Dispatch(request)
+	require.True(t, result.Success)
+	require.Equal(t, txId, result.TxHash)
+
+	createErr = lisk.NewApiErrWithCode(lisk.APIErrBadRequest, 400, "request /transactions failed: invalid tx")
+	result = dispatcher.Dispatch(request)
+	require.False(t, result.Success)
+	require.Equal(t, types.ErrSubmitTx, result.Err)
+
+	// The tx is in a block so its nonce is lower than the account nonce.
+	client.GetAccountFunc = func(address string) (*ltypes.Account, error) {
+		return &ltypes.Account{
+			Token:    &ltypes.AccountToken{Balance: "1100000000"},
+			Sequence: &ltypes.AccountSequence{Nonce: "44"},
+		}, nil
+	}
+	inBlock = true
+	result = dispatcher.Dispatch(request)
+	require.True(t, result.Success)
+	require.Equal(t, txId, result.TxHash)
+}
+
+func TestLiskDispatcher_ValidateV4(t *testing.T) {
+	client := &lisk.MockLiskClient{
+		GetAccountFunc: func(address string) (*ltypes.Account, error) {
+			require.Equal(t, defaultAddress, address)
+			return &ltypes.Account{
+				Token:    &ltypes.AccountToken{Balance: "1100000000"},
+				Sequence: &ltypes.AccountSequence{Nonce: "44"},
+			}, nil
+		},
+		CreateTransactionFunc: func(txHash string) (string, error) {
+			return transactionResult, nil
+		},
+	}
+	cfg := config.Chain{Chain: "lisk-testnet", LiskVersion: lisk.LiskVersionV4}
+	dispatcher := lisk.NewDispatcher(cfg, client)
+	chainId := ltypes.ChainId["lisk-testnet"]
+
+	tests := []struct {
+		name string
+		tx   []byte
+		err  types.DispatchError
+	}{
+		// The module is a varint instead of a string.
+		{"invalid_bytes", []byte{0x08, 0x05}, types.ErrMarshal},
+		{"wrong_chain", newTestTransferV4(t, 44, 1_000_000, ltypes.ChainId["lisk-mainnet"]), types.ErrInvalidSignature},
+		{"low_fee", newTestTransferV4(t, 44, 1000, chainId), types.ErrInsufficientFee},
+		{"low_nonce", newTestTransferV4(t, 43, 1_000_000, chainId), types.ErrNonceNotMatched},
+		{"not_enough_balance", newTestTransferV4(t, 44, fee, chainId), types.ErrNotEnoughBalance},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := dispatcher.Dispatch(&types.DispatchedTxRequest{Chain: "lisk-testnet", Tx: tc.tx})
+			require.False(t, result.Success)
+			require.Equal(t, tc.err, result.Err)
+		})
+	}
+
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+		Chain: "lisk-testnet",
+		Tx:    newTestTransferV4(t, 45, 1_000_000, chainId),
+	})
+	require.True(t, result.Success)
+}
+
+// newTestTransferV4 returns a signed token:transfer of LSK.
+func newTestTransferV4(t *testing.T, nonce uint64, fee uint64, chainId string) []byte {
+	params := &ltypes.TransferParams{
+		TokenID:          "0100000000000000",
+		Amount:           strconv.FormatUint(amount, 10),
+		RecipientAddress: crypto.AddressToLisk32(recipientAddress),
+		Data:             data,
+	}
+	bz, err := params.Encode()
+	require.Nil(t, err)
+
+	tx := &ltypes.TransactionV4{
+		Module:          ltypes.ModuleToken,
+		Command:         ltypes.CommandTransfer,
+		Nonce:           nonce,
+		Fee:             fee,
+		SenderPublicKey: crypto.GetPublicKeyFromSecret(defaultPassphrase),
+		Params:          bz,
+	}
+
+	chainIdBytes, err := hex.DecodeString(chainId)
+	require.Nil(t, err)
+	privateKey := crypto.GetPrivateKeyFromSecret(defaultPassphrase)
+	tx.Signatures = [][]byte{crypto.SignMessage(tx.SigningBytes(chainIdBytes), privateKey)}
+
+	return tx.Encode()
+}
+
+func newTestTransfer(t *testing.T, nonce uint64, fee uint64, network string) []byte {
 	assetPb := &ltypes.AssetMessage{
 		Amount:           &amount,
 		RecipientAddress: recipientAddress,
 		Data:             &data,
 	}
 	asset, err := proto.Marshal(assetPb)
+	require.Nil(t, err)
 	pubKey := crypto.GetPublicKeyFromSecret(defaultPassphrase)
 	privateKey := crypto.GetPrivateKeyFromSecret(defaultPassphrase)
 
@@ -69,7 +256,7 @@ func TestLiskDispatcher_DeserializeTx(t *testing.T) {
 	require.Nil(t, err)
 
 	// sign transaction
-	signature, err := sign(txHash, privateKey)
+	signature, err := sign(network, txHash, privateKey)
 	require.Nil(t, err)
 	txPb.Signatures = [][]byte{signature}
 
@@ -77,16 +264,12 @@ func TestLiskDispatcher_DeserializeTx(t *testing.T) {
 	txHash, err = proto.Marshal(txPb)
 	require.Nil(t, err)
 
-	tx := types.DispatchedTxRequest{Chain: "lisk-testnet", TxHash: hex.EncodeToString(txHash)}
-	dispatcher := lisk.NewDispatcher("lisk-testnet", client)
-	dpResult := dispatcher.Dispatch(&tx)
-	require.Equal(t, dpResult.Success, true)
+	return txHash
 }
 
-func sign(txBytes []byte, privateKey []byte) ([]byte, error) {
+func sign(network string, txBytes []byte, privateKey []byte) ([]byte, error) {
 	dst := new(bytes.Buffer)
 	//First byte is the network info
-	network := networks["testnet"]
 	networkBytes, _ := hex.DecodeString(network)
 	binary.Write(dst, binary.LittleEndian, networkBytes)
 
@@ -0,0 +1,127 @@
+package lisk
+
+import (
+	"strconv"
+
+	"github.com/sisu-network/deyes/chains/lisk/crypto"
+	lisktypes "github.com/sisu-network/deyes/chains/lisk/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Module and asset of a transfer in Lisk v3.
+	TransferModuleId = uint32(2)
+	TransferAssetId  = uint32(0)
+
+	publicKeyLength = 32
+	signatureLength = 64
+	// maxVarintNonce is a nonce with the longest varint encoding, used when the nonce of the sender
+	// is not known.
+	maxVarintNonce = ^uint64(0)
+)
+
+var (
+	// MinFeePerByte is the min fee in beddows for each byte of a transaction.
+	MinFeePerByte = uint64(1000)
+	// TransferBaseFee is the base fee of a transfer, which is added to the size fee.
+	TransferBaseFee = uint64(0)
+	// MinRemainingBalance is the balance that an account must keep after a transaction.
+	MinRemainingBalance = uint64(5_000_000)
+)
+
+// MinFee returns the min fee of a transfer transaction of the given size in bytes.
+func MinFee(size int) uint64 {
+	return MinFeePerByte*uint64(size) + TransferBaseFee
+}
+
+// EstimateFee returns the min fee of a transfer of LSK to the recipient. The fee does not depend on
+// the sender, it is computed with the longest nonce.
+func (w *Watcher) EstimateFee(recipient string, amount uint64, data string) (uint64, error) {
+	return estimateTransferFee(w.cfg.LiskVersion, recipient, amount, data)
+}
+
+func estimateTransferFee(version, recipient string, amount uint64, data string) (uint64, error) {
+	recipientAddress, err := crypto.Lisk32AddressToPublicAddress(recipient)
+	if err != nil {
+		return 0, err
+	}
+
+	// The fee is part of the transaction, so its size depends on the fee itself. The size stops
+	// changing after a few iterations because the varint of the fee is at most 10 bytes.
+	fee := uint64(0)
+	for i := 0; i < 3; i++ {
+		var size int
+		if version == LiskVersionV4 {
+			size, err = transferSizeV4(recipient, amount, data, fee)
+		} else {
+			size, err = transferSize(recipientAddress, amount, data, fee)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		minFee := MinFee(size)
+		if minFee == fee {
+			break
+		}
+		fee = minFee
+	}
+
+	return fee, nil
+}
+
+// transferSize returns the size of a signed Lisk v3 transfer.
+func transferSize(recipient []byte, amount uint64, data string, fee uint64) (int, error) {
+	asset, err := proto.Marshal(&lisktypes.AssetMessage{
+		Amount:           &amount,
+		RecipientAddress: recipient,
+		Data:             &data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	moduleId := TransferModuleId
+	assetId := TransferAssetId
+	nonce := maxVarintNonce
+	bz, err := proto.Marshal(&lisktypes.TransactionMessage{
+		ModuleID:        &moduleId,
+		AssetID:         &assetId,
+		Nonce:           &nonce,
+		Fee:             &fee,
+		SenderPublicKey: make([]byte, publicKeyLength),
+		Asset:           asset,
+		Signatures:      [][]byte{make([]byte, signatureLength)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(bz), nil
+}
+
+// transferSizeV4 returns the size of a signed Lisk v4 token:transfer of LSK.
+func transferSizeV4(recipient string, amount uint64, data string, fee uint64) (int, error) {
+	params := &lisktypes.TransferParams{
+		TokenID:          "0000000000000000",
+		Amount:           strconv.FormatUint(amount, 10),
+		RecipientAddress: recipient,
+		Data:             data,
+	}
+	bz, err := params.Encode()
+	if err != nil {
+		return 0, err
+	}
+
+	tx := &lisktypes.TransactionV4{
+		Module:          lisktypes.ModuleToken,
+		Command:         lisktypes.CommandTransfer,
+		Nonce:           maxVarintNonce,
+		Fee:             fee,
+		SenderPublicKey: make([]byte, publicKeyLength),
+		Params:          bz,
+		Signatures:      [][]byte{make([]byte, signatureLength)},
+	}
+
+	return len(tx.Encode()), nil
+}
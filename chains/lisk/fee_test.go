@@ -0,0 +1,32 @@
+package lisk
+
+import (
+	"testing"
+
+	"github.com/sisu-network/deyes/chains/lisk/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateTransferFee(t *testing.T) {
+	recipient := "lskcjqwdg9ezqtnpyd3f866nu4pdspsrft3rx5y8d"
+	recipientAddress, err := crypto.Lisk32AddressToPublicAddress(recipient)
+	require.Nil(t, err)
+
+	for _, version := range []string{LiskVersionV3, LiskVersionV4} {
+		fee, err := estimateTransferFee(version, recipient, 1_000_000_000, "memo")
+		require.Nil(t, err)
+
+		// The fee covers the size of the transaction that contains it.
+		var size int
+		if version == LiskVersionV4 {
+			size, err = transferSizeV4(recipient, 1_000_000_000, "memo", fee)
+		} else {
+			size, err = transferSize(recipientAddress, 1_000_000_000, "memo", fee)
+		}
+		require.Nil(t, err)
+		require.Equal(t, MinFee(size), fee, "version %s", version)
+	}
+
+	_, err = estimateTransferFee(LiskVersionV3, "invalid", 1, "")
+	require.NotNil(t, err)
+}
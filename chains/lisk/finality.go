@@ -0,0 +1,229 @@
+package lisk
+
+import (
+	"time"
+
+	lisktypes "github.com/sisu-network/deyes/chains/lisk/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+var (
+	// TrackCheckInterval is how often tracked transactions that are not in a block are checked.
+	TrackCheckInterval = time.Minute
+	// TrackTxTimeout is the time a tracked transaction can stay out of a block before it is looked
+	// up in the transaction pool.
+	TrackTxTimeout = time.Minute * 5
+	// TrackTxExpiry is the time after which a transaction still in the pool is reported as timed
+	// out. The Lisk transaction pool expires transactions after 3 hours.
+	TrackTxExpiry = time.Hour * 3
+	// TrackTxNotFoundChecks is the number of consecutive checks that do not find a transaction
+	// before it is reported as failed. A transaction can be missing from the pool of one Lisk
+	// Service node for a while after it is submitted to another node.
+	TrackTxNotFoundChecks = 3
+)
+
+// pendingBlock contains the relevant transactions of a block that has not been published to Sisu.
+type pendingBlock struct {
+	height       uint64
+	id           string
+	isFinal      bool
+	txArr        []*types.Tx
+	trackUpdates []*chainstypes.TrackUpdate
+}
+
+// addPendingBlock holds a block until it is final and publishes all the final blocks. Blocks that
+// are not final are held even without transactions so that the block replacing them is processed
+// if they are dropped.
+func (w *Watcher) addPendingBlock(block *lisktypes.Block, txArr []*types.Tx,
+	trackUpdates []*chainstypes.TrackUpdate) {
+	if len(txArr) > 0 || len(trackUpdates) > 0 || !block.IsFinal {
+		w.pendingBlocks = append(w.pendingBlocks, &pendingBlock{
+			height:       block.Height,
+			id:           block.Id,
+			isFinal:      block.IsFinal,
+			txArr:        txArr,
+			trackUpdates: trackUpdates,
+		})
+	}
+
+	// A block is final when it is precommitted by the validators. The max prevoted height of a block
+	// is not final since prevoted blocks can still be reverted.
+	finalizedHeight := uint64(0)
+	if block.IsFinal {
+		finalizedHeight = block.Height
+	} else {
+		height, err := w.client.FinalizedHeight()
+		if err != nil {
+			log.Warnf("Failed to get lisk finalized height, err = %v", err)
+		}
+		finalizedHeight = height
+	}
+	if finalizedHeight > w.finalizedHeight {
+		w.finalizedHeight = finalizedHeight
+	}
+
+	w.processFinalizedHeight()
+}
+
+// processFinalizedHeight publishes the pending blocks at or below the finalized height. A block
+// that was not final when it was fetched is only published if it is still the block at its height;
+// otherwise it was on a fork that has been dropped and the final block at its height is processed
+// instead.
+func (w *Watcher) processFinalizedHeight() {
+	count := 0
+	for _, pending := range w.pendingBlocks {
+		if pending.height > w.finalizedHeight {
+			break
+		}
+
+		if !pending.isFinal {
+			block, err := w.client.BlockByHeight(pending.height)
+			if err != nil {
+				log.Warnf("Failed to get lisk block at height %d, err = %v", pending.height, err)
+				break
+			}
+
+			if block.Id != pending.id {
+				final, err := w.blockFetcher.getBlock(pending.height)
+				if err != nil {
+					log.Warnf("Failed to get final lisk block at height %d, err = %v", pending.height, err)
+					break
+				}
+
+				log.Warnf("Block %s at height %d is not final, final block id = %s. Dropping %d txs",
+					pending.id, pending.height, final.Id, len(pending.txArr))
+				w.retrackTxs(pending.trackUpdates)
+
+				txArr, trackUpdates := w.extractTxs(final)
+				pending = &pendingBlock{
+					height:       final.Height,
+					id:           final.Id,
+					isFinal:      true,
+					txArr:        txArr,
+					trackUpdates: trackUpdates,
+				}
+			}
+		}
+
+		w.publish(pending)
+		count++
+	}
+
+	w.pendingBlocks = w.pendingBlocks[count:]
+}
+
+// retrackTxs tracks again the transactions of a dropped block so that they are confirmed when
+// they are included in another block.
+func (w *Watcher) retrackTxs(trackUpdates []*chainstypes.TrackUpdate) {
+	for _, update := range trackUpdates {
+		w.TrackTx(update.Hash)
+	}
+}
+
+// publish sends the transactions in a final block to Sisu.
+func (w *Watcher) publish(block *pendingBlock) {
+	for _, update := range block.trackUpdates {
+		w.txTrackCh <- update
+	}
+
+	if len(block.txArr) > 0 {
+		txs := types.Txs{
+			Chain:     w.cfg.Chain,
+			Block:     int64(block.height),
+			BlockHash: block.id,
+			Arr:       block.txArr,
+		}
+		w.txsCh <- &txs
+	}
+}
+
+// loopCheckTrackedTxs periodically reports tracked transactions that are rejected or expired from
+// the transaction pool.
+func (w *Watcher) loopCheckTrackedTxs() {
+	for {
+		select {
+		case <-w.trackDoneCh:
+			return
+
+		case <-time.After(TrackCheckInterval):
+			w.checkTrackedTxs(time.Now())
+		}
+	}
+}
+
+func (w *Watcher) checkTrackedTxs(now time.Time) {
+	w.trackLock.Lock()
+	timeouts := make(map[string]time.Time)
+	for hash, trackedTime := range w.trackedTxs {
+		if now.Sub(trackedTime) >= TrackTxTimeout {
+			timeouts[hash] = trackedTime
+		}
+	}
+	for hash := range w.notFoundTxs {
+		if _, ok := w.trackedTxs[hash]; !ok {
+			delete(w.notFoundTxs, hash)
+		}
+	}
+	w.trackLock.Unlock()
+
+	for hash, trackedTime := range timeouts {
+		tx, err := w.client.TransactionById(hash)
+		if !IsApiErrCode(err, APIErrNotFound) {
+			delete(w.notFoundTxs, hash)
+		}
+
+		var result chainstypes.TrackResult
+		var blockHeight int64
+		switch {
+		case IsApiErrCode(err, APIErrNotFound):
+			w.notFoundTxs[hash]++
+			if w.notFoundTxs[hash] < TrackTxNotFoundChecks {
+				log.Verbosef("Lisk tx %s is not found, check count = %d", hash, w.notFoundTxs[hash])
+				continue
+			}
+
+			// The transaction is rejected or dropped from the pool.
+			delete(w.notFoundTxs, hash)
+			result = chainstypes.TrackResultFailure
+
+		case err != nil:
+			log.Warnf("Failed to get lisk tx %s, err = %v", hash, err)
+			continue
+
+		case now.Sub(trackedTime) < TrackTxExpiry:
+			// The transaction is still in the pool or in a block that has not been processed.
+			continue
+
+		case isPendingTx(tx):
+			result = chainstypes.TrackResultTimeout
+
+		case tx.ExecutionStatus == lisktypes.ExecutionStatusFailed:
+			result = chainstypes.TrackResultFailure
+
+		default:
+			// The transaction was included in a block before it was tracked. The block is final
+			// by now.
+			result = chainstypes.TrackResultConfirmed
+			blockHeight = tx.Block.Height
+		}
+
+		if !w.untrackTx(hash) {
+			// The transaction has been confirmed in the meantime.
+			continue
+		}
+
+		log.Warnf("Lisk tx %s is not found in the processed blocks, result = %d", hash, result)
+		w.txTrackCh <- &chainstypes.TrackUpdate{
+			Chain:       w.cfg.Chain,
+			Hash:        hash,
+			BlockHeight: blockHeight,
+			Result:      result,
+		}
+	}
+}
+
+func isPendingTx(tx *lisktypes.Transaction) bool {
+	return tx.IsPending || tx.ExecutionStatus == lisktypes.ExecutionStatusPending || tx.Block == nil
+}
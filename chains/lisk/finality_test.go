@@ -0,0 +1,158 @@
+package lisk
+
+import (
+	"testing"
+	"time"
+
+	ltypes "github.com/sisu-network/deyes/chains/lisk/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFinalityWatcher(client Client) (*Watcher, chan *types.Txs, chan *chainstypes.TrackUpdate) {
+	cfg := config.Chain{
+		Chain:     "lisk-testnet",
+		BlockTime: 1000,
+	}
+	txsCh := make(chan *types.Txs, 10)
+	txTrackCh := make(chan *chainstypes.TrackUpdate, 10)
+	watcher := NewWatcher(getTestDb(), cfg, txsCh, txTrackCh, client).(*Watcher)
+	watcher.vault = "vault"
+
+	return watcher, txsCh, txTrackCh
+}
+
+func newTestDepositBlock(height uint64, id string) *ltypes.Block {
+	return &ltypes.Block{
+		Id:     id,
+		Height: height,
+		Transactions: []*ltypes.Transaction{
+			{
+				Id:     id + "_tx",
+				Sender: &ltypes.Sender{Address: "sender"},
+				Asset: &ltypes.Asset{
+					Amount:    "1000",
+					Recipient: &ltypes.AssetRecipient{Address: "vault"},
+				},
+			},
+		},
+	}
+}
+
+func TestWatcher_Finality(t *testing.T) {
+	blockIds := map[uint64]string{
+		10: "block10",
+		11: "block11_fork",
+	}
+	finalizedHeight := uint64(9)
+	client := &MockLiskClient{
+		FinalizedHeightFunc: func() (uint64, error) {
+			return finalizedHeight, nil
+		},
+		BlockByHeightFunc: func(height uint64) (*ltypes.Block, error) {
+			return &ltypes.Block{Id: blockIds[height], Height: height, NumberOfTransactions: 2}, nil
+		},
+		TransactionByBlockFunc: func(block string) ([]*ltypes.Transaction, error) {
+			require.Equal(t, "block11_fork", block)
+
+			// The final block contains the tracked tx and another deposit.
+			fork := newTestDepositBlock(11, "block11_fork")
+			return append(fork.Transactions, &ltypes.Transaction{Id: "block11_tx"}), nil
+		},
+	}
+	watcher, txsCh, txTrackCh := newTestFinalityWatcher(client)
+
+	// Deposits are not reported before their blocks are final.
+	watcher.TrackTx("block11_tx")
+	watcher.processBlock(newTestDepositBlock(10, "block10"))
+	watcher.processBlock(newTestDepositBlock(11, "block11"))
+	require.Equal(t, 0, len(txsCh))
+	require.Equal(t, 0, len(txTrackCh))
+	require.Equal(t, 2, len(watcher.pendingBlocks))
+
+	// Prevoted blocks are not final.
+	watcher.processBlock(&ltypes.Block{Id: "block12", Height: 12, MaxHeightPrevoted: 11})
+	require.Equal(t, 0, len(txsCh))
+	require.Equal(t, 3, len(watcher.pendingBlocks))
+
+	// Blocks 10 and 11 are final. Block 11 has been replaced by another block, whose transactions are
+	// processed instead.
+	finalizedHeight = 11
+	watcher.processBlock(&ltypes.Block{Id: "block13", Height: 13, MaxHeightPrevoted: 12})
+	require.Equal(t, 2, len(txsCh))
+	txs := <-txsCh
+	require.Equal(t, int64(10), txs.Block)
+	require.Equal(t, "block10_tx", txs.Arr[0].Hash)
+	require.True(t, txs.Arr[0].Success)
+	txs = <-txsCh
+	require.Equal(t, int64(11), txs.Block)
+	require.Equal(t, "block11_fork", txs.BlockHash)
+	require.Equal(t, "block11_fork_tx", txs.Arr[0].Hash)
+
+	// The tracked tx of the dropped block is confirmed in the final block.
+	require.Equal(t, 1, len(txTrackCh))
+	update := <-txTrackCh
+	require.Equal(t, "block11_tx", update.Hash)
+	require.Equal(t, chainstypes.TrackResultConfirmed, update.Result)
+	require.Equal(t, 2, len(watcher.pendingBlocks))
+	require.Equal(t, 0, len(watcher.trackedTxs))
+}
+
+func TestWatcher_CheckTrackedTxs(t *testing.T) {
+	client := &MockLiskClient{
+		TransactionByIdFunc: func(id string) (*ltypes.Transaction, error) {
+			switch id {
+			case "rejected":
+				return nil, NewApiErrWithCode(APIErrNotFound, 0, "lisk transaction is not found")
+			case "failed":
+				return &ltypes.Transaction{
+					Id:              id,
+					Block:           &ltypes.TransactionBlock{Height: 5},
+					ExecutionStatus: ltypes.ExecutionStatusFailed,
+				}, nil
+			default:
+				return &ltypes.Transaction{Id: id, IsPending: true}, nil
+			}
+		},
+	}
+	watcher, _, txTrackCh := newTestFinalityWatcher(client)
+
+	now := time.Now()
+	watcher.trackedTxs["rejected"] = now.Add(-TrackTxTimeout)
+	watcher.trackedTxs["pending"] = now.Add(-TrackTxTimeout)
+	watcher.trackedTxs["expired"] = now.Add(-TrackTxExpiry)
+	watcher.trackedTxs["failed"] = now.Add(-TrackTxExpiry)
+	watcher.trackedTxs["recent"] = now
+
+	// A transaction is rejected when it is not found in several checks.
+	for i := 1; i < TrackTxNotFoundChecks; i++ {
+		watcher.checkTrackedTxs(now)
+		require.Equal(t, i, watcher.notFoundTxs["rejected"])
+		_, ok := watcher.trackedTxs["rejected"]
+		require.True(t, ok)
+
+		for len(txTrackCh) > 0 {
+			require.NotEqual(t, "rejected", (<-txTrackCh).Hash)
+		}
+	}
+
+	watcher.trackedTxs["expired"] = now.Add(-TrackTxExpiry)
+	watcher.trackedTxs["failed"] = now.Add(-TrackTxExpiry)
+	watcher.checkTrackedTxs(now)
+
+	results := make(map[string]chainstypes.TrackResult)
+	for len(txTrackCh) > 0 {
+		update := <-txTrackCh
+		results[update.Hash] = update.Result
+	}
+	require.Equal(t, map[string]chainstypes.TrackResult{
+		"rejected": chainstypes.TrackResultFailure,
+		"expired":  chainstypes.TrackResultTimeout,
+		"failed":   chainstypes.TrackResultFailure,
+	}, results)
+
+	require.Equal(t, 2, len(watcher.trackedTxs))
+	require.Equal(t, 0, len(watcher.notFoundTxs))
+}
@@ -5,12 +5,15 @@ import (
 )
 
 type MockLiskClient struct {
-	StartFunc              func()
-	BlockNumberFunc        func() (uint64, error)
-	BlockByHeightFunc      func(height uint64) (*types.Block, error)
-	TransactionByBlockFunc func(block string) ([]*types.Transaction, error)
-	GetAccountFunc         func(address string) (*types.Account, error)
-	CreateTransactionFunc  func(txHash string) (string, error)
+	StartFunc               func()
+	BlockNumberFunc         func() (uint64, error)
+	FinalizedHeightFunc     func() (uint64, error)
+	BlockByHeightFunc       func(height uint64) (*types.Block, error)
+	TransactionByBlockFunc  func(block string) ([]*types.Transaction, error)
+	TransactionByIdFunc     func(id string) (*types.Transaction, error)
+	EventsByTransactionFunc func(id string) ([]*types.Event, error)
+	GetAccountFunc          func(address string) (*types.Account, error)
+	CreateTransactionFunc   func(txHash string) (string, error)
 }
 
 func (c *MockLiskClient) Start() {
@@ -27,6 +30,14 @@ func (c *MockLiskClient) BlockNumber() (uint64, error) {
 	return 0, nil
 }
 
+func (c *MockLiskClient) FinalizedHeight() (uint64, error) {
+	if c.FinalizedHeightFunc != nil {
+		return c.FinalizedHeightFunc()
+	}
+
+	return 0, nil
+}
+
 func (c *MockLiskClient) BlockByHeight(height uint64) (*types.Block, error) {
 	if c.BlockByHeightFunc != nil {
 		return c.BlockByHeightFunc(height)
@@ -58,3 +69,19 @@ func (c *MockLiskClient) TransactionByBlock(block string) ([]*types.Transaction,
 
 	return nil, nil
 }
+
+func (c *MockLiskClient) TransactionById(id string) (*types.Transaction, error) {
+	if c.TransactionByIdFunc != nil {
+		return c.TransactionByIdFunc(id)
+	}
+
+	return nil, nil
+}
+
+func (c *MockLiskClient) EventsByTransaction(id string) ([]*types.Event, error) {
+	if c.EventsByTransactionFunc != nil {
+		return c.EventsByTransactionFunc(id)
+	}
+
+	return nil, nil
+}
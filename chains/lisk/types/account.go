@@ -45,3 +45,26 @@ type DposDelegate struct {
 	Rank                    uint64 `json:"Rank"`
 	Status                  string `json:"status"`
 }
+
+// Lisk v4 (Lisk Service v3)
+
+type ResponseAuth struct {
+	Data *AuthAccount `json:"data"`
+}
+
+type AuthAccount struct {
+	Nonce              string   `json:"nonce"`
+	NumberOfSignatures uint64   `json:"numberOfSignatures"`
+	MandatoryKeys      []string `json:"mandatoryKeys"`
+	OptionalKeys       []string `json:"optionalKeys"`
+}
+
+type ResponseTokenBalances struct {
+	Data []*TokenBalance `json:"data"`
+	Meta *Meta           `json:"meta"`
+}
+
+type TokenBalance struct {
+	TokenID          string `json:"tokenID"`
+	AvailableBalance string `json:"availableBalance"`
+}
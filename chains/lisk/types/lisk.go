@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 type ResponseBlock struct {
 	Data []*Block `json:"data"`
 	Meta *Meta
@@ -9,6 +11,21 @@ type ResponseTransaction struct {
 	Data []*Transaction `json:"data"`
 	Meta *Meta
 }
+type ResponseEvent struct {
+	Data []*Event `json:"data"`
+	Meta *Meta
+}
+
+type ResponseNetworkStatus struct {
+	Data *NetworkStatus `json:"data"`
+}
+
+type NetworkStatus struct {
+	Height uint64 `json:"height"`
+	// Height of the last block that is precommitted by the validators.
+	FinalizedHeight uint64 `json:"finalizedHeight"`
+}
+
 type ResponseAccount struct {
 	Data []*Account `json:"data"`
 	Meta *Meta
@@ -57,6 +74,7 @@ type Block struct {
 	TransactionRoot           string         `json:"transactionRoot"`
 	Signature                 string         `json:"signature"`
 	PreviousBlockId           string         `json:"previousBlockId"`
+	PreviousBlockID           string         `json:"previousBlockID"`
 	NumberOfTransactions      int64          `json:"numberOfTransactions"`
 	TotalForged               string         `json:"totalForged"`
 	TotalBurnt                string         `json:"totalBurnt"`
@@ -74,3 +92,13 @@ type Params struct {
 	Height  string `json:"height"`
 	BlockId string `json:"blockId"`
 }
+
+// Event is an event emitted by a module when a block or a transaction is executed (Lisk v4).
+type Event struct {
+	Id     string          `json:"id"`
+	Module string          `json:"module"`
+	Name   string          `json:"name"`
+	Data   json.RawMessage `json:"data"`
+	Topics []string        `json:"topics"`
+	Index  int             `json:"index"`
+}
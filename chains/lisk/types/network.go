@@ -5,3 +5,13 @@ var (
 		"lisk-testnet": "15f0dacc1060e91818224a94286b13aa04279c640bd5d6f193182031d133df7c",
 	}
 )
+
+var (
+	// ChainId is the id of the chains of Lisk v4 (Lisk SDK 6), which is part of the signed bytes of
+	// transactions.
+	ChainId = map[string]string{
+		"lisk-mainnet": "00000000",
+		"lisk-testnet": "01000000",
+		"lisk-devnet":  "04000000",
+	}
+)
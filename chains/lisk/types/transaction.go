@@ -1,6 +1,9 @@
 package types
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type Transaction struct {
 	Id              string            `json:"id"`
@@ -13,9 +16,22 @@ type Transaction struct {
 	Signatures      []string          `json:"signatures"`
 	Asset           *Asset            `json:"asset"`
 	IsPending       bool              `json:"isPending"`
+
+	// Lisk v4 (Lisk Service v3)
+	ModuleCommand   string          `json:"moduleCommand,omitempty"`
+	Params          json.RawMessage `json:"params,omitempty"`
+	ExecutionStatus string          `json:"executionStatus,omitempty"`
+	// Transfer is the decoded transfer of a token:transfer or token:transferCrossChain command.
+	Transfer *Transfer `json:"transfer,omitempty"`
+	// CcmTransfers are the tokens received from other chains by a cross chain update.
+	CcmTransfers []*Transfer `json:"ccmTransfers,omitempty"`
 }
 
 func (tx *Transaction) Validate() error {
+	if len(tx.ModuleCommand) > 0 {
+		return tx.validateV4()
+	}
+
 	if tx.Asset == nil {
 		return fmt.Errorf("Tx asset is nil")
 	}
@@ -31,6 +47,28 @@ func (tx *Transaction) Validate() error {
 	return nil
 }
 
+func (tx *Transaction) validateV4() error {
+	if tx.Transfer == nil && len(tx.CcmTransfers) == 0 {
+		return fmt.Errorf("Tx is not a token transfer, module command = %s", tx.ModuleCommand)
+	}
+
+	if tx.Sender == nil {
+		return fmt.Errorf("Tx sender is nil")
+	}
+
+	if tx.Transfer != nil && len(tx.Transfer.Recipient) == 0 {
+		return fmt.Errorf("Transfer recipient is empty")
+	}
+
+	for _, transfer := range tx.CcmTransfers {
+		if len(transfer.Recipient) == 0 {
+			return fmt.Errorf("Transfer recipient is empty")
+		}
+	}
+
+	return nil
+}
+
 type TransactionResponse struct {
 	Message       string `json:"message"`
 	TransactionId string `json:"transactionId"`
@@ -0,0 +1,567 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/sisu-network/deyes/chains/lisk/crypto"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Types of Lisk v4 (Lisk SDK 6). Transactions and command params are encoded with lisk-codec,
+// which uses the protobuf wire format with fields in schema order.
+
+const (
+	ModuleToken                = "token"
+	CommandTransfer            = "transfer"
+	CommandTransferCrossChain  = "transferCrossChain"
+	ModuleCommandTransfer      = ModuleToken + ":" + CommandTransfer
+	ModuleCommandTransferCross = ModuleToken + ":" + CommandTransferCrossChain
+
+	ModuleInteroperability = "interoperability"
+	// Commands of the interoperability module that submit the CCMs (cross chain messages) sent by
+	// other chains.
+	ModuleCommandSubmitMainchainCCU = ModuleInteroperability + ":submitMainchainCrossChainUpdate"
+	ModuleCommandSubmitSidechainCCU = ModuleInteroperability + ":submitSidechainCrossChainUpdate"
+
+	// EventCcmTransfer is emitted by the token module when a CCM credits tokens to an account.
+	EventCcmTransfer = "ccmTransfer"
+	// EventCcmProcessed is emitted by the interoperability module when a CCM is processed.
+	EventCcmProcessed = "ccmProcessed"
+	// Result of token events and ccmProcessed events when the CCM is applied.
+	EventResultSuccessful = 0
+
+	ExecutionStatusSuccessful = "successful"
+	ExecutionStatusFailed     = "failed"
+	ExecutionStatusPending    = "pending"
+
+	// TransactionTag is the tag prepended to the signed bytes of a transaction.
+	TransactionTag = "LSK_TX_"
+
+	ChainIdLength = 4
+	TokenIdLength = 8
+	AddressLength = 20
+)
+
+// TransactionV4 is a Lisk v4 transaction.
+type TransactionV4 struct {
+	Module          string
+	Command         string
+	Nonce           uint64
+	Fee             uint64
+	SenderPublicKey []byte
+	Params          []byte
+	Signatures      [][]byte
+}
+
+// Encode returns the lisk-codec encoding of the transaction.
+func (tx *TransactionV4) Encode() []byte {
+	var bz []byte
+	bz = appendString(bz, 1, tx.Module)
+	bz = appendString(bz, 2, tx.Command)
+	bz = appendUint64(bz, 3, tx.Nonce)
+	bz = appendUint64(bz, 4, tx.Fee)
+	bz = appendBytes(bz, 5, tx.SenderPublicKey)
+	bz = appendBytes(bz, 6, tx.Params)
+	for _, signature := range tx.Signatures {
+		bz = appendBytes(bz, 7, signature)
+	}
+
+	return bz
+}
+
+// SigningBytes returns the bytes signed by the sender: the transaction tag, the chain id and the
+// encoding of the transaction without signatures.
+func (tx *TransactionV4) SigningBytes(chainId []byte) []byte {
+	unsigned := *tx
+	unsigned.Signatures = nil
+
+	bz := append([]byte(TransactionTag), chainId...)
+	return append(bz, unsigned.Encode()...)
+}
+
+// Id returns the transaction id, which is the sha256 of the signed transaction.
+func (tx *TransactionV4) Id() string {
+	hash := sha256.Sum256(tx.Encode())
+	return hex.EncodeToString(hash[:])
+}
+
+// ModuleCommand returns the module and command in the format of Lisk Service, e.g. token:transfer.
+func (tx *TransactionV4) ModuleCommand() string {
+	return tx.Module + ":" + tx.Command
+}
+
+// transactionV4WireTypes are the wire types of the fields of a transaction.
+var transactionV4WireTypes = map[protowire.Number]protowire.Type{
+	1: protowire.BytesType,
+	2: protowire.BytesType,
+	3: protowire.VarintType,
+	4: protowire.VarintType,
+	5: protowire.BytesType,
+	6: protowire.BytesType,
+	7: protowire.BytesType,
+}
+
+func DecodeTransactionV4(bz []byte) (*TransactionV4, error) {
+	tx := &TransactionV4{}
+	err := decodeFields(bz, transactionV4WireTypes, func(num protowire.Number, value interface{}) error {
+		switch num {
+		case 1:
+			tx.Module = string(value.([]byte))
+		case 2:
+			tx.Command = string(value.([]byte))
+		case 3:
+			tx.Nonce = value.(uint64)
+		case 4:
+			tx.Fee = value.(uint64)
+		case 5:
+			tx.SenderPublicKey = value.([]byte)
+		case 6:
+			tx.Params = value.([]byte)
+		case 7:
+			tx.Signatures = append(tx.Signatures, value.([]byte))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// TransferParams are the params of the token:transfer command in the format of Lisk Service.
+type TransferParams struct {
+	TokenID          string `json:"tokenID"`
+	Amount           string `json:"amount"`
+	RecipientAddress string `json:"recipientAddress"`
+	Data             string `json:"data"`
+}
+
+// TransferCrossChainParams are the params of the token:transferCrossChain command in the format
+// of Lisk Service.
+type TransferCrossChainParams struct {
+	TokenID           string `json:"tokenID"`
+	Amount            string `json:"amount"`
+	ReceivingChainID  string `json:"receivingChainID"`
+	RecipientAddress  string `json:"recipientAddress"`
+	Data              string `json:"data"`
+	MessageFee        string `json:"messageFee"`
+	MessageFeeTokenID string `json:"messageFeeTokenID"`
+}
+
+func (p *TransferParams) Encode() ([]byte, error) {
+	tokenId, recipient, amount, err := decodeTransferFields(p.TokenID, p.RecipientAddress, p.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	var bz []byte
+	bz = appendBytes(bz, 1, tokenId)
+	bz = appendUint64(bz, 2, amount)
+	bz = appendBytes(bz, 3, recipient)
+	bz = appendString(bz, 4, p.Data)
+
+	return bz, nil
+}
+
+func (p *TransferCrossChainParams) Encode() ([]byte, error) {
+	tokenId, recipient, amount, err := decodeTransferFields(p.TokenID, p.RecipientAddress, p.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	chainId, err := hex.DecodeString(p.ReceivingChainID)
+	if err != nil || len(chainId) != ChainIdLength {
+		return nil, fmt.Errorf("invalid receiving chain id %s", p.ReceivingChainID)
+	}
+
+	messageFeeTokenId, err := hex.DecodeString(p.MessageFeeTokenID)
+	if err != nil || len(messageFeeTokenId) != TokenIdLength {
+		return nil, fmt.Errorf("invalid message fee token id %s", p.MessageFeeTokenID)
+	}
+
+	messageFee, err := strconv.ParseUint(p.MessageFee, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message fee %s", p.MessageFee)
+	}
+
+	var bz []byte
+	bz = appendBytes(bz, 1, tokenId)
+	bz = appendUint64(bz, 2, amount)
+	bz = appendBytes(bz, 3, chainId)
+	bz = appendBytes(bz, 4, recipient)
+	bz = appendString(bz, 5, p.Data)
+	bz = appendUint64(bz, 6, messageFee)
+	bz = appendBytes(bz, 7, messageFeeTokenId)
+
+	return bz, nil
+}
+
+// transferParamsWireTypes are the wire types of the fields of the token:transfer params.
+var transferParamsWireTypes = map[protowire.Number]protowire.Type{
+	1: protowire.BytesType,
+	2: protowire.VarintType,
+	3: protowire.BytesType,
+	4: protowire.BytesType,
+}
+
+func DecodeTransferParams(bz []byte) (*TransferParams, error) {
+	p := &TransferParams{}
+	err := decodeFields(bz, transferParamsWireTypes, func(num protowire.Number, value interface{}) error {
+		switch num {
+		case 1:
+			p.TokenID = hex.EncodeToString(value.([]byte))
+		case 2:
+			p.Amount = strconv.FormatUint(value.(uint64), 10)
+		case 3:
+			p.RecipientAddress = crypto.AddressToLisk32(value.([]byte))
+		case 4:
+			p.Data = string(value.([]byte))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// transferCrossChainParamsWireTypes are the wire types of the fields of the
+// token:transferCrossChain params.
+var transferCrossChainParamsWireTypes = map[protowire.Number]protowire.Type{
+	1: protowire.BytesType,
+	2: protowire.VarintType,
+	3: protowire.BytesType,
+	4: protowire.BytesType,
+	5: protowire.BytesType,
+	6: protowire.VarintType,
+	7: protowire.BytesType,
+}
+
+func DecodeTransferCrossChainParams(bz []byte) (*TransferCrossChainParams, error) {
+	p := &TransferCrossChainParams{}
+	err := decodeFields(bz, transferCrossChainParamsWireTypes, func(num protowire.Number, value interface{}) error {
+		switch num {
+		case 1:
+			p.TokenID = hex.EncodeToString(value.([]byte))
+		case 2:
+			p.Amount = strconv.FormatUint(value.(uint64), 10)
+		case 3:
+			p.ReceivingChainID = hex.EncodeToString(value.([]byte))
+		case 4:
+			p.RecipientAddress = crypto.AddressToLisk32(value.([]byte))
+		case 5:
+			p.Data = string(value.([]byte))
+		case 6:
+			p.MessageFee = strconv.FormatUint(value.(uint64), 10)
+		case 7:
+			p.MessageFeeTokenID = hex.EncodeToString(value.([]byte))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// crossChainTransferMessageParamsWireTypes are the wire types of the fields of the params of a
+// token transfer CCM.
+var crossChainTransferMessageParamsWireTypes = map[protowire.Number]protowire.Type{
+	1: protowire.BytesType,
+	2: protowire.VarintType,
+	3: protowire.BytesType,
+	4: protowire.BytesType,
+	5: protowire.BytesType,
+}
+
+// DecodeCrossChainTransferMessageParams decodes the params of a token transfer CCM received from
+// another chain.
+func DecodeCrossChainTransferMessageParams(bz []byte) (*Transfer, error) {
+	transfer := &Transfer{CrossChain: true}
+	err := decodeFields(bz, crossChainTransferMessageParamsWireTypes, func(num protowire.Number, value interface{}) error {
+		switch num {
+		case 1:
+			transfer.TokenID = hex.EncodeToString(value.([]byte))
+		case 2:
+			transfer.Amount = strconv.FormatUint(value.(uint64), 10)
+		case 3:
+			transfer.Sender = crypto.AddressToLisk32(value.([]byte))
+		case 4:
+			transfer.Recipient = crypto.AddressToLisk32(value.([]byte))
+		case 5:
+			transfer.Data = string(value.([]byte))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// Transfer is a token transfer of a Lisk v4 transaction.
+type Transfer struct {
+	CrossChain bool   `json:"crossChain"`
+	TokenID    string `json:"tokenID"`
+	Amount     string `json:"amount"`
+	Recipient  string `json:"recipient"`
+	Data       string `json:"data"`
+	// Chain that receives a cross chain transfer.
+	ReceivingChainID string `json:"receivingChainID,omitempty"`
+	// Sender and chain that sent a transfer received from another chain.
+	Sender         string `json:"sender,omitempty"`
+	SendingChainID string `json:"sendingChainID,omitempty"`
+}
+
+// CcmTransferEventData is the data of a token:ccmTransfer event in the format of Lisk Service.
+type CcmTransferEventData struct {
+	SenderAddress    string `json:"senderAddress"`
+	RecipientAddress string `json:"recipientAddress"`
+	TokenID          string `json:"tokenID"`
+	Amount           string `json:"amount"`
+	ReceivingChainID string `json:"receivingChainID"`
+	Result           int    `json:"result"`
+}
+
+// CcmProcessedEventData is the data of an interoperability:ccmProcessed event in the format of
+// Lisk Service.
+type CcmProcessedEventData struct {
+	Ccm struct {
+		Module            string `json:"module"`
+		CrossChainCommand string `json:"crossChainCommand"`
+		SendingChainID    string `json:"sendingChainID"`
+		ReceivingChainID  string `json:"receivingChainID"`
+		Params            string `json:"params"`
+	} `json:"ccm"`
+	Result int `json:"result"`
+}
+
+// IsCrossChainUpdate returns true if the transaction submits CCMs sent by other chains.
+func (tx *Transaction) IsCrossChainUpdate() bool {
+	return tx.ModuleCommand == ModuleCommandSubmitMainchainCCU ||
+		tx.ModuleCommand == ModuleCommandSubmitSidechainCCU
+}
+
+// GetCcmTransfers returns the tokens received from other chains by the CCMs of a cross chain
+// update. A transfer is credited when the token module emits a successful ccmTransfer event. Its
+// data and sending chain are taken from the matching ccmProcessed event.
+func GetCcmTransfers(events []*Event) ([]*Transfer, error) {
+	messages := make([]*Transfer, 0)
+	for _, event := range events {
+		if event.Module != ModuleInteroperability || event.Name != EventCcmProcessed {
+			continue
+		}
+
+		data := &CcmProcessedEventData{}
+		if err := json.Unmarshal(event.Data, data); err != nil {
+			return nil, err
+		}
+
+		if data.Result != EventResultSuccessful || data.Ccm.Module != ModuleToken ||
+			data.Ccm.CrossChainCommand != CommandTransferCrossChain {
+			continue
+		}
+
+		params, err := hex.DecodeString(data.Ccm.Params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ccm params %s", data.Ccm.Params)
+		}
+
+		message, err := DecodeCrossChainTransferMessageParams(params)
+		if err != nil {
+			return nil, err
+		}
+		message.SendingChainID = data.Ccm.SendingChainID
+		message.ReceivingChainID = data.Ccm.ReceivingChainID
+		messages = append(messages, message)
+	}
+
+	transfers := make([]*Transfer, 0)
+	for _, event := range events {
+		if event.Module != ModuleToken || event.Name != EventCcmTransfer {
+			continue
+		}
+
+		data := &CcmTransferEventData{}
+		if err := json.Unmarshal(event.Data, data); err != nil {
+			return nil, err
+		}
+
+		if data.Result != EventResultSuccessful {
+			continue
+		}
+
+		transfer := &Transfer{
+			CrossChain:       true,
+			TokenID:          data.TokenID,
+			Amount:           data.Amount,
+			Recipient:        data.RecipientAddress,
+			ReceivingChainID: data.ReceivingChainID,
+			Sender:           data.SenderAddress,
+		}
+
+		for i, message := range messages {
+			if message.TokenID == transfer.TokenID && message.Amount == transfer.Amount &&
+				message.Sender == transfer.Sender && message.Recipient == transfer.Recipient {
+				transfer.Data = message.Data
+				transfer.SendingChainID = message.SendingChainID
+				messages = append(messages[:i], messages[i+1:]...)
+				break
+			}
+		}
+
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// GetTransfer returns the transfer of a token:transfer or token:transferCrossChain transaction
+// from Lisk Service. It returns nil if the transaction is not a transfer.
+func (tx *Transaction) GetTransfer() (*Transfer, error) {
+	switch tx.ModuleCommand {
+	case ModuleCommandTransfer:
+		params := &TransferParams{}
+		if err := json.Unmarshal(tx.Params, params); err != nil {
+			return nil, err
+		}
+
+		return &Transfer{
+			TokenID:   params.TokenID,
+			Amount:    params.Amount,
+			Recipient: params.RecipientAddress,
+			Data:      params.Data,
+		}, nil
+
+	case ModuleCommandTransferCross:
+		params := &TransferCrossChainParams{}
+		if err := json.Unmarshal(tx.Params, params); err != nil {
+			return nil, err
+		}
+
+		return &Transfer{
+			CrossChain:       true,
+			TokenID:          params.TokenID,
+			Amount:           params.Amount,
+			Recipient:        params.RecipientAddress,
+			Data:             params.Data,
+			ReceivingChainID: params.ReceivingChainID,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// GetTokenChainId returns the id of the chain that issues a token. A token id is the chain id (4
+// bytes) followed by the local id of the token in the chain (4 bytes).
+func GetTokenChainId(tokenId string) (string, error) {
+	bz, err := hex.DecodeString(tokenId)
+	if err != nil || len(bz) != TokenIdLength {
+		return "", fmt.Errorf("invalid token id %s", tokenId)
+	}
+
+	return hex.EncodeToString(bz[:ChainIdLength]), nil
+}
+
+// IsLSKToken returns true if the token is LSK, the token of the Lisk mainchain. The first byte
+// of the chain id is the network, the other bytes are zero for the mainchain.
+func IsLSKToken(tokenId string) bool {
+	bz, err := hex.DecodeString(tokenId)
+	if err != nil || len(bz) != TokenIdLength {
+		return false
+	}
+
+	for _, b := range bz[1:] {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func decodeTransferFields(tokenId, recipient, amount string) ([]byte, []byte, uint64, error) {
+	tokenIdBytes, err := hex.DecodeString(tokenId)
+	if err != nil || len(tokenIdBytes) != TokenIdLength {
+		return nil, nil, 0, fmt.Errorf("invalid token id %s", tokenId)
+	}
+
+	recipientBytes, err := crypto.Lisk32AddressToPublicAddress(recipient)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	amountValue, err := strconv.ParseUint(amount, 10, 64)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("invalid amount %s", amount)
+	}
+
+	return tokenIdBytes, recipientBytes, amountValue, nil
+}
+
+func appendString(bz []byte, num protowire.Number, value string) []byte {
+	return appendBytes(bz, num, []byte(value))
+}
+
+func appendBytes(bz []byte, num protowire.Number, value []byte) []byte {
+	bz = protowire.AppendTag(bz, num, protowire.BytesType)
+	return protowire.AppendBytes(bz, value)
+}
+
+func appendUint64(bz []byte, num protowire.Number, value uint64) []byte {
+	bz = protowire.AppendTag(bz, num, protowire.VarintType)
+	return protowire.AppendVarint(bz, value)
+}
+
+// decodeFields calls f with each field of a message. Varints are passed as uint64 and length
+// delimited fields as []byte. It fails if the wire type of a field is not the one in wireTypes.
+func decodeFields(bz []byte, wireTypes map[protowire.Number]protowire.Type,
+	f func(num protowire.Number, value interface{}) error) error {
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		bz = bz[n:]
+
+		if expected, ok := wireTypes[num]; ok && expected != typ {
+			return fmt.Errorf("invalid wire type %d for field %d, expected %d", typ, num, expected)
+		}
+
+		var value interface{}
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(bz)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value = v
+			bz = bz[n:]
+
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(bz)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value = v
+			bz = bz[n:]
+
+		default:
+			return fmt.Errorf("unsupported wire type %d for field %d", typ, num)
+		}
+
+		if err := f(num, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/sisu-network/deyes/chains/lisk/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+const testLisk32Address = "lskcjqwdg9ezqtnpyd3f866nu4pdspsrft3rx5y8d"
+
+func TestTransactionV4_EncodeDecode(t *testing.T) {
+	params := &TransferParams{
+		TokenID:          "0400000000000000",
+		Amount:           "100000000",
+		RecipientAddress: testLisk32Address,
+		Data:             "sisu",
+	}
+	bz, err := params.Encode()
+	require.Nil(t, err)
+
+	tx := &TransactionV4{
+		Module:          ModuleToken,
+		Command:         CommandTransfer,
+		Nonce:           5,
+		Fee:             164000,
+		SenderPublicKey: make([]byte, 32),
+		Params:          bz,
+		Signatures:      [][]byte{make([]byte, 64)},
+	}
+
+	decoded, err := DecodeTransactionV4(tx.Encode())
+	require.Nil(t, err)
+	require.Equal(t, tx, decoded)
+	require.Equal(t, ModuleCommandTransfer, decoded.ModuleCommand())
+	require.Equal(t, tx.Id(), decoded.Id())
+
+	chainId := []byte{4, 0, 0, 0}
+	unsigned := *tx
+	unsigned.Signatures = nil
+	require.Equal(t, append([]byte("LSK_TX_\x04\x00\x00\x00"), unsigned.Encode()...), tx.SigningBytes(chainId))
+
+	decodedParams, err := DecodeTransferParams(decoded.Params)
+	require.Nil(t, err)
+	require.Equal(t, params, decodedParams)
+}
+
+func TestDecodeV4_InvalidWireType(t *testing.T) {
+	// The module is a varint instead of a string.
+	_, err := DecodeTransactionV4(appendUint64(nil, 1, 5))
+	require.NotNil(t, err)
+
+	// The amount is a string instead of a varint.
+	_, err = DecodeTransferParams(appendString(nil, 2, "100"))
+	require.NotNil(t, err)
+
+	// The receiving chain id is a varint instead of bytes.
+	_, err = DecodeTransferCrossChainParams(appendUint64(nil, 3, 4))
+	require.NotNil(t, err)
+}
+
+func TestTransferCrossChainParams_EncodeDecode(t *testing.T) {
+	params := &TransferCrossChainParams{
+		TokenID:           "0400000100000000",
+		Amount:            "2500",
+		ReceivingChainID:  "04000001",
+		RecipientAddress:  testLisk32Address,
+		Data:              "",
+		MessageFee:        "10000000",
+		MessageFeeTokenID: "0400000000000000",
+	}
+	bz, err := params.Encode()
+	require.Nil(t, err)
+
+	decoded, err := DecodeTransferCrossChainParams(bz)
+	require.Nil(t, err)
+	require.Equal(t, params, decoded)
+
+	params.ReceivingChainID = "0400"
+	_, err = params.Encode()
+	require.NotNil(t, err)
+}
+
+func TestTransaction_GetTransfer(t *testing.T) {
+	tx := &Transaction{
+		ModuleCommand: ModuleCommandTransferCross,
+		Params: json.RawMessage(`{"tokenID":"0400000100000000","amount":"2500",` +
+			`"receivingChainID":"04000001","recipientAddress":"` + testLisk32Address + `",` +
+			`"data":"memo","messageFee":"10000000","messageFeeTokenID":"0400000000000000"}`),
+	}
+
+	transfer, err := tx.GetTransfer()
+	require.Nil(t, err)
+	require.Equal(t, &Transfer{
+		CrossChain:       true,
+		TokenID:          "0400000100000000",
+		Amount:           "2500",
+		Recipient:        testLisk32Address,
+		Data:             "memo",
+		ReceivingChainID: "04000001",
+	}, transfer)
+
+	tx.ModuleCommand = "pos:stake"
+	transfer, err = tx.GetTransfer()
+	require.Nil(t, err)
+	require.Nil(t, transfer)
+}
+
+func TestGetCcmTransfers(t *testing.T) {
+	sender := "lskqz6gpqfu9tb5yc2jtqmqvqp3x8ze35g99u2zfd"
+	senderBytes, err := crypto.Lisk32AddressToPublicAddress(sender)
+	require.Nil(t, err)
+	recipientBytes, err := crypto.Lisk32AddressToPublicAddress(testLisk32Address)
+	require.Nil(t, err)
+	tokenId, err := hex.DecodeString("0400000100000000")
+	require.Nil(t, err)
+
+	var params []byte
+	params = appendBytes(params, 1, tokenId)
+	params = appendUint64(params, 2, 2500)
+	params = appendBytes(params, 3, senderBytes)
+	params = appendBytes(params, 4, recipientBytes)
+	params = appendString(params, 5, "memo")
+
+	events := []*Event{
+		{
+			Module: ModuleToken,
+			Name:   EventCcmTransfer,
+			Data: json.RawMessage(`{"senderAddress":"` + sender + `","recipientAddress":"` + testLisk32Address +
+				`","tokenID":"0400000100000000","amount":"2500","receivingChainID":"04000000","result":0}`),
+		},
+		// A failed transfer is not credited.
+		{
+			Module: ModuleToken,
+			Name:   EventCcmTransfer,
+			Data: json.RawMessage(`{"senderAddress":"` + sender + `","recipientAddress":"` + testLisk32Address +
+				`","tokenID":"0400000100000000","amount":"10","receivingChainID":"04000000","result":1}`),
+		},
+		{
+			Module: ModuleInteroperability,
+			Name:   EventCcmProcessed,
+			Data: json.RawMessage(`{"ccm":{"module":"token","crossChainCommand":"transferCrossChain",` +
+				`"sendingChainID":"04000001","receivingChainID":"04000000","params":"` +
+				hex.EncodeToString(params) + `"},"result":0}`),
+		},
+	}
+
+	transfers, err := GetCcmTransfers(events)
+	require.Nil(t, err)
+	require.Equal(t, []*Transfer{
+		{
+			CrossChain:       true,
+			TokenID:          "0400000100000000",
+			Amount:           "2500",
+			Recipient:        testLisk32Address,
+			Data:             "memo",
+			ReceivingChainID: "04000000",
+			Sender:           sender,
+			SendingChainID:   "04000001",
+		},
+	}, transfers)
+}
+
+func TestTokenId(t *testing.T) {
+	require.True(t, IsLSKToken("0400000000000000"))
+	require.True(t, IsLSKToken("0000000000000000"))
+	require.False(t, IsLSKToken("0400000100000000"))
+	require.False(t, IsLSKToken("04000000"))
+
+	chainId, err := GetTokenChainId("0400000100000000")
+	require.Nil(t, err)
+	require.Equal(t, "04000001", chainId)
+}
@@ -6,8 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/golang/groupcache/lru"
 	"github.com/sisu-network/deyes/chains"
 	lisktypes "github.com/sisu-network/deyes/chains/lisk/types"
 	chainstypes "github.com/sisu-network/deyes/chains/types"
@@ -17,25 +17,33 @@ import (
 	"github.com/sisu-network/lib/log"
 )
 
-const (
-	TxTrackCacheSize = 1_000
-)
-
 type Watcher struct {
-	cfg          config.Chain
-	client       Client
-	blockTime    int
-	db           database.Database
-	vault        string
-	txTrackCache *lru.Cache
-	lock         *sync.RWMutex
-	txsCh        chan *types.Txs
-	txTrackCh    chan *chainstypes.TrackUpdate
-	doneCh       chan bool
+	cfg       config.Chain
+	client    Client
+	blockTime int
+	db        database.Database
+	vault     string
+	lock      *sync.RWMutex
+	txsCh     chan *types.Txs
+	txTrackCh chan *chainstypes.TrackUpdate
+	doneCh    chan bool
 
 	// Block fetcher
 	blockCh      chan *lisktypes.Block
 	blockFetcher BlockFetcher
+
+	// Blocks are published to Sisu when they are final. pendingBlocks and finalizedHeight are only
+	// accessed by the goroutine that processes blocks.
+	pendingBlocks   []*pendingBlock
+	finalizedHeight uint64
+
+	// Sent transactions that are not in a block yet and the time they are tracked.
+	trackedTxs  map[string]time.Time
+	trackLock   *sync.Mutex
+	trackDoneCh chan bool
+	// Number of consecutive checks that did not find a tracked transaction. It is only accessed by
+	// the goroutine that checks tracked transactions.
+	notFoundTxs map[string]int
 }
 
 func NewWatcher(db database.Database, cfg config.Chain, txsCh chan *types.Txs,
@@ -51,9 +59,12 @@ func NewWatcher(db database.Database, cfg config.Chain, txsCh chan *types.Txs,
 		blockTime:    cfg.BlockTime,
 		client:       client,
 		lock:         &sync.RWMutex{},
-		txTrackCache: lru.New(TxTrackCacheSize),
 		txTrackCh:    txTrackCh,
 		doneCh:       make(chan bool),
+		trackedTxs:   make(map[string]time.Time),
+		trackLock:    &sync.Mutex{},
+		trackDoneCh:  make(chan bool),
+		notFoundTxs:  make(map[string]int),
 	}
 
 	return w
@@ -95,11 +106,13 @@ func (w *Watcher) Start() {
 func (w *Watcher) Stop() {
 	w.blockFetcher.stop()
 	w.doneCh <- true
+	w.trackDoneCh <- true
 }
 
 func (w *Watcher) scanBlocks() {
 	go w.blockFetcher.start()
 	go w.waitForBlock()
+	go w.loopCheckTrackedTxs()
 }
 
 // waitForBlock waits for new blocks from the block fetcher. It then filters interested txs and
@@ -133,24 +146,44 @@ func (w *Watcher) GetNonce(address string) (int64, error) {
 }
 
 func (w *Watcher) processBlock(block *lisktypes.Block) {
+	txArr, trackUpdates := w.extractTxs(block)
+	w.addPendingBlock(block, txArr, trackUpdates)
+}
+
+// extractTxs returns the deposits to the vault and the updates of the tracked transactions in a
+// block.
+func (w *Watcher) extractTxs(block *lisktypes.Block) ([]*types.Tx, []*chainstypes.TrackUpdate) {
 	txArr := make([]*types.Tx, 0)
+	trackUpdates := make([]*chainstypes.TrackUpdate, 0)
 
 	for _, tx := range block.Transactions {
-		if _, ok := w.txTrackCache.Get(tx.Id); ok {
+		if w.untrackTx(tx.Id) {
 			log.Verbose("Confirming lisk tx with hash = ", tx.Id)
 
 			result := chainstypes.TrackResultConfirmed
-			// This is a transaction that we are tracking. Inform Sisu about this.
-			w.txTrackCh <- &chainstypes.TrackUpdate{
+			if w.cfg.LiskVersion == LiskVersionV4 && tx.ExecutionStatus == lisktypes.ExecutionStatusFailed {
+				result = chainstypes.TrackResultFailure
+			}
+
+			// This is a transaction that we are tracking. Inform Sisu about this when the block is
+			// final.
+			trackUpdates = append(trackUpdates, &chainstypes.TrackUpdate{
 				Chain:       w.cfg.Chain,
 				Hash:        tx.Id,
 				BlockHeight: int64(block.Height),
 				Result:      result,
-			}
+			})
 
 			continue
 		}
 
+		if w.cfg.LiskVersion == LiskVersionV4 {
+			if txFormatted := w.processTxV4(tx); txFormatted != nil {
+				txArr = append(txArr, txFormatted)
+			}
+			continue
+		}
+
 		if tx.Sender != nil && tx.Asset != nil && tx.Asset.Recipient != nil &&
 			strings.EqualFold(tx.Asset.Recipient.Address, w.vault) {
 			if err := tx.Validate(); err != nil {
@@ -175,24 +208,92 @@ func (w *Watcher) processBlock(block *lisktypes.Block) {
 				Serialized: bz,
 				From:       tx.Sender.Address,
 				To:         tx.Asset.Recipient.Address,
-				Success:    tx.IsPending == false,
+				// Transactions in a block of Lisk v3 are always executed successfully.
+				Success: true,
 			}
 			txArr = append(txArr, &txFormatted)
 		}
 	}
 
-	if len(txArr) > 0 {
-		txs := types.Txs{
-			Chain:     w.cfg.Chain,
-			Block:     int64(block.Height),
-			BlockHash: block.Id,
-			Arr:       txArr,
+	return txArr, trackUpdates
+}
+
+// processTxV4 returns the transaction if it transfers tokens to the vault: a token:transfer
+// command or a cross chain update whose CCMs credit the vault. A token:transferCrossChain command
+// sends the tokens to another chain so it is not a deposit even if the recipient is the vault.
+func (w *Watcher) processTxV4(tx *lisktypes.Transaction) *types.Tx {
+	var transfers []*lisktypes.Transfer
+	if tx.Transfer != nil && !tx.Transfer.CrossChain && strings.EqualFold(tx.Transfer.Recipient, w.vault) {
+		transfers = append(transfers, tx.Transfer)
+	}
+
+	ccmTransfers := make([]*lisktypes.Transfer, 0)
+	for _, transfer := range tx.CcmTransfers {
+		if strings.EqualFold(transfer.Recipient, w.vault) {
+			ccmTransfers = append(ccmTransfers, transfer)
+		}
+	}
+	transfers = append(transfers, ccmTransfers...)
+
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	// Only the CCMs that credit the vault are sent to Sisu.
+	deposit := *tx
+	deposit.CcmTransfers = ccmTransfers
+	if err := deposit.Validate(); err != nil {
+		log.Errorf("Failed to validate transaction, err = %v", err)
+		return nil
+	}
+
+	from := tx.Sender.Address
+	for _, transfer := range transfers {
+		if transfer.CrossChain {
+			from = transfer.Sender
 		}
-		w.txsCh <- &txs
+
+		log.Infof("There is a transfer of amount %s (token %s) from address %s to %s with message %s, cross chain = %v",
+			transfer.Amount,
+			transfer.TokenID,
+			from,
+			transfer.Recipient,
+			transfer.Data,
+			transfer.CrossChain,
+		)
+	}
+
+	bz, err := json.Marshal(&deposit)
+	if err != nil {
+		log.Errorf("Failed to marshal transaction, err = %v", err)
+		return nil
+	}
+
+	return &types.Tx{
+		Hash:       tx.Id,
+		Serialized: bz,
+		From:       from,
+		To:         transfers[0].Recipient,
+		Success:    tx.ExecutionStatus == lisktypes.ExecutionStatusSuccessful,
 	}
 }
 
 func (w *Watcher) TrackTx(txHash string) {
 	log.Verbose("Tracking tx: ", txHash)
-	w.txTrackCache.Add(txHash, true)
+
+	w.trackLock.Lock()
+	defer w.trackLock.Unlock()
+
+	w.trackedTxs[txHash] = time.Now()
+}
+
+// untrackTx removes a tracked transaction and returns true if it was tracked.
+func (w *Watcher) untrackTx(txHash string) bool {
+	w.trackLock.Lock()
+	defer w.trackLock.Unlock()
+
+	_, ok := w.trackedTxs[txHash]
+	delete(w.trackedTxs, txHash)
+
+	return ok
 }
@@ -32,6 +32,7 @@ func TestWatcher_TestScanBlocks(t *testing.T) {
 				Id:                   "mock_block_id",
 				Height:               1,
 				NumberOfTransactions: 1,
+				IsFinal:              true,
 			}
 
 			return &block, nil
@@ -83,3 +84,104 @@ func TestWatcher_TestScanBlocks(t *testing.T) {
 	// Stop the watcher to clean up all running go routine.
 	watcher.Stop()
 }
+
+func TestWatcher_ScanBlocksV4(t *testing.T) {
+	vaultAddress := "lskcjqwdg9ezqtnpyd3f866nu4pdspsrft3rx5y8d"
+	client := &MockLiskClient{
+		BlockNumberFunc: func() (uint64, error) {
+			return 1, nil
+		},
+		BlockByHeightFunc: func(height uint64) (*ltypes.Block, error) {
+			return &ltypes.Block{
+				Id:                   "mock_block_id",
+				Height:               1,
+				NumberOfTransactions: 2,
+				IsFinal:              true,
+			}, nil
+		},
+		TransactionByBlockFunc: func(block string) ([]*ltypes.Transaction, error) {
+			// An outgoing cross chain transfer sends the tokens to another chain.
+			outgoing := &ltypes.Transaction{
+				Id:              "mock_outgoing_id",
+				ModuleCommand:   ltypes.ModuleCommandTransferCross,
+				Sender:          &ltypes.Sender{Address: "sender"},
+				ExecutionStatus: ltypes.ExecutionStatusSuccessful,
+				Transfer: &ltypes.Transfer{
+					CrossChain:       true,
+					TokenID:          "0400000100000000",
+					Amount:           "1000",
+					Recipient:        vaultAddress,
+					ReceivingChainID: "04000002",
+				},
+			}
+			// A cross chain update with a CCM that credits the vault.
+			ccu := &ltypes.Transaction{
+				Id:              "mock_ccu_id",
+				ModuleCommand:   ltypes.ModuleCommandSubmitSidechainCCU,
+				Sender:          &ltypes.Sender{Address: "relayer"},
+				ExecutionStatus: ltypes.ExecutionStatusSuccessful,
+				CcmTransfers: []*ltypes.Transfer{
+					{
+						CrossChain:       true,
+						TokenID:          "0400000100000000",
+						Amount:           "1000",
+						Recipient:        vaultAddress,
+						Sender:           "sender",
+						ReceivingChainID: "04000000",
+						SendingChainID:   "04000001",
+					},
+					{
+						CrossChain: true,
+						TokenID:    "0400000100000000",
+						Amount:     "1000",
+						Recipient:  "other",
+						Sender:     "sender",
+					},
+				},
+			}
+			// A transfer to another address is ignored.
+			other := &ltypes.Transaction{
+				Id:              "mock_other_id",
+				ModuleCommand:   ltypes.ModuleCommandTransfer,
+				Sender:          &ltypes.Sender{Address: "sender"},
+				ExecutionStatus: ltypes.ExecutionStatusSuccessful,
+				Transfer: &ltypes.Transfer{
+					TokenID:   "0400000000000000",
+					Amount:    "1000",
+					Recipient: "other",
+				},
+			}
+
+			return []*ltypes.Transaction{outgoing, ccu, other}, nil
+		},
+	}
+
+	db := getTestDb()
+	cfg := config.Chain{
+		Chain:       "lisk-testnet",
+		BlockTime:   1000,
+		AdjustTime:  100,
+		Rpcs:        []string{"https://example.com"},
+		LiskVersion: LiskVersionV4,
+	}
+	txsCh := make(chan *types.Txs)
+
+	watcher := NewWatcher(db, cfg, txsCh, nil, client).(*Watcher)
+	watcher.SetVault(vaultAddress, "")
+	watcher.Start()
+
+	txs := <-txsCh
+	require.Equal(t, 1, len(txs.Arr))
+	require.Equal(t, "mock_ccu_id", txs.Arr[0].Hash)
+	require.Equal(t, "sender", txs.Arr[0].From)
+	require.Equal(t, vaultAddress, txs.Arr[0].To)
+	require.True(t, txs.Arr[0].Success)
+
+	tx := ltypes.Transaction{}
+	err := json.Unmarshal(txs.Arr[0].Serialized, &tx)
+	require.Nil(t, err)
+	require.Len(t, tx.CcmTransfers, 1)
+	require.Equal(t, "04000001", tx.CcmTransfers[0].SendingChainID)
+
+	watcher.Stop()
+}
@@ -0,0 +1,154 @@
+package solana
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/mr-tron/base58"
+	"github.com/near/borsh-go"
+	solanatypes "github.com/sisu-network/deyes/chains/solana/types"
+	"github.com/sisu-network/lib/log"
+
+	solanago "github.com/gagliardetto/solana-go"
+)
+
+var (
+	MemoProgramIds = map[string]bool{
+		"MemoSq4gqABAXKb96qnH8TssFjxBr2uZ9j9MK1N5nTy": true,
+		"Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo": true,
+	}
+
+	errInvalidInstruction = fmt.Errorf("invalid bridge instruction")
+)
+
+type tokenBalanceChange struct {
+	account  string
+	mint     string
+	owner    string
+	program  string
+	decimals int
+	delta    *big.Int
+}
+
+// getTokenBalanceChanges returns the change of each token account in the transaction from the pre
+// and post token balances in the transaction meta.
+func getTokenBalanceChanges(outerTx *solanatypes.Transaction) []*tokenBalanceChange {
+	accounts := outerTx.AccountKeys()
+	changes := make([]*tokenBalanceChange, 0)
+	pre := make(map[int]*big.Int)
+
+	for _, balance := range outerTx.Meta.PreTokenBalances {
+		amount, ok := new(big.Int).SetString(balance.UiTokenAmount.Amount, 10)
+		if ok {
+			pre[balance.AccountIndex] = amount
+		}
+	}
+
+	for _, balance := range outerTx.Meta.PostTokenBalances {
+		if balance.AccountIndex >= len(accounts) {
+			continue
+		}
+
+		post, ok := new(big.Int).SetString(balance.UiTokenAmount.Amount, 10)
+		if !ok {
+			continue
+		}
+
+		delta := new(big.Int).Set(post)
+		if amount, ok := pre[balance.AccountIndex]; ok {
+			delta.Sub(delta, amount)
+		}
+
+		changes = append(changes, &tokenBalanceChange{
+			account:  accounts[balance.AccountIndex],
+			mint:     balance.Mint,
+			owner:    balance.Owner,
+			program:  balance.ProgramId,
+			decimals: balance.UiTokenAmount.Decimals,
+			delta:    delta,
+		})
+	}
+
+	return changes
+}
+
+// getMemo returns the text of the first memo instruction in the transaction.
+func getMemo(outerTx *solanatypes.Transaction) string {
+	accounts := outerTx.AccountKeys()
+	for _, ix := range outerTx.TransactionInner.Message.Instructions {
+		if ix.ProgramIdIndex >= len(accounts) || !MemoProgramIds[accounts[ix.ProgramIdIndex]] {
+			continue
+		}
+
+		bz, err := base58.Decode(ix.Data)
+		if err != nil {
+			log.Warnf("Failed to decode memo data, err = %v", err)
+			continue
+		}
+
+		return string(bz)
+	}
+
+	return ""
+}
+
+// decodeTransferOut decodes the data of a bridge program instruction. The first byte is the
+// instruction discriminator and the rest is the borsh encoded TransferOutData.
+func decodeTransferOut(data string) (*solanatypes.BridgeTransferOut, error) {
+	bz, err := base58.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bz) < 2 {
+		return nil, errInvalidInstruction
+	}
+
+	transferData := new(solanatypes.TransferOutData)
+	if err := borsh.Deserialize(transferData, bz[1:]); err != nil {
+		return nil, err
+	}
+
+	if len(transferData.TokenAddress) == 0 || len(transferData.Recipient) == 0 {
+		return nil, errInvalidInstruction
+	}
+
+	return &solanatypes.BridgeTransferOut{
+		Amount:       transferData.Amount.String(),
+		TokenAddress: transferData.TokenAddress,
+		ChainId:      transferData.ChainId,
+		Recipient:    transferData.Recipient,
+	}, nil
+}
+
+// findAssociatedTokenAddress returns the associated token account of a wallet for a mint. The
+// token program can be either the SPL token program or Token-2022.
+func findAssociatedTokenAddress(wallet, mint, tokenProgram string) (string, error) {
+	walletPk, err := solanago.PublicKeyFromBase58(wallet)
+	if err != nil {
+		return "", err
+	}
+
+	mintPk, err := solanago.PublicKeyFromBase58(mint)
+	if err != nil {
+		return "", err
+	}
+
+	programPk := solanago.TokenProgramID
+	if len(tokenProgram) > 0 {
+		programPk, err = solanago.PublicKeyFromBase58(tokenProgram)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	addr, _, err := solanago.FindProgramAddress(
+		[][]byte{walletPk[:], programPk[:], mintPk[:]},
+		solanago.SPLAssociatedTokenAccountProgramID,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return addr.String(), nil
+}
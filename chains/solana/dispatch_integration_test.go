@@ -11,6 +11,7 @@ import (
 	solanago "github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/programs/system"
 	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/types"
 )
 
@@ -62,7 +63,10 @@ func TestDispatch(t *testing.T) {
 		panic(err)
 	}
 
-	dispatcher := NewDispatcher([]string{rpc.LocalNet_RPC}, []string{rpc.LocalNet_WS})
+	dispatcher := NewDispatcher(config.Chain{
+		Chain: "solana-devnet",
+		Rpcs:  []string{rpc.LocalNet_RPC},
+	}, nil)
 	dispatcher.Dispatch(&types.DispatchedTxRequest{
 		Chain: "solana-devnet",
 		Tx:    bz,
@@ -3,32 +3,65 @@ package solana
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	bin "github.com/gagliardetto/binary"
 	solanago "github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/text"
 	"github.com/ybbus/jsonrpc/v3"
 
+	solanatypes "github.com/sisu-network/deyes/chains/solana/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/types"
 	"github.com/sisu-network/lib/log"
 )
 
+var (
+	// RebroadcastInterval is the time between two broadcasts of a transaction that has not been
+	// confirmed. Leaders can drop transactions when they are congested so we keep sending the
+	// transaction until it is confirmed or its blockhash expires.
+	RebroadcastInterval = time.Second * 2
+	// MaxRebroadcastTime is the safety limit for rebroadcasting when the last valid block height is
+	// not known. A blockhash is valid for 150 blocks (about 1 minute).
+	MaxRebroadcastTime = time.Minute * 3
+)
+
+const (
+	// RPC error code when preflight simulation fails.
+	ErrCodeSimulationFailed = -32002
+)
+
 type Dispatcher struct {
+	chain      string
+	commitment string
 	clientUrls []string
 	clientRpcs []jsonrpc.RPCClient
+	txTrackCh  chan *chainstypes.TrackUpdate
+
+	rebroadcastInterval time.Duration
+	maxRebroadcastTime  time.Duration
 }
 
-func NewDispatcher(clientUrls, wsUrls []string) *Dispatcher {
+func NewDispatcher(cfg config.Chain, txTrackCh chan *chainstypes.TrackUpdate) *Dispatcher {
 	clientRpcs := make([]jsonrpc.RPCClient, 0)
 
-	for _, url := range clientUrls {
+	for _, url := range cfg.Rpcs {
 		clientRpcs = append(clientRpcs, jsonrpc.NewClient(url))
 	}
 	return &Dispatcher{
-		clientUrls: clientUrls,
+		chain:      cfg.Chain,
+		commitment: getCommitment(cfg),
+		clientUrls: cfg.Rpcs,
 		clientRpcs: clientRpcs,
+		txTrackCh:  txTrackCh,
+
+		rebroadcastInterval: RebroadcastInterval,
+		maxRebroadcastTime:  MaxRebroadcastTime,
 	}
 }
 
@@ -36,23 +69,249 @@ func (d *Dispatcher) Start() {
 }
 
 func (d *Dispatcher) Dispatch(request *types.DispatchedTxRequest) *types.DispatchedTxResult {
+	tx, err := solanago.TransactionFromDecoder(bin.NewBinDecoder(request.Tx))
+	if err != nil || len(tx.Signatures) == 0 {
+		log.Errorf("Failed to decode solana tx, err = %v", err)
+		return types.NewDispatchTxError(request, types.ErrMarshal)
+	}
+
+	txHash := request.TxHash
+	if len(txHash) == 0 {
+		txHash = tx.Signatures[0].String()
+	}
+
+	// Do not send transactions whose blockhash has expired. They will be rejected by the network.
+	expired, err := d.isExpired(tx.Message.RecentBlockhash.String(), request.LastValidBlockHeight)
+	if err != nil {
+		log.Warnf("Failed to check blockhash validity of solana tx %s, err = %v", txHash, err)
+	} else if expired {
+		log.Errorf("Recent blockhash of solana tx %s has expired", txHash)
+		return types.NewDispatchTxError(request, types.ErrTxExpired)
+	}
+
+	dispatchErr := types.ErrSubmitTx
 	for i, client := range d.clientRpcs {
 		log.Verbosef("Dispatching solana tx using url %s", d.clientUrls[i])
-		signature, err := d.sendTransaction(request.Tx, client)
+		signature, err := d.sendTransaction(request.Tx, client, false)
+		if err == nil {
+			log.Verbose("Dispatching solana tx successfully signature = ", signature)
+			dispatchErr = types.ErrNil
+			break
+		}
+
+		rpcErr, ok := err.(*jsonrpc.RPCError)
+		if !ok {
+			log.Warnf("Failed to dispatch transaction with url %s, err = %v", d.clientUrls[i], err)
+			continue
+		}
+
+		// The node has rejected the transaction. Other nodes would do the same.
+		dispatchErr = d.getDispatchError(txHash, rpcErr)
+		break
+	}
+
+	if dispatchErr != types.ErrNil {
+		return types.NewDispatchTxError(request, dispatchErr)
+	}
+
+	go d.rebroadcast(request, txHash, tx.Message.RecentBlockhash.String())
+
+	return &types.DispatchedTxResult{
+		Success: true,
+		Chain:   request.Chain,
+		TxHash:  txHash,
+	}
+}
+
+// getDispatchError converts a sendTransaction error to a DispatchError and logs the simulation logs
+// if preflight fails.
+func (d *Dispatcher) getDispatchError(txHash string, rpcErr *jsonrpc.RPCError) types.DispatchError {
+	simErr := &solanatypes.SimulationErrData{}
+	if rpcErr.Data != nil {
+		bz, err := json.Marshal(rpcErr.Data)
+		if err == nil {
+			json.Unmarshal(bz, simErr)
+		}
+	}
+
+	if rpcErr.Code == ErrCodeSimulationFailed {
+		log.Errorf("Preflight simulation of solana tx %s failed, message = %s, err = %v, logs = \n%s",
+			txHash, rpcErr.Message, simErr.Err, strings.Join(simErr.Logs, "\n"))
+	} else {
+		log.Errorf("Solana tx %s is rejected, code = %d, message = %s", txHash, rpcErr.Code, rpcErr.Message)
+	}
+
+	msg := strings.ToLower(fmt.Sprintf("%s %v", rpcErr.Message, simErr.Err))
+	switch {
+	case strings.Contains(msg, "alreadyprocessed") || strings.Contains(msg, "already been processed"):
+		// The same transaction has been submitted by another node.
+		return types.ErrNil
+	case strings.Contains(msg, "blockhashnotfound") || strings.Contains(msg, "blockhash not found"):
+		return types.ErrTxExpired
+	case strings.Contains(msg, "insufficientfunds") || strings.Contains(msg, "insufficient funds") ||
+		strings.Contains(msg, "insufficient lamports"):
+		return types.ErrNotEnoughBalance
+	default:
+		return types.ErrSubmitTx
+	}
+}
+
+// rebroadcast sends the transaction periodically until it is confirmed or its blockhash expires.
+// When the transaction expires, a timeout update is sent so that Sisu can create a new transaction.
+// A timeout is only reported when the expiry is confirmed and the transaction is not found on
+// chain, since Sisu would otherwise pay out twice.
+func (d *Dispatcher) rebroadcast(request *types.DispatchedTxRequest, txHash string, blockhash string) {
+	start := time.Now()
+	for time.Since(start) < d.maxRebroadcastTime {
+		time.Sleep(d.rebroadcastInterval)
+
+		status, err := d.getSignatureStatus(txHash, false)
+		if err != nil {
+			log.Warnf("Failed to get status of solana tx %s, err = %v", txHash, err)
+			continue
+		}
+
+		if status != nil {
+			// The transaction has been included in a block. The watcher reports the final result.
+			log.Verbosef("Solana tx %s is included at slot %d, status = %s", txHash, status.Slot,
+				status.ConfirmationStatus)
+			return
+		}
+
+		expired, err := d.isExpired(blockhash, request.LastValidBlockHeight)
 		if err != nil {
-			log.Warnf("Failed to dispatch transaction with url ", d.clientUrls[i], " err = ", err)
+			log.Warnf("Failed to check blockhash validity of solana tx %s, err = %v", txHash, err)
 			continue
 		}
 
-		log.Verbose("Dispatching solana tx successfully signature = ", signature)
-		return &types.DispatchedTxResult{
-			Success: true,
-			Chain:   request.Chain,
-			TxHash:  request.TxHash,
+		if expired {
+			// The transaction could have been included after the last status check. Search the
+			// transaction history before reporting the timeout.
+			status, err := d.getSignatureStatus(txHash, true)
+			if err != nil {
+				log.Warnf("Failed to get status of expired solana tx %s, err = %v", txHash, err)
+				continue
+			}
+
+			if status != nil {
+				log.Verbosef("Solana tx %s is included at slot %d before it expired", txHash, status.Slot)
+				return
+			}
+
+			log.Warnf("Solana tx %s expired before being confirmed", txHash)
+			d.sendTimeout(request, txHash)
+			return
+		}
+
+		log.Verbosef("Rebroadcasting solana tx %s", txHash)
+		for _, client := range shuffleClients(d.clientRpcs) {
+			if _, err := d.sendTransaction(request.Tx, client, true); err == nil {
+				break
+			}
+		}
+	}
+
+	// The expiry of the transaction could not be checked so its outcome is unknown. No timeout is
+	// reported; the watcher reports the result if the transaction is included in a block.
+	log.Errorf("Stop rebroadcasting solana tx %s after %v, its outcome is unknown", txHash, d.maxRebroadcastTime)
+}
+
+func (d *Dispatcher) sendTimeout(request *types.DispatchedTxRequest, txHash string) {
+	d.txTrackCh <- &chainstypes.TrackUpdate{
+		Chain:  d.chain,
+		Bytes:  request.Tx,
+		Hash:   txHash,
+		Result: chainstypes.TrackResultTimeout,
+	}
+}
+
+// isExpired checks if a blockhash can no longer be used. If the last valid block height is known,
+// it is compared with the current block height. Otherwise, we ask the node if the blockhash is
+// still valid.
+func (d *Dispatcher) isExpired(blockhash string, lastValidBlockHeight int64) (bool, error) {
+	if lastValidBlockHeight > 0 {
+		height, err := d.getBlockHeight()
+		if err != nil {
+			return false, err
 		}
+
+		return int64(height) > lastValidBlockHeight, nil
+	}
+
+	valid, err := d.isBlockhashValid(blockhash)
+	if err != nil {
+		return false, err
 	}
 
-	return types.NewDispatchTxError(request, types.ErrSubmitTx)
+	return !valid, nil
+}
+
+func (d *Dispatcher) getBlockHeight() (uint64, error) {
+	return executeWithClients(d.clientRpcs, func(client jsonrpc.RPCClient) (uint64, bool, error) {
+		params := []interface{}{&solanatypes.CommitmentConfig{Commitment: d.commitment}}
+		res, err := client.Call(context.Background(), "getBlockHeight", params)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if res.Error != nil {
+			return 0, true, res.Error
+		}
+
+		var height uint64
+		err = res.GetObject(&height)
+
+		return height, true, err
+	})
+}
+
+func (d *Dispatcher) isBlockhashValid(blockhash string) (bool, error) {
+	return executeWithClients(d.clientRpcs, func(client jsonrpc.RPCClient) (bool, bool, error) {
+		params := []interface{}{blockhash, &solanatypes.CommitmentConfig{Commitment: d.commitment}}
+		res, err := client.Call(context.Background(), "isBlockhashValid", params)
+		if err != nil {
+			return false, false, err
+		}
+
+		if res.Error != nil {
+			return false, true, res.Error
+		}
+
+		result := &solanatypes.IsBlockhashValidResult{}
+		err = res.GetObject(result)
+
+		return result.Value, true, err
+	})
+}
+
+// getSignatureStatus returns the status of a transaction or nil if it is not found. Only recent
+// transactions are searched unless searchHistory is true.
+func (d *Dispatcher) getSignatureStatus(signature string, searchHistory bool) (*solanatypes.SignatureStatus, error) {
+	return executeWithClients(d.clientRpcs, func(client jsonrpc.RPCClient) (*solanatypes.SignatureStatus, bool, error) {
+		params := []interface{}{[]string{signature}}
+		if searchHistory {
+			params = append(params, &solanatypes.SignatureStatusesConfig{SearchTransactionHistory: true})
+		}
+		res, err := client.Call(context.Background(), "getSignatureStatuses", params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.Error != nil {
+			return nil, true, res.Error
+		}
+
+		result := &solanatypes.SignatureStatusesResult{}
+		if err := res.GetObject(result); err != nil {
+			return nil, true, err
+		}
+
+		if len(result.Value) == 0 {
+			return nil, true, nil
+		}
+
+		return result.Value[0], true, nil
+	})
 }
 
 // analyzeTx is a function for debugging transaction.
@@ -68,17 +327,20 @@ func (d *Dispatcher) analyzeTx(bxBytes []byte) {
 	decodedTx.EncodeTree(text.NewTreeEncoder(os.Stdout, text.Bold("TEST TRANSACTION")))
 }
 
-func (d *Dispatcher) sendTransaction(tx []byte, client jsonrpc.RPCClient) (solanago.Signature, error) {
+func (d *Dispatcher) sendTransaction(tx []byte, client jsonrpc.RPCClient, skipPreflight bool) (solanago.Signature, error) {
 	encodedTx := base64.StdEncoding.EncodeToString(tx)
-	opts := rpc.TransactionOpts{
-		SkipPreflight:       false,
-		PreflightCommitment: "",
+	// We rebroadcast the transaction ourselves.
+	maxRetries := uint(0)
+	opts := &solanatypes.SendTransactionOpts{
+		Encoding:            "base64",
+		SkipPreflight:       skipPreflight,
+		PreflightCommitment: d.commitment,
+		MaxRetries:          &maxRetries,
 	}
 
-	obj := opts.ToMap()
 	params := []interface{}{
 		encodedTx,
-		obj,
+		opts,
 	}
 
 	response, err := client.Call(context.Background(), "sendTransaction", params...)
@@ -0,0 +1,253 @@
+package solana
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc/v3"
+	"go.uber.org/atomic"
+)
+
+type rpcHandler func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError)
+
+func newMockRpcServer(t *testing.T, handler rpcHandler) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		request := &struct {
+			Id     int               `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(request))
+
+		result, rpcErr := handler(request.Method, request.Params)
+		response := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      request.Id,
+		}
+		if rpcErr != nil {
+			response["error"] = rpcErr
+		} else {
+			response["result"] = result
+		}
+
+		json.NewEncoder(rw).Encode(response)
+	}))
+}
+
+func newTestTx(t *testing.T) []byte {
+	owner, err := solanago.NewRandomPrivateKey()
+	require.Nil(t, err)
+
+	tx, err := solanago.NewTransaction(
+		[]solanago.Instruction{
+			system.NewTransferInstruction(1_000, owner.PublicKey(), solanago.MustPublicKeyFromBase58(testVault)).Build(),
+		},
+		solanago.MustHashFromBase58("EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N"),
+		solanago.TransactionPayer(owner.PublicKey()),
+	)
+	require.Nil(t, err)
+
+	_, err = tx.Sign(func(key solanago.PublicKey) *solanago.PrivateKey {
+		return &owner
+	})
+	require.Nil(t, err)
+
+	bz, err := tx.MarshalBinary()
+	require.Nil(t, err)
+
+	return bz
+}
+
+func newTestDispatcher(url string, txTrackCh chan *chainstypes.TrackUpdate) *Dispatcher {
+	return NewDispatcher(config.Chain{
+		Chain: "solana-devnet",
+		Rpcs:  []string{url},
+	}, txTrackCh)
+}
+
+func TestDispatcher_PreflightFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		err     interface{}
+		success bool
+		result  types.DispatchError
+	}{
+		{name: "expired", message: "Transaction simulation failed: Blockhash not found", err: "BlockhashNotFound", result: types.ErrTxExpired},
+		{name: "balance", message: "Transaction simulation failed: Attempt to debit an account but found no record of a prior credit.", err: "InsufficientFundsForFee", result: types.ErrNotEnoughBalance},
+		{name: "processed", message: "Transaction simulation failed: This transaction has already been processed", err: "AlreadyProcessed", success: true, result: types.ErrNil},
+		{name: "program", message: "Transaction simulation failed: Error processing Instruction 0: custom program error: 0x1", err: map[string]interface{}{"InstructionError": []interface{}{0, map[string]interface{}{"Custom": 1}}}, result: types.ErrSubmitTx},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+				switch method {
+				case "getBlockHeight":
+					return 100, nil
+				case "sendTransaction":
+					return nil, &jsonrpc.RPCError{
+						Code:    ErrCodeSimulationFailed,
+						Message: tc.message,
+						Data: map[string]interface{}{
+							"err":  tc.err,
+							"logs": []string{"Program 11111111111111111111111111111111 invoke [1]"},
+						},
+					}
+				case "getSignatureStatuses":
+					return map[string]interface{}{"value": []interface{}{map[string]interface{}{"slot": 99}}}, nil
+				}
+				return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+			})
+			defer server.Close()
+
+			dispatcher := newTestDispatcher(server.URL, nil)
+			result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+				Chain:                "solana-devnet",
+				Tx:                   newTestTx(t),
+				TxHash:               "sig",
+				LastValidBlockHeight: 150,
+			})
+
+			require.Equal(t, tc.success, result.Success)
+			require.Equal(t, tc.result, result.Err)
+		})
+	}
+}
+
+func TestDispatcher_ExpiredBeforeSending(t *testing.T) {
+	sent := false
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "isBlockhashValid":
+			return map[string]interface{}{"value": false}, nil
+		case "sendTransaction":
+			sent = true
+		}
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	dispatcher := newTestDispatcher(server.URL, nil)
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+		Chain: "solana-devnet",
+		Tx:    newTestTx(t),
+	})
+
+	require.False(t, result.Success)
+	require.Equal(t, types.ErrTxExpired, result.Err)
+	require.False(t, sent)
+}
+
+func TestDispatcher_RebroadcastUntilExpired(t *testing.T) {
+	sendCount := atomic.Int32{}
+	height := 100
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "getBlockHeight":
+			height += 10
+			return height, nil
+		case "sendTransaction":
+			sendCount.Inc()
+			return "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW", nil
+		case "getSignatureStatuses":
+			// The transaction is dropped by the leader.
+			return map[string]interface{}{"value": []interface{}{nil}}, nil
+		}
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	txTrackCh := make(chan *chainstypes.TrackUpdate, 1)
+	dispatcher := newTestDispatcher(server.URL, txTrackCh)
+	dispatcher.rebroadcastInterval = time.Millisecond * 10
+	result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+		Chain:                "solana-devnet",
+		Tx:                   newTestTx(t),
+		TxHash:               "sig",
+		LastValidBlockHeight: 150,
+	})
+	require.True(t, result.Success)
+
+	update := <-txTrackCh
+	require.Equal(t, "sig", update.Hash)
+	require.Equal(t, chainstypes.TrackResultTimeout, update.Result)
+	// The first broadcast and 4 rebroadcasts at height 120, 130, 140 and 150.
+	require.Equal(t, int32(5), sendCount.Load())
+}
+
+func TestDispatcher_IncludedAtExpiry(t *testing.T) {
+	searched := atomic.Bool{}
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "getBlockHeight":
+			return 200, nil
+		case "sendTransaction":
+			return "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW", nil
+		case "getSignatureStatuses":
+			if len(params) < 2 {
+				// The transaction is not in the recent status cache of the node.
+				return map[string]interface{}{"value": []interface{}{nil}}, nil
+			}
+
+			// The transaction landed right before its blockhash expired.
+			searched.Store(true)
+			return map[string]interface{}{"value": []interface{}{
+				map[string]interface{}{"slot": 140, "confirmationStatus": "finalized"},
+			}}, nil
+		}
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	txTrackCh := make(chan *chainstypes.TrackUpdate, 1)
+	dispatcher := newTestDispatcher(server.URL, txTrackCh)
+	dispatcher.rebroadcastInterval = time.Millisecond * 10
+	dispatcher.rebroadcast(&types.DispatchedTxRequest{
+		Chain:                "solana-devnet",
+		Tx:                   newTestTx(t),
+		TxHash:               "sig",
+		LastValidBlockHeight: 150,
+	}, "sig", "")
+
+	// No timeout is reported for a transaction that is on chain.
+	require.True(t, searched.Load())
+	require.Equal(t, 0, len(txTrackCh))
+}
+
+func TestDispatcher_RebroadcastUnknownOutcome(t *testing.T) {
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "sendTransaction":
+			return "5VERv8NMvzbJMEkV8xnrLkEaWRtSz9CosKDYjCJjBRnbJLgp8uirBgmQpjKhoR4tjF3ZpRzrFmBV6UjKdiSZkQUW", nil
+		case "getSignatureStatuses":
+			return map[string]interface{}{"value": []interface{}{nil}}, nil
+		}
+		// The expiry of the transaction cannot be checked.
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	txTrackCh := make(chan *chainstypes.TrackUpdate, 1)
+	dispatcher := newTestDispatcher(server.URL, txTrackCh)
+	dispatcher.rebroadcastInterval = time.Millisecond * 10
+	dispatcher.maxRebroadcastTime = time.Millisecond * 50
+	dispatcher.rebroadcast(&types.DispatchedTxRequest{
+		Chain:                "solana-devnet",
+		Tx:                   newTestTx(t),
+		TxHash:               "sig",
+		LastValidBlockHeight: 150,
+	}, "sig", "")
+
+	// The outcome is unknown when the rebroadcast stops, so no timeout is reported.
+	require.Equal(t, 0, len(txTrackCh))
+}
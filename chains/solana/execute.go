@@ -6,15 +6,12 @@ import (
 	"github.com/ybbus/jsonrpc/v3"
 )
 
-// shuffleClients shuffles and returns random permutation of a list of clients.
+// shuffleClients returns a random permutation of a list of clients. The list is shared by many
+// goroutines so it is not modified.
 func shuffleClients(c []jsonrpc.RPCClient) []jsonrpc.RPCClient {
-	clients := c
-	for i := 0; i < len(clients)*2; i++ {
-		x := rand.Intn(len(clients))
-		y := rand.Intn(len(clients))
-		temp := clients[x]
-		clients[x] = clients[y]
-		clients[y] = temp
+	clients := make([]jsonrpc.RPCClient, len(c))
+	for i, j := range rand.Perm(len(c)) {
+		clients[i] = c[j]
 	}
 
 	return clients
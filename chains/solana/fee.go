@@ -0,0 +1,176 @@
+package solana
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	solanatypes "github.com/sisu-network/deyes/chains/solana/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/ybbus/jsonrpc/v3"
+)
+
+const (
+	// MaxPrioritizationFeeAccounts is the maximum number of accounts accepted by
+	// getRecentPrioritizationFees.
+	MaxPrioritizationFeeAccounts = 128
+	// ComputeUnitMarginPercent is added on top of the simulated compute units.
+	ComputeUnitMarginPercent = 10
+	// MaxComputeUnitLimit is the maximum compute units that a transaction can request.
+	MaxComputeUnitLimit = 1_400_000
+)
+
+// GetPriorityFee returns the percentiles of recent prioritization fees paid by transactions that
+// write to the given accounts. If no account is given, the writable accounts of the bridge program
+// are used so that the fees reflect the contention on the bridge's accounts.
+func (w *Watcher) GetPriorityFee(accounts []string) (*types.SolanaPriorityFeeResult, error) {
+	if len(accounts) == 0 {
+		accounts = w.cfg.SolanaBridgeWritableAccounts
+	}
+	if len(accounts) == 0 {
+		// Without accounts, the node returns the fees of the whole network.
+		return nil, fmt.Errorf("no account given and no writable account of the bridge program is configured")
+	}
+
+	if len(accounts) > MaxPrioritizationFeeAccounts {
+		return nil, fmt.Errorf("too many accounts, max = %d", MaxPrioritizationFeeAccounts)
+	}
+
+	fees, err := executeWithClients(w.clients, func(client jsonrpc.RPCClient) ([]*solanatypes.PrioritizationFee, bool, error) {
+		params := []interface{}{accounts}
+		res, err := client.Call(context.Background(), "getRecentPrioritizationFees", params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.Error != nil {
+			return nil, true, res.Error
+		}
+
+		fees := make([]*solanatypes.PrioritizationFee, 0)
+		err = res.GetObject(&fees)
+
+		return fees, true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]uint64, len(fees))
+	for i, fee := range fees {
+		values[i] = fee.PrioritizationFee
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	return &types.SolanaPriorityFeeResult{
+		Min:      percentile(values, 0),
+		Low:      percentile(values, 25),
+		Medium:   percentile(values, 50),
+		High:     percentile(values, 75),
+		VeryHigh: percentile(values, 95),
+		Max:      percentile(values, 100),
+	}, nil
+}
+
+// percentile returns the p-th percentile of a sorted list using the nearest rank method.
+func percentile(sorted []uint64, p int) uint64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// EstimateComputeUnits simulates an unsigned transaction message (base64 encoded) and returns the
+// compute units that it consumes.
+func (w *Watcher) EstimateComputeUnits(message string) (*types.SolanaComputeUnitsResult, error) {
+	msg, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := unsignedTx(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := executeWithClients(w.clients, func(client jsonrpc.RPCClient) (*solanatypes.SimulateTransactionResult, bool, error) {
+		opts := &solanatypes.SimulateTransactionOpts{
+			Encoding:               "base64",
+			SigVerify:              false,
+			ReplaceRecentBlockhash: true,
+			Commitment:             w.commitment,
+		}
+		params := []interface{}{base64.StdEncoding.EncodeToString(tx), opts}
+		res, err := client.Call(context.Background(), "simulateTransaction", params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.Error != nil {
+			return nil, true, res.Error
+		}
+
+		result := &solanatypes.SimulateTransactionResult{}
+		err = res.GetObject(result)
+
+		return result, true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Value.Err != nil {
+		return nil, fmt.Errorf("simulation failed, err = %v, logs = \n%s", result.Value.Err,
+			strings.Join(result.Value.Logs, "\n"))
+	}
+
+	limit := result.Value.UnitsConsumed * (100 + ComputeUnitMarginPercent) / 100
+	if limit > MaxComputeUnitLimit {
+		limit = MaxComputeUnitLimit
+	}
+
+	return &types.SolanaComputeUnitsResult{
+		UnitsConsumed:    result.Value.UnitsConsumed,
+		ComputeUnitLimit: limit,
+		Logs:             result.Value.Logs,
+	}, nil
+}
+
+// unsignedTx wraps a serialized message into a transaction with empty signatures.
+func unsignedTx(msg []byte) ([]byte, error) {
+	if len(msg) == 0 {
+		return nil, fmt.Errorf("empty message")
+	}
+
+	// Versioned messages have the highest bit of the first byte set and the header follows the
+	// version byte.
+	headerIndex := 0
+	if msg[0]&0x80 != 0 {
+		headerIndex = 1
+	}
+
+	if len(msg) <= headerIndex {
+		return nil, fmt.Errorf("invalid message")
+	}
+
+	numSigs := int(msg[headerIndex])
+	if numSigs >= 0x80 {
+		return nil, fmt.Errorf("invalid number of signatures %d", numSigs)
+	}
+
+	// The number of signatures is encoded as a compact-u16 which is a single byte for values < 128.
+	tx := make([]byte, 0, 1+numSigs*64+len(msg))
+	tx = append(tx, byte(numSigs))
+	tx = append(tx, make([]byte, numSigs*64)...)
+	tx = append(tx, msg...)
+
+	return tx, nil
+}
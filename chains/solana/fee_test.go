@@ -0,0 +1,95 @@
+package solana
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/sisu-network/deyes/config"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc/v3"
+)
+
+const testBridgeState = "4Nd1mBQtrMJVYVfKf2PJy9NZUZdTAsp7D4xWLs4gDB4T"
+
+func TestWatcher_GetPriorityFee(t *testing.T) {
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		require.Equal(t, "getRecentPrioritizationFees", method)
+
+		accounts := make([]string, 0)
+		require.Nil(t, json.Unmarshal(params[0], &accounts))
+		require.Equal(t, []string{testBridgeState, testVault}, accounts)
+
+		fees := make([]map[string]interface{}, 0)
+		for i := 0; i < 20; i++ {
+			fees = append(fees, map[string]interface{}{"slot": 1000 + i, "prioritizationFee": (19 - i) * 100})
+		}
+		return fees, nil
+	})
+	defer server.Close()
+
+	w := NewWatcher(config.Chain{Chain: "solana-devnet", Rpcs: []string{server.URL}}, getTestDb(), nil, nil)
+
+	// The vault is not used when no writable account of the bridge is configured.
+	w.SetVault(testVault, "")
+	_, err := w.GetPriorityFee(nil)
+	require.NotNil(t, err)
+
+	w.cfg.SolanaBridgeWritableAccounts = []string{testBridgeState, testVault}
+	result, err := w.GetPriorityFee(nil)
+	require.Nil(t, err)
+	require.Equal(t, uint64(0), result.Min)
+	require.Equal(t, uint64(400), result.Low)
+	require.Equal(t, uint64(900), result.Medium)
+	require.Equal(t, uint64(1400), result.High)
+	require.Equal(t, uint64(1800), result.VeryHigh)
+	require.Equal(t, uint64(1900), result.Max)
+}
+
+func TestWatcher_EstimateComputeUnits(t *testing.T) {
+	payer := solanago.MustPublicKeyFromBase58(testSender)
+	tx, err := solanago.NewTransaction(
+		[]solanago.Instruction{
+			system.NewTransferInstruction(1_000, payer, solanago.MustPublicKeyFromBase58(testVault)).Build(),
+		},
+		solanago.Hash{},
+		solanago.TransactionPayer(payer),
+	)
+	require.Nil(t, err)
+	msg, err := tx.Message.MarshalBinary()
+	require.Nil(t, err)
+
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		require.Equal(t, "simulateTransaction", method)
+
+		var encoded string
+		require.Nil(t, json.Unmarshal(params[0], &encoded))
+		bz, err := base64.StdEncoding.DecodeString(encoded)
+		require.Nil(t, err)
+
+		// The transaction has one empty signature followed by the message.
+		decoded, err := solanago.TransactionFromDecoder(bin.NewBinDecoder(bz))
+		require.Nil(t, err)
+		require.Equal(t, 1, len(decoded.Signatures))
+		require.Equal(t, solanago.Signature{}, decoded.Signatures[0])
+		require.Equal(t, tx.Message.AccountKeys, decoded.Message.AccountKeys)
+
+		return map[string]interface{}{
+			"value": map[string]interface{}{
+				"err":           nil,
+				"logs":          []string{"Program 11111111111111111111111111111111 success"},
+				"unitsConsumed": 150,
+			},
+		}, nil
+	})
+	defer server.Close()
+
+	w := NewWatcher(config.Chain{Chain: "solana-devnet", Rpcs: []string{server.URL}}, getTestDb(), nil, nil)
+	result, err := w.EstimateComputeUnits(base64.StdEncoding.EncodeToString(msg))
+	require.Nil(t, err)
+	require.Equal(t, uint64(150), result.UnitsConsumed)
+	require.Equal(t, uint64(165), result.ComputeUnitLimit)
+}
@@ -8,6 +8,33 @@ import (
 	solanatypes "github.com/sisu-network/deyes/chains/solana/types"
 	"github.com/sisu-network/lib/log"
 	"github.com/ybbus/jsonrpc/v3"
+	"go.uber.org/atomic"
+)
+
+var (
+	MinFetchers = 2
+	MaxFetchers = 20
+	// SlotsPerFetcher is the slot lag handled by one fetcher. The pool grows when we fall behind.
+	SlotsPerFetcher = 10
+	// MaxSlotRange is the maximum number of slots fetched in one round.
+	MaxSlotRange = uint64(100)
+	// PollInterval is the time we wait for new slots when we have caught up with the chain.
+	PollInterval = time.Millisecond * 400
+	// StallTimeout is the time after which a slot that has not been fetched is assigned to another
+	// fetcher.
+	StallTimeout = time.Second * 10
+	// RetryDelay is the delay before retrying a slot that failed.
+	RetryDelay     = time.Millisecond * 500
+	MaxFetchErrors = 10
+	// MaxNotAvailableDelay is the max delay before retrying a block that is not available yet. The
+	// delay doubles after each attempt.
+	MaxNotAvailableDelay = time.Second * 5
+)
+
+const (
+	// RPC error code when a block is not available yet, e.g. on a node that lags behind the node
+	// that answered getBlocks.
+	ErrCodeBlockNotAvailable = -32004
 )
 
 type BlockResult struct {
@@ -16,84 +43,304 @@ type BlockResult struct {
 	Block *solanatypes.Block
 }
 
-type fetcher struct {
-	n            uint64
-	startingSlot uint64
-	clients      []jsonrpc.RPCClient
-	blockCh      chan *BlockResult
+type fetchResult struct {
+	slot         uint64
+	block        *solanatypes.Block
+	skip         bool
+	notAvailable bool
+	err          error
+}
+
+// fetcherPool fetches blocks with a pool of workers and delivers them to blockCh in slot order. It
+// uses getBlocks to find the slots that have blocks so that skipped slots are not requested.
+type fetcherPool struct {
+	clients    []jsonrpc.RPCClient
+	commitment string
+	blockCh    chan *BlockResult
+	done       atomic.Bool
+
+	pollInterval         time.Duration
+	stallTimeout         time.Duration
+	retryDelay           time.Duration
+	maxNotAvailableDelay time.Duration
 }
 
-func newFetcher(clients []jsonrpc.RPCClient, n, startingSlot uint64, blockCh chan *BlockResult) *fetcher {
-	return &fetcher{
-		n:            n,
-		startingSlot: startingSlot,
-		blockCh:      blockCh,
-		clients:      clients,
+func newFetcherPool(clients []jsonrpc.RPCClient, commitment string, blockCh chan *BlockResult) *fetcherPool {
+	return &fetcherPool{
+		clients:              clients,
+		commitment:           commitment,
+		blockCh:              blockCh,
+		pollInterval:         PollInterval,
+		stallTimeout:         StallTimeout,
+		retryDelay:           RetryDelay,
+		maxNotAvailableDelay: MaxNotAvailableDelay,
 	}
 }
 
-func (f *fetcher) start() {
-	slot := f.startingSlot
+func (p *fetcherPool) start(startingSlot uint64) {
+	next := startingSlot
+	for !p.done.Load() {
+		tip, err := p.getSlot()
+		if err != nil {
+			log.Warn("Failed to get solana slot, err = ", err)
+			time.Sleep(p.retryDelay)
+			continue
+		}
 
-	errCount := 0
-	minSleepTime := time.Millisecond * 1200
-	sleepTime := minSleepTime
+		if tip < next {
+			time.Sleep(p.pollInterval)
+			continue
+		}
 
-	for {
-		time.Sleep(sleepTime)
-		block, err := f.getBlockNumber(slot)
+		end := tip
+		if end-next+1 > MaxSlotRange {
+			end = next + MaxSlotRange - 1
+		}
 
+		slots, err := p.getBlocks(next, end)
 		if err != nil {
-			rpcErr, ok := err.(*jsonrpc.RPCError)
-			if ok {
-				// -32007: Slot 171913340 was skipped, or missing due to ledger jump to recent snapshot
-				// -32015: Transaction version (0) is not supported by the requesting client. Please try the request again with the following configuration parameter: \"maxSupportedTransactionVersion\": 0"
-				if rpcErr.Code == -32007 || rpcErr.Code == -32015 {
-					f.blockCh <- &BlockResult{Skip: true, Slot: slot}
-					// Slot is skipped, try the next one.
-					errCount = 0
-					slot += f.n
+			log.Warnf("Failed to get solana blocks from slot %d to %d, err = %v", next, end, err)
+			time.Sleep(p.retryDelay)
+			continue
+		}
+
+		workers := getWorkerCount(tip - next)
+		log.Verbosef("Fetching %d solana blocks from slot %d to %d with %d workers, tip = %d",
+			len(slots), next, end, workers, tip)
+		p.fetchSlots(slots, workers)
+
+		next = end + 1
+	}
+}
+
+func (p *fetcherPool) stop() {
+	p.done.Store(true)
+}
+
+// getWorkerCount returns the number of workers needed for a slot lag.
+func getWorkerCount(lag uint64) int {
+	workers := int(lag/uint64(SlotsPerFetcher)) + 1
+	if workers < MinFetchers {
+		workers = MinFetchers
+	}
+	if workers > MaxFetchers {
+		workers = MaxFetchers
+	}
+
+	return workers
+}
+
+// fetchSlots fetches the blocks at the given slots in parallel and sends them to blockCh in order.
+// A slot that is not fetched after stallTimeout since a worker picked it up is queued again so that
+// another worker can pick it up; the first result for each slot is used.
+func (p *fetcherPool) fetchSlots(slots []uint64, workers int) {
+	if len(slots) == 0 {
+		return
+	}
+
+	doneCh := make(chan bool)
+	defer close(doneCh)
+
+	jobs := make(chan uint64, len(slots))
+	startedCh := make(chan uint64)
+	resultCh := make(chan *fetchResult, len(slots))
+
+	for i := 0; i < workers; i++ {
+		go p.work(jobs, startedCh, resultCh, doneCh)
+	}
+
+	queue := func(slot uint64, delay time.Duration) {
+		go func() {
+			time.Sleep(delay)
+			select {
+			case jobs <- slot:
+			case <-doneCh:
+			}
+		}()
+	}
+
+	// inflight contains the time a worker started fetching a slot. Slots waiting in the queue are
+	// not in inflight so that they are not reassigned when the workers are busy.
+	inflight := make(map[uint64]time.Time)
+	errCount := make(map[uint64]int)
+	notAvailableCount := make(map[uint64]int)
+	results := make(map[uint64]*BlockResult)
+	fetched := make(map[uint64]bool)
+	for _, slot := range slots {
+		jobs <- slot
+	}
+
+	ticker := time.NewTicker(p.stallTimeout / 2)
+	defer ticker.Stop()
+
+	index := 0
+	for index < len(slots) {
+		select {
+		case slot := <-startedCh:
+			if !fetched[slot] {
+				inflight[slot] = time.Now()
+			}
+
+		case r := <-resultCh:
+			if fetched[r.slot] {
+				// Another worker has fetched this slot.
+				continue
+			}
+
+			delete(inflight, r.slot)
+			if r.notAvailable {
+				// The block exists since getBlocks returned its slot. It is retried until the node
+				// has it, without counting toward the max error count.
+				delay := p.getNotAvailableDelay(notAvailableCount[r.slot])
+				notAvailableCount[r.slot]++
+				log.Verbosef("Solana block at slot %d is not available yet, retrying in %v", r.slot, delay)
+				queue(r.slot, delay)
+				continue
+			}
+
+			if r.err != nil {
+				errCount[r.slot]++
+				if errCount[r.slot] < MaxFetchErrors {
+					log.Warnf("Failed to fetch solana block at slot %d, err = %v", r.slot, r.err)
+					queue(r.slot, p.retryDelay)
+					continue
 				}
-				if rpcErr.Code == -32004 { // Block not found yet
-					sleepTime = sleepTime + time.Millisecond*500
+
+				// We reach the maximum error count for unknown reason. Skip this block.
+				log.Error("Max retry reached. Skip this slot ", r.slot)
+				r.skip = true
+			}
+
+			fetched[r.slot] = true
+			results[r.slot] = &BlockResult{Skip: r.skip, Slot: r.slot, Block: r.block}
+
+			// Deliver all the blocks that are ready in order.
+			for index < len(slots) {
+				result, ok := results[slots[index]]
+				if !ok {
+					break
 				}
-			} else {
-				log.Warn("Solana fetcher error: err = ", err)
-				errCount++
-				if errCount == 10 {
-					// We reach the maximum error count for unknown reason. Skip this blog
-					log.Error("Max retry reached. Skip this slot ", slot)
-					f.blockCh <- &BlockResult{Skip: true, Slot: slot}
-					errCount = 0
-					slot += f.n
+
+				p.blockCh <- result
+				delete(results, slots[index])
+				index++
+			}
+
+		case <-ticker.C:
+			for slot, startTime := range inflight {
+				if time.Since(startTime) > p.stallTimeout {
+					log.Warnf("Fetching solana block at slot %d is stalled, reassigning", slot)
+					delete(inflight, slot)
+					queue(slot, 0)
 				}
 			}
-		} else {
-			sleepTime = sleepTime - time.Millisecond*100
-			if block != nil {
-				if block.Transactions == nil {
-					log.Error("Err is not nil but transactions list is nil. block = ", block)
-					f.blockCh <- &BlockResult{Skip: true, Slot: slot}
+		}
+	}
+}
+
+// getNotAvailableDelay returns the delay before the next attempt to fetch a block that is not
+// available yet.
+func (p *fetcherPool) getNotAvailableDelay(attempts int) time.Duration {
+	delay := p.retryDelay
+	for i := 0; i < attempts && delay < p.maxNotAvailableDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxNotAvailableDelay {
+		delay = p.maxNotAvailableDelay
+	}
+
+	return delay
+}
+
+func (p *fetcherPool) work(jobs chan uint64, startedCh chan uint64, resultCh chan *fetchResult, doneCh chan bool) {
+	for {
+		select {
+		case <-doneCh:
+			return
+
+		case slot := <-jobs:
+			select {
+			case startedCh <- slot:
+			case <-doneCh:
+				return
+			}
+
+			result := &fetchResult{slot: slot}
+			block, err := p.getBlock(slot)
+			if err != nil {
+				rpcErr, ok := err.(*jsonrpc.RPCError)
+				// -32007: Slot 171913340 was skipped, or missing due to ledger jump to recent snapshot
+				// -32009: Slot was skipped, or missing in long-term storage
+				// -32015: Transaction version (0) is not supported by the requesting client.
+				if ok && (rpcErr.Code == -32007 || rpcErr.Code == -32009 || rpcErr.Code == -32015) {
+					result.skip = true
+				} else if ok && rpcErr.Code == ErrCodeBlockNotAvailable {
+					result.notAvailable = true
 				} else {
-					f.blockCh <- &BlockResult{Skip: false, Slot: slot, Block: block}
+					result.err = err
 				}
+			} else if block.Transactions == nil {
+				log.Error("Err is nil but transactions list is nil. slot = ", slot)
+				result.skip = true
+			} else {
+				block.Slot = slot
+				result.block = block
 			}
 
-			slot += f.n
-			errCount = 0
+			select {
+			case resultCh <- result:
+			case <-doneCh:
+				return
+			}
 		}
+	}
+}
 
-		if sleepTime < minSleepTime {
-			sleepTime = minSleepTime
+func (p *fetcherPool) getSlot() (uint64, error) {
+	return executeWithClients(p.clients, func(client jsonrpc.RPCClient) (uint64, bool, error) {
+		params := []interface{}{&solanatypes.CommitmentConfig{Commitment: p.commitment}}
+		res, err := client.Call(context.Background(), "getSlot", params)
+		if err != nil {
+			return 0, false, err
 		}
-	}
+
+		if res.Error != nil {
+			return 0, true, res.Error
+		}
+
+		var slot uint64
+		err = res.GetObject(&slot)
+
+		return slot, true, err
+	})
+}
+
+// getBlocks returns the slots that have blocks between start and end (inclusive).
+func (p *fetcherPool) getBlocks(start, end uint64) ([]uint64, error) {
+	return executeWithClients(p.clients, func(client jsonrpc.RPCClient) ([]uint64, bool, error) {
+		params := []interface{}{start, end, &solanatypes.CommitmentConfig{Commitment: p.commitment}}
+		res, err := client.Call(context.Background(), "getBlocks", params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.Error != nil {
+			return nil, true, res.Error
+		}
+
+		slots := make([]uint64, 0)
+		err = res.GetObject(&slots)
+
+		return slots, true, err
+	})
 }
 
-func (f *fetcher) getBlockNumber(slot uint64) (*solanatypes.Block, error) {
-	return executeWithClients(f.clients, func(client jsonrpc.RPCClient) (*solanatypes.Block, bool, error) {
+func (p *fetcherPool) getBlock(slot uint64) (*solanatypes.Block, error) {
+	return executeWithClients(p.clients, func(client jsonrpc.RPCClient) (*solanatypes.Block, bool, error) {
 		var request = &solanatypes.GetBlockRequest{
 			TransactionDetails:             "full",
 			MaxSupportedTransactionVersion: 100,
+			Commitment:                     p.commitment,
 		}
 
 		res, err := client.Call(context.Background(), "getBlock", slot, request)
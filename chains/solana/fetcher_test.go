@@ -0,0 +1,159 @@
+package solana
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc/v3"
+	"go.uber.org/atomic"
+)
+
+func TestFetcherPool_Ordered(t *testing.T) {
+	stalled := atomic.Bool{}
+	getBlockCount := atomic.Int32{}
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "getSlot":
+			return 110, nil
+		case "getBlocks":
+			// Slot 102 and 104 are skipped.
+			return []uint64{100, 101, 103, 105}, nil
+		case "getBlock":
+			getBlockCount.Inc()
+
+			var slot uint64
+			require.Nil(t, json.Unmarshal(params[0], &slot))
+			require.NotEqual(t, uint64(102), slot)
+			require.NotEqual(t, uint64(104), slot)
+
+			if slot == 101 && !stalled.Load() {
+				// The first request for slot 101 hangs.
+				stalled.Store(true)
+				time.Sleep(time.Millisecond * 300)
+			}
+
+			return map[string]interface{}{
+				"blockhash":    "hash",
+				"parentSlot":   slot - 1,
+				"transactions": []interface{}{},
+			}, nil
+		}
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	blockCh := make(chan *BlockResult, 10)
+	pool := newFetcherPool([]jsonrpc.RPCClient{jsonrpc.NewClient(server.URL)}, "confirmed", blockCh)
+	pool.stallTimeout = time.Millisecond * 50
+	pool.fetchSlots([]uint64{100, 101, 103, 105}, 2)
+
+	require.Equal(t, 4, len(blockCh))
+	for _, slot := range []uint64{100, 101, 103, 105} {
+		result := <-blockCh
+		require.False(t, result.Skip)
+		require.Equal(t, slot, result.Slot)
+		require.Equal(t, slot, result.Block.Slot)
+	}
+
+	// Slot 101 is fetched twice since the first request is stalled.
+	require.Equal(t, int32(5), getBlockCount.Load())
+}
+
+func TestFetcherPool_QueuedSlotsNotReassigned(t *testing.T) {
+	getBlockCount := atomic.Int32{}
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		getBlockCount.Inc()
+		time.Sleep(time.Millisecond * 30)
+
+		return map[string]interface{}{"blockhash": "hash", "transactions": []interface{}{}}, nil
+	})
+	defer server.Close()
+
+	blockCh := make(chan *BlockResult, 10)
+	pool := newFetcherPool([]jsonrpc.RPCClient{jsonrpc.NewClient(server.URL)}, "confirmed", blockCh)
+	pool.stallTimeout = time.Millisecond * 50
+	// One worker takes longer than the stall timeout to fetch all the slots.
+	pool.fetchSlots([]uint64{100, 101, 102, 103, 104, 105}, 1)
+
+	require.Equal(t, 6, len(blockCh))
+	// Slots waiting in the queue are not reassigned.
+	require.Equal(t, int32(6), getBlockCount.Load())
+}
+
+func TestFetcherPool_WorkerCount(t *testing.T) {
+	require.Equal(t, MinFetchers, getWorkerCount(0))
+	require.Equal(t, 5, getWorkerCount(45))
+	require.Equal(t, MaxFetchers, getWorkerCount(100_000))
+}
+
+func TestFetcherPool_Start(t *testing.T) {
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "getSlot":
+			return 205, nil
+		case "getBlocks":
+			var start, end uint64
+			require.Nil(t, json.Unmarshal(params[0], &start))
+			require.Nil(t, json.Unmarshal(params[1], &end))
+
+			slots := make([]uint64, 0)
+			for slot := start; slot <= end; slot++ {
+				if slot%2 == 0 {
+					slots = append(slots, slot)
+				}
+			}
+			return slots, nil
+		case "getBlock":
+			return map[string]interface{}{"blockhash": "hash", "transactions": []interface{}{}}, nil
+		}
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	blockCh := make(chan *BlockResult)
+	pool := newFetcherPool([]jsonrpc.RPCClient{jsonrpc.NewClient(server.URL)}, "confirmed", blockCh)
+	go pool.start(0)
+	defer pool.stop()
+
+	// Slots are delivered in order across batches.
+	for slot := uint64(0); slot <= 204; slot += 2 {
+		result := <-blockCh
+		require.Equal(t, slot, result.Slot)
+	}
+}
+
+func TestFetcherPool_BlockNotAvailable(t *testing.T) {
+	getBlockCount := atomic.Int32{}
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		// The node does not have the block for more attempts than the max error count.
+		if getBlockCount.Inc() <= int32(MaxFetchErrors*2) {
+			return nil, &jsonrpc.RPCError{Code: ErrCodeBlockNotAvailable, Message: "Block not available for slot 100"}
+		}
+
+		return map[string]interface{}{
+			"blockhash":    "hash",
+			"parentSlot":   99,
+			"transactions": []interface{}{},
+		}, nil
+	})
+	defer server.Close()
+
+	blockCh := make(chan *BlockResult, 1)
+	pool := newFetcherPool([]jsonrpc.RPCClient{jsonrpc.NewClient(server.URL)}, "confirmed", blockCh)
+	pool.retryDelay = time.Millisecond
+	pool.maxNotAvailableDelay = time.Millisecond * 4
+	pool.fetchSlots([]uint64{100}, 1)
+
+	result := <-blockCh
+	require.False(t, result.Skip)
+	require.Equal(t, uint64(100), result.Block.Slot)
+}
+
+func TestGetNotAvailableDelay(t *testing.T) {
+	pool := newFetcherPool(nil, "confirmed", nil)
+	require.Equal(t, RetryDelay, pool.getNotAvailableDelay(0))
+	require.Equal(t, RetryDelay*4, pool.getNotAvailableDelay(2))
+	require.Equal(t, MaxNotAvailableDelay, pool.getNotAvailableDelay(100))
+}
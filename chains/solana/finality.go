@@ -0,0 +1,230 @@
+package solana
+
+import (
+	"context"
+	"time"
+
+	solanatypes "github.com/sisu-network/deyes/chains/solana/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+	"github.com/ybbus/jsonrpc/v3"
+)
+
+var (
+	FinalityCheckInterval = time.Second * 2
+)
+
+// pendingBlock contains the relevant transactions of a block that has not been published to Sisu.
+type pendingBlock struct {
+	slot         uint64
+	blockHash    string
+	txArr        []*types.Tx
+	trackUpdates []*chainstypes.TrackUpdate
+}
+
+// waitForFinality periodically publishes pending blocks that have been finalized.
+func (w *Watcher) waitForFinality() {
+	for {
+		time.Sleep(FinalityCheckInterval)
+
+		finalizedSlot, err := w.getSlotWithCommitment(solanatypes.CommitmentFinalized)
+		if err != nil {
+			log.Warnf("Failed to get finalized slot for chain %s, err = %v", w.cfg.Chain, err)
+			continue
+		}
+
+		w.processFinalizedSlot(finalizedSlot)
+	}
+}
+
+// processFinalizedSlot publishes the blocks of the finalized fork up to the finalized slot. A pending
+// block is only published if its hash matches the finalized block at the same slot; otherwise it
+// was on a fork that has been dropped and the finalized block at its slot is processed instead.
+// Finalized blocks at slots that were skipped on the fork we fetched are processed too.
+func (w *Watcher) processFinalizedSlot(finalizedSlot uint64) {
+	w.pendingLock.Lock()
+	pendings := w.pendingBlocks
+	w.pendingLock.Unlock()
+
+	// Only the slots before the last fetched block are checked. The blocks after it are processed
+	// by the fetcher first.
+	n := 0
+	for n < len(pendings) && pendings[n].slot <= finalizedSlot {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	start := pendings[0].slot
+	if w.finalizedSlot > 0 && w.finalizedSlot < start {
+		start = w.finalizedSlot + 1
+	}
+	end := pendings[n-1].slot
+
+	slots, err := w.getFinalizedBlocks(start, end)
+	if err != nil {
+		log.Warnf("Failed to get finalized blocks from slot %d to %d, err = %v", start, end, err)
+		return
+	}
+
+	finalized := make(map[uint64]bool, len(slots))
+	for _, slot := range slots {
+		finalized[slot] = true
+	}
+
+	count := 0
+	for slot := start; slot <= end; slot++ {
+		var pending *pendingBlock
+		if count < n && pendings[count].slot == slot {
+			pending = pendings[count]
+		}
+
+		if !finalized[slot] {
+			if pending != nil {
+				log.Warnf("Block %s at slot %d is not finalized, the slot was skipped. Dropping %d txs",
+					pending.blockHash, slot, len(pending.txArr))
+				w.retrackTxs(pending.trackUpdates)
+				count++
+			}
+			continue
+		}
+
+		if !w.publishFinalized(slot, pending) {
+			break
+		}
+
+		w.finalizedSlot = slot
+		if pending != nil {
+			count++
+		}
+	}
+
+	w.pendingLock.Lock()
+	w.pendingBlocks = w.pendingBlocks[count:]
+	w.pendingLock.Unlock()
+}
+
+// publishFinalized publishes the finalized block at a slot. The pending block at the slot is
+// published if it is the finalized block; otherwise the finalized block is fetched and processed.
+// It returns false if the finalized block could not be fetched.
+func (w *Watcher) publishFinalized(slot uint64, pending *pendingBlock) bool {
+	if pending != nil {
+		hash, finalized, err := w.getFinalizedBlockHash(slot)
+		if err != nil {
+			log.Warnf("Failed to get finalized block at slot %d, err = %v", slot, err)
+			return false
+		}
+
+		if !finalized {
+			return false
+		}
+
+		if hash == pending.blockHash {
+			w.publish(pending)
+			return true
+		}
+
+		log.Warnf("Block %s at slot %d is not finalized, finalized block hash = %s. Dropping %d txs",
+			pending.blockHash, slot, hash, len(pending.txArr))
+		w.retrackTxs(pending.trackUpdates)
+	} else {
+		log.Warnf("Finalized block at slot %d was not fetched, processing it", slot)
+	}
+
+	block, err := w.getBlockWithCommitment(slot, solanatypes.CommitmentFinalized)
+	if err != nil {
+		log.Warnf("Failed to get finalized block at slot %d, err = %v", slot, err)
+		return false
+	}
+
+	txArr, trackUpdates := w.extractTxs(block)
+	w.publish(&pendingBlock{
+		slot:         slot,
+		blockHash:    block.BlockHash,
+		txArr:        txArr,
+		trackUpdates: trackUpdates,
+	})
+
+	return true
+}
+
+// retrackTxs tracks again the transactions of a dropped block so that they are confirmed when
+// they are included in another block.
+func (w *Watcher) retrackTxs(trackUpdates []*chainstypes.TrackUpdate) {
+	for _, update := range trackUpdates {
+		w.TrackTx(update.Hash)
+	}
+}
+
+// getFinalizedBlocks returns the slots that have finalized blocks between start and end
+// (inclusive).
+func (w *Watcher) getFinalizedBlocks(start, end uint64) ([]uint64, error) {
+	return executeWithClients(w.clients, func(client jsonrpc.RPCClient) ([]uint64, bool, error) {
+		params := []interface{}{start, end, &solanatypes.CommitmentConfig{Commitment: solanatypes.CommitmentFinalized}}
+		res, err := client.Call(context.Background(), "getBlocks", params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.Error != nil {
+			return nil, true, res.Error
+		}
+
+		slots := make([]uint64, 0)
+		err = res.GetObject(&slots)
+
+		return slots, true, err
+	})
+}
+
+// getFinalizedBlockHash returns the hash of the finalized block at a slot. An empty hash is returned
+// if the slot was skipped. finalized is false if the slot has not been finalized yet.
+func (w *Watcher) getFinalizedBlockHash(slot uint64) (string, bool, error) {
+	type blockHashResult struct {
+		hash      string
+		finalized bool
+	}
+
+	result, err := executeWithClients(w.clients, func(client jsonrpc.RPCClient) (*blockHashResult, bool, error) {
+		rewards := false
+		request := &solanatypes.GetBlockRequest{
+			TransactionDetails:             "none",
+			MaxSupportedTransactionVersion: 100,
+			Commitment:                     solanatypes.CommitmentFinalized,
+			Rewards:                        &rewards,
+		}
+
+		res, err := client.Call(context.Background(), "getBlock", slot, request)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if res.Error != nil {
+			switch res.Error.Code {
+			case -32007, -32009:
+				// The slot was skipped.
+				return &blockHashResult{finalized: true}, true, nil
+			case -32004:
+				// Block not available yet.
+				return &blockHashResult{finalized: false}, true, nil
+			}
+
+			return nil, true, res.Error
+		}
+
+		block := new(solanatypes.Block)
+		if err := res.GetObject(block); err != nil {
+			return nil, true, err
+		}
+
+		return &blockHashResult{hash: block.BlockHash, finalized: true}, true, nil
+	})
+
+	if err != nil {
+		return "", false, err
+	}
+
+	return result.hash, result.finalized, nil
+}
@@ -0,0 +1,28 @@
+package types
+
+// Deposit is an SPL token transfer to a token account owned by the vault.
+type Deposit struct {
+	Mint     string `json:"mint"`
+	Amount   string `json:"amount"` // raw amount in the smallest unit of the mint
+	Decimals int    `json:"decimals"`
+	Sender   string `json:"sender"`
+	// TokenAccount is the vault's token account that receives the token.
+	TokenAccount string `json:"token_account"`
+	Memo         string `json:"memo"`
+}
+
+// BridgeTransferOut is a decoded TransferOut instruction of the bridge program.
+type BridgeTransferOut struct {
+	Amount       string `json:"amount"`
+	TokenAddress string `json:"token_address"`
+	ChainId      uint64 `json:"chain_id"`
+	Recipient    string `json:"recipient"`
+}
+
+// SolanaTransaction is the data model of a Solana transaction that we send to Sisu.
+type SolanaTransaction struct {
+	Signature   string               `json:"signature"`
+	Slot        int64                `json:"slot"`
+	Deposits    []*Deposit           `json:"deposits"`
+	TransferOut []*BridgeTransferOut `json:"transfer_out"`
+}
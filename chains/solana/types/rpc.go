@@ -1,8 +1,19 @@
 package types
 
+const (
+	CommitmentConfirmed = "confirmed"
+	CommitmentFinalized = "finalized"
+)
+
 type GetBlockRequest struct {
 	TransactionDetails             string `json:"transactionDetails"`
 	MaxSupportedTransactionVersion int    `json:"maxSupportedTransactionVersion"`
+	Commitment                     string `json:"commitment,omitempty"`
+	Rewards                        *bool  `json:"rewards,omitempty"`
+}
+
+type CommitmentConfig struct {
+	Commitment string `json:"commitment,omitempty"`
 }
 
 type Instruction struct {
@@ -11,9 +22,32 @@ type Instruction struct {
 	Data           string `json:"data"`
 }
 
+type UiTokenAmount struct {
+	Amount   string `json:"amount"`
+	Decimals int    `json:"decimals"`
+}
+
+type TokenBalance struct {
+	AccountIndex  int           `json:"accountIndex"`
+	Mint          string        `json:"mint"`
+	Owner         string        `json:"owner"`
+	ProgramId     string        `json:"programId"`
+	UiTokenAmount UiTokenAmount `json:"uiTokenAmount"`
+}
+
+// LoadedAddresses are the accounts loaded from address lookup tables in versioned transactions.
+type LoadedAddresses struct {
+	Writable []string `json:"writable"`
+	Readonly []string `json:"readonly"`
+}
+
 type TransactionMeta struct {
-	Fee uint64      `json:"fee"`
-	Err interface{} `json:"err"`
+	Fee               uint64           `json:"fee"`
+	Err               interface{}      `json:"err"`
+	PreTokenBalances  []TokenBalance   `json:"preTokenBalances"`
+	PostTokenBalances []TokenBalance   `json:"postTokenBalances"`
+	LoadedAddresses   *LoadedAddresses `json:"loadedAddresses"`
+	LogMessages       []string         `json:"logMessages"`
 }
 
 type TransactionMessage struct {
@@ -31,9 +65,80 @@ type Transaction struct {
 	TransactionInner *TransactionInner `json:"transaction"`
 }
 
+// AccountKeys returns all the accounts used by the transaction in the order that instructions and
+// token balances refer to them: static keys, then writable and readonly loaded addresses.
+func (tx *Transaction) AccountKeys() []string {
+	if tx.TransactionInner == nil || tx.TransactionInner.Message == nil {
+		return nil
+	}
+
+	keys := append([]string{}, tx.TransactionInner.Message.AccountKeys...)
+	if tx.Meta != nil && tx.Meta.LoadedAddresses != nil {
+		keys = append(keys, tx.Meta.LoadedAddresses.Writable...)
+		keys = append(keys, tx.Meta.LoadedAddresses.Readonly...)
+	}
+
+	return keys
+}
+
 type Block struct {
 	BlockHeight  int            `json:"blockHeight"`
 	Transactions []*Transaction `json:"transactions"`
 	ParentSlot   int            `json:"parentSlot"`
 	BlockHash    string         `json:"blockhash"`
+
+	// Slot is not returned by getBlock and is set by the fetcher. It is not always ParentSlot + 1
+	// since the slots in between can be skipped.
+	Slot uint64 `json:"-"`
+}
+
+type SendTransactionOpts struct {
+	Encoding            string `json:"encoding"`
+	SkipPreflight       bool   `json:"skipPreflight"`
+	PreflightCommitment string `json:"preflightCommitment,omitempty"`
+	MaxRetries          *uint  `json:"maxRetries,omitempty"`
+}
+
+// SimulationErrData is the data of the RPC error returned when preflight simulation fails.
+type SimulationErrData struct {
+	Err  interface{} `json:"err"`
+	Logs []string    `json:"logs"`
+}
+
+type SignatureStatus struct {
+	Slot               uint64      `json:"slot"`
+	Err                interface{} `json:"err"`
+	ConfirmationStatus string      `json:"confirmationStatus"`
+}
+
+type SignatureStatusesConfig struct {
+	SearchTransactionHistory bool `json:"searchTransactionHistory"`
+}
+
+type SignatureStatusesResult struct {
+	Value []*SignatureStatus `json:"value"`
+}
+
+type IsBlockhashValidResult struct {
+	Value bool `json:"value"`
+}
+
+type PrioritizationFee struct {
+	Slot              uint64 `json:"slot"`
+	PrioritizationFee uint64 `json:"prioritizationFee"`
+}
+
+type SimulateTransactionOpts struct {
+	Encoding               string `json:"encoding"`
+	SigVerify              bool   `json:"sigVerify"`
+	ReplaceRecentBlockhash bool   `json:"replaceRecentBlockhash"`
+	Commitment             string `json:"commitment,omitempty"`
+}
+
+type SimulateTransactionResult struct {
+	Value struct {
+		Err           interface{} `json:"err"`
+		Logs          []string    `json:"logs"`
+		UnitsConsumed uint64      `json:"unitsConsumed"`
+	} `json:"value"`
 }
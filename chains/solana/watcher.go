@@ -3,6 +3,7 @@ package solana
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"encoding/json"
@@ -16,11 +17,10 @@ import (
 	"github.com/sisu-network/lib/log"
 	"go.uber.org/atomic"
 
+	solanago "github.com/gagliardetto/solana-go"
 	"github.com/ybbus/jsonrpc/v3"
 )
 
-const FetcherCount = 5
-
 type Watcher struct {
 	cfg          config.Chain
 	lastSlot     atomic.Uint64
@@ -29,8 +29,21 @@ type Watcher struct {
 	txTrackCache *lru.Cache
 	db           database.Database
 
+	// vault is the wallet that owns the token accounts receiving deposits.
+	vault     string
+	vaultAtas map[string]string // key: mint + token program
+	lock      *sync.RWMutex
+
 	txsCh     chan *types.Txs
 	txTrackCh chan *chainstypes.TrackUpdate
+
+	// Blocks fetched at "confirmed" commitment that have relevant transactions but are not finalized
+	// yet.
+	commitment    string
+	pendingBlocks []*pendingBlock
+	pendingLock   *sync.Mutex
+	// finalizedSlot is the last slot whose finalized block has been published.
+	finalizedSlot uint64
 }
 
 func NewWatcher(cfg config.Chain, db database.Database, txsCh chan *types.Txs,
@@ -48,11 +61,48 @@ func NewWatcher(cfg config.Chain, db database.Database, txsCh chan *types.Txs,
 		txTrackCh:    txTrackCh,
 		rpcUrls:      cfg.Rpcs,
 		clients:      clients,
+		vaultAtas:    make(map[string]string),
+		lock:         &sync.RWMutex{},
+		commitment:   getCommitment(cfg),
+		pendingLock:  &sync.Mutex{},
+	}
+}
+
+// getCommitment returns the commitment level of a chain. Unknown values default to "finalized".
+func getCommitment(cfg config.Chain) string {
+	switch cfg.SolanaCommitment {
+	case solanatypes.CommitmentConfirmed:
+		return solanatypes.CommitmentConfirmed
+	case "", solanatypes.CommitmentFinalized:
+		return solanatypes.CommitmentFinalized
+	default:
+		log.Warnf("Unknown solana commitment %s for chain %s, using %s", cfg.SolanaCommitment,
+			cfg.Chain, solanatypes.CommitmentFinalized)
+		return solanatypes.CommitmentFinalized
+	}
+}
+
+func (w *Watcher) init() {
+	vaults, err := w.db.GetVaults(w.cfg.Chain)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(vaults) > 0 {
+		w.vault = vaults[0]
+		log.Infof("Saved gateway in the db for chain %s is %s", w.cfg.Chain, w.vault)
+	} else {
+		log.Infof("Vault for chain %s is not set yet", w.cfg.Chain)
 	}
 }
 
 func (w *Watcher) Start() {
+	w.init()
 	go w.scanBlocks()
+
+	if w.commitment != solanatypes.CommitmentFinalized {
+		go w.waitForFinality()
+	}
 }
 
 func (w *Watcher) scanBlocks() {
@@ -67,28 +117,46 @@ func (w *Watcher) scanBlocks() {
 		time.Sleep(time.Second * 3)
 	}
 
-	n := uint64(FetcherCount)
-	blockChs := make([]chan *BlockResult, n)
-	for i := uint64(0); i < n; i++ {
-		index := (slot + i) % n
-		blockChs[index] = make(chan *BlockResult)
-		fetch := newFetcher(w.clients, uint64(n), slot+i, blockChs[index])
-		go fetch.start()
-	}
+	blockCh := make(chan *BlockResult)
+	pool := newFetcherPool(w.clients, w.commitment, blockCh)
+	go pool.start(slot)
 
-	for i := 0; ; i++ {
-		index := (slot + uint64(i)) % n
-		result := <-blockChs[index]
+	for result := range blockCh {
 		if result.Skip {
 			continue
 		}
 
+		w.lastSlot.Store(result.Slot)
 		w.processBlock(result.Block)
 	}
 }
 
 func (w *Watcher) processBlock(block *solanatypes.Block) {
+	txArr, trackUpdates := w.extractTxs(block)
+	pending := &pendingBlock{
+		slot:         block.Slot,
+		blockHash:    block.BlockHash,
+		txArr:        txArr,
+		trackUpdates: trackUpdates,
+	}
+
+	if w.commitment == solanatypes.CommitmentFinalized {
+		w.publish(pending)
+		return
+	}
+
+	// Hold the block until it is finalized. Blocks without transactions are held too so that the
+	// finalized block is processed if they are dropped.
+	w.pendingLock.Lock()
+	w.pendingBlocks = append(w.pendingBlocks, pending)
+	w.pendingLock.Unlock()
+}
+
+// extractTxs returns the transactions of a block that are relevant to us and the updates of the
+// transactions that we are tracking.
+func (w *Watcher) extractTxs(block *solanatypes.Block) ([]*types.Tx, []*chainstypes.TrackUpdate) {
 	txArr := make([]*types.Tx, 0)
+	trackUpdates := make([]*chainstypes.TrackUpdate, 0)
 
 	// Process all transaction in the block
 	for _, outerTx := range block.Transactions {
@@ -116,36 +184,60 @@ func (w *Watcher) processBlock(block *solanatypes.Block) {
 			}
 
 			// This is a transaction that we are tracking. Inform Sisu about this.
-			w.txTrackCh <- &chainstypes.TrackUpdate{
+			trackUpdates = append(trackUpdates, &chainstypes.TrackUpdate{
 				Chain:       w.cfg.Chain,
 				Bytes:       bz,
 				Hash:        txId,
-				BlockHeight: int64(block.ParentSlot + 1),
+				BlockHeight: int64(block.Slot),
 				Result:      result,
-			}
+			})
+
+			continue
+		}
 
+		// Check to see if this is a transaction sent to our bridge or one of our token accounts.
+		solanaTx := w.extractDeposits(outerTx, int64(block.Slot))
+		if solanaTx == nil {
 			continue
 		}
 
-		// Check to see if this is a transaction sent to one of our token accounts.
-		if w.acceptTx(outerTx) {
-			log.Verbose("Found a transaction sent to our bridge")
+		log.Verbosef("Found a transaction sent to our bridge, deposits = %d, transfer out = %d",
+			len(solanaTx.Deposits), len(solanaTx.TransferOut))
+		serialized, err := json.Marshal(solanaTx)
+		if err != nil {
+			log.Error("Failed to marshal solana transaction, err = ", err)
+			continue
+		}
 
-			txArr = append(txArr, &types.Tx{
-				Hash:       outerTx.TransactionInner.Signatures[0],
-				Serialized: bz,
-				To:         w.cfg.SolanaBridgeProgramId,
-				Success:    true,
-			})
+		tx := &types.Tx{
+			Hash:       txId,
+			Serialized: serialized,
+			To:         w.cfg.SolanaBridgeProgramId,
+			Success:    true,
 		}
+		if len(solanaTx.Deposits) > 0 {
+			tx.From = solanaTx.Deposits[0].Sender
+			tx.To = w.getVault()
+		}
+
+		txArr = append(txArr, tx)
+	}
+
+	return txArr, trackUpdates
+}
+
+// publish sends the transactions in a finalized block to Sisu.
+func (w *Watcher) publish(block *pendingBlock) {
+	for _, update := range block.trackUpdates {
+		w.txTrackCh <- update
 	}
 
-	if len(txArr) > 0 {
+	if len(block.txArr) > 0 {
 		txs := types.Txs{
 			Chain:     w.cfg.Chain,
-			Block:     int64(block.ParentSlot + 1),
-			BlockHash: block.BlockHash,
-			Arr:       txArr,
+			Block:     int64(block.slot),
+			BlockHash: block.blockHash,
+			Arr:       block.txArr,
 		}
 
 		// Broadcast the result
@@ -153,12 +245,14 @@ func (w *Watcher) processBlock(block *solanatypes.Block) {
 	}
 }
 
+// acceptTx returns true if the transaction succeeded and has at least one instruction sent to the
+// bridge program.
 func (w *Watcher) acceptTx(outerTx *solanatypes.Transaction) bool {
-	if outerTx.Meta.Err != nil {
+	if outerTx == nil || outerTx.Meta == nil || outerTx.Meta.Err != nil {
 		return false
 	}
 
-	if outerTx == nil || outerTx.TransactionInner == nil || outerTx.TransactionInner.Message == nil ||
+	if outerTx.TransactionInner == nil || outerTx.TransactionInner.Message == nil ||
 		outerTx.TransactionInner.Message.AccountKeys == nil {
 		return false
 	}
@@ -179,7 +273,7 @@ func (w *Watcher) acceptTx(outerTx *solanatypes.Transaction) bool {
 
 	// Check that there is at least one instruction sent to the program id
 	for _, ix := range outerTx.TransactionInner.Message.Instructions {
-		if accounts[ix.ProgramIdIndex] == w.cfg.SolanaBridgeProgramId {
+		if ix.ProgramIdIndex < len(accounts) && accounts[ix.ProgramIdIndex] == w.cfg.SolanaBridgeProgramId {
 			return true
 		}
 	}
@@ -187,9 +281,133 @@ func (w *Watcher) acceptTx(outerTx *solanatypes.Transaction) bool {
 	return false
 }
 
+// extractDeposits returns the structured data of a transaction that calls the bridge program or
+// transfers SPL tokens to token accounts of the vault. It returns nil if the transaction is not
+// relevant to us.
+func (w *Watcher) extractDeposits(outerTx *solanatypes.Transaction, slot int64) *solanatypes.SolanaTransaction {
+	if outerTx == nil || outerTx.Meta == nil || outerTx.Meta.Err != nil ||
+		outerTx.TransactionInner == nil || outerTx.TransactionInner.Message == nil ||
+		len(outerTx.TransactionInner.Signatures) == 0 {
+		return nil
+	}
+
+	solanaTx := &solanatypes.SolanaTransaction{
+		Signature:   outerTx.TransactionInner.Signatures[0],
+		Slot:        slot,
+		Deposits:    make([]*solanatypes.Deposit, 0),
+		TransferOut: make([]*solanatypes.BridgeTransferOut, 0),
+	}
+
+	if w.acceptTx(outerTx) {
+		accounts := outerTx.AccountKeys()
+		for _, ix := range outerTx.TransactionInner.Message.Instructions {
+			if ix.ProgramIdIndex >= len(accounts) || accounts[ix.ProgramIdIndex] != w.cfg.SolanaBridgeProgramId {
+				continue
+			}
+
+			transferOut, err := decodeTransferOut(ix.Data)
+			if err != nil {
+				log.Warnf("Failed to decode bridge instruction in tx %s, err = %v", solanaTx.Signature, err)
+				continue
+			}
+
+			solanaTx.TransferOut = append(solanaTx.TransferOut, transferOut)
+		}
+	}
+
+	vault := w.getVault()
+	if len(vault) > 0 {
+		changes := getTokenBalanceChanges(outerTx)
+		memo := getMemo(outerTx)
+
+		for _, change := range changes {
+			if change.delta.Sign() <= 0 || !w.isVaultTokenAccount(vault, change) {
+				continue
+			}
+
+			solanaTx.Deposits = append(solanaTx.Deposits, &solanatypes.Deposit{
+				Mint:         change.mint,
+				Amount:       change.delta.String(),
+				Decimals:     change.decimals,
+				Sender:       findSender(outerTx, changes, change.mint),
+				TokenAccount: change.account,
+				Memo:         memo,
+			})
+		}
+	}
+
+	if len(solanaTx.Deposits) == 0 && len(solanaTx.TransferOut) == 0 {
+		return nil
+	}
+
+	return solanaTx
+}
+
+// isVaultTokenAccount checks if a token account belongs to the vault. The account is either the
+// associated token account of the vault or any token account whose owner is the vault.
+func (w *Watcher) isVaultTokenAccount(vault string, change *tokenBalanceChange) bool {
+	if change.owner == vault {
+		return true
+	}
+
+	ata, err := w.getVaultAta(vault, change.mint, change.program)
+	if err != nil {
+		log.Warnf("Cannot find associated token account for mint %s, err = %v", change.mint, err)
+		return false
+	}
+
+	return ata == change.account
+}
+
+func (w *Watcher) getVaultAta(vault, mint, program string) (string, error) {
+	key := mint + program
+
+	w.lock.RLock()
+	ata, ok := w.vaultAtas[key]
+	w.lock.RUnlock()
+	if ok {
+		return ata, nil
+	}
+
+	ata, err := findAssociatedTokenAddress(vault, mint, program)
+	if err != nil {
+		return "", err
+	}
+
+	w.lock.Lock()
+	if w.vault == vault {
+		w.vaultAtas[key] = ata
+	}
+	w.lock.Unlock()
+
+	return ata, nil
+}
+
+// findSender returns the owner of the token account that sends the token. If the sender cannot be
+// found, the fee payer is returned.
+func findSender(outerTx *solanatypes.Transaction, changes []*tokenBalanceChange, mint string) string {
+	for _, change := range changes {
+		if change.mint == mint && change.delta.Sign() < 0 && len(change.owner) > 0 {
+			return change.owner
+		}
+	}
+
+	accounts := outerTx.AccountKeys()
+	if len(accounts) > 0 {
+		return accounts[0]
+	}
+
+	return ""
+}
+
 func (w *Watcher) getSlot() (uint64, error) {
+	return w.getSlotWithCommitment(w.commitment)
+}
+
+func (w *Watcher) getSlotWithCommitment(commitment string) (uint64, error) {
 	return executeWithClients(w.clients, func(client jsonrpc.RPCClient) (uint64, bool, error) {
-		res, err := client.Call(context.Background(), "getSlot")
+		params := []interface{}{&solanatypes.CommitmentConfig{Commitment: commitment}}
+		res, err := client.Call(context.Background(), "getSlot", params)
 		if err != nil {
 			return 0, false, err
 		}
@@ -206,10 +424,15 @@ func (w *Watcher) getSlot() (uint64, error) {
 }
 
 func (w *Watcher) getBlockNumber(slot uint64) (*solanatypes.Block, error) {
+	return w.getBlockWithCommitment(slot, w.commitment)
+}
+
+func (w *Watcher) getBlockWithCommitment(slot uint64, commitment string) (*solanatypes.Block, error) {
 	return executeWithClients(w.clients, func(client jsonrpc.RPCClient) (*solanatypes.Block, bool, error) {
 		var request = &solanatypes.GetBlockRequest{
 			TransactionDetails:             "full",
 			MaxSupportedTransactionVersion: 100,
+			Commitment:                     commitment,
 		}
 
 		res, err := client.Call(context.Background(), "getBlock", slot, request)
@@ -218,7 +441,7 @@ func (w *Watcher) getBlockNumber(slot uint64) (*solanatypes.Block, error) {
 		}
 
 		if res.Error != nil {
-			return nil, true, err
+			return nil, true, res.Error
 		}
 
 		block := new(solanatypes.Block)
@@ -233,12 +456,35 @@ func (w *Watcher) getBlockNumber(slot uint64) (*solanatypes.Block, error) {
 			return nil, true, err
 		}
 
+		block.Slot = slot
 		return block, true, nil
 	})
 }
 
 func (w *Watcher) SetVault(addr string, token string) {
-	// Do nothing as we are not watching any token change.
+	if _, err := solanago.PublicKeyFromBase58(addr); err != nil {
+		log.Errorf("Invalid solana vault address %s, err = %v", addr, err)
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	log.Verbosef("Setting vault for chain %s with address %s", w.cfg.Chain, addr)
+	err := w.db.SetVault(w.cfg.Chain, addr, token)
+	if err == nil {
+		w.vault = addr
+		w.vaultAtas = make(map[string]string)
+	} else {
+		log.Error("Failed to save vault")
+	}
+}
+
+func (w *Watcher) getVault() string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.vault
 }
 
 func (w *Watcher) TrackTx(txHash string) {
@@ -254,7 +500,8 @@ func (w *Watcher) QueryRecentBlock() (string, int64, error) {
 	}
 
 	res, err := executeWithClients(w.clients, func(client jsonrpc.RPCClient) (*RpcResponse, bool, error) {
-		result, err := client.Call(context.Background(), "getLatestBlockhash")
+		params := []interface{}{&solanatypes.CommitmentConfig{Commitment: w.commitment}}
+		result, err := client.Call(context.Background(), "getLatestBlockhash", params)
 		if err != nil {
 			return nil, false, err
 		}
@@ -0,0 +1,263 @@
+package solana
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/near/borsh-go"
+	solanatypes "github.com/sisu-network/deyes/chains/solana/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc/v3"
+)
+
+const (
+	testBridgeProgram = "3tqV2dLdFGKeyKkySetgy9ipaThgX6gc4oxFfMqs7Dzr"
+	testVault         = "CvocQ9ivbdz5rUnTh6zBgxaiR4asMNbXRrG2VPUYpoau"
+	testSender        = "9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM"
+	testSenderAta     = "2wmVCSfPxGPjrnMMn7rchp4uaeoTqN39mXFC2zhPdri9"
+	testMint          = "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	memoProgram       = "MemoSq4gqABAXKb96qnH8TssFjxBr2uZ9j9MK1N5nTy"
+)
+
+func getTestDb() database.Database {
+	db := database.NewDb(&config.Deyes{InMemory: true, DbHost: "localhost"})
+	err := db.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func newTestWatcher(txsCh chan *types.Txs) *Watcher {
+	w := NewWatcher(config.Chain{
+		Chain:                 "solana-devnet",
+		SolanaBridgeProgramId: testBridgeProgram,
+	}, getTestDb(), txsCh, nil)
+	w.SetVault(testVault, "")
+
+	return w
+}
+
+func newTokenBalance(index int, owner, amount string) solanatypes.TokenBalance {
+	return solanatypes.TokenBalance{
+		AccountIndex:  index,
+		Mint:          testMint,
+		Owner:         owner,
+		UiTokenAmount: solanatypes.UiTokenAmount{Amount: amount, Decimals: 6},
+	}
+}
+
+func encodeTransferOut(t *testing.T) string {
+	bz, err := borsh.Serialize(solanatypes.TransferOutData{
+		Amount:       *big.NewInt(1000),
+		TokenAddress: testMint,
+		ChainId:      189985,
+		Recipient:    "0x8095f5b69F2970f38DC6eBD2682ed71E4939f988",
+	})
+	require.Nil(t, err)
+
+	return base58.Encode(append([]byte{1}, bz...))
+}
+
+func TestWatcher_ProcessBlockSplDeposit(t *testing.T) {
+	txsCh := make(chan *types.Txs, 1)
+	w := newTestWatcher(txsCh)
+
+	vaultAta, err := findAssociatedTokenAddress(testVault, testMint, "")
+	require.Nil(t, err)
+
+	outerTx := &solanatypes.Transaction{
+		Meta: &solanatypes.TransactionMeta{
+			// The vault's ATA is created in this transaction so it does not have a pre balance. Its
+			// owner is not set to check ATA resolution.
+			PreTokenBalances: []solanatypes.TokenBalance{
+				newTokenBalance(1, testSender, "5000000"),
+			},
+			PostTokenBalances: []solanatypes.TokenBalance{
+				newTokenBalance(1, testSender, "3000000"),
+				newTokenBalance(4, "", "2000000"),
+			},
+			LoadedAddresses: &solanatypes.LoadedAddresses{Writable: []string{vaultAta}},
+		},
+		TransactionInner: &solanatypes.TransactionInner{
+			Signatures: []string{"sig1"},
+			Message: &solanatypes.TransactionMessage{
+				AccountKeys: []string{testSender, testSenderAta, memoProgram, testMint},
+				Instructions: []solanatypes.Instruction{
+					{ProgramIdIndex: 2, Data: base58.Encode([]byte("ganache1:0xabc"))},
+				},
+			},
+		},
+	}
+
+	// Slots 98 and 99 were skipped. The deposit slot is the slot of the block, not ParentSlot + 1.
+	w.processBlock(&solanatypes.Block{
+		Slot:         100,
+		ParentSlot:   97,
+		BlockHash:    "hash",
+		Transactions: []*solanatypes.Transaction{outerTx},
+	})
+
+	txs := <-txsCh
+	require.Equal(t, int64(100), txs.Block)
+	require.Equal(t, 1, len(txs.Arr))
+	require.Equal(t, testSender, txs.Arr[0].From)
+	require.Equal(t, testVault, txs.Arr[0].To)
+
+	solanaTx := &solanatypes.SolanaTransaction{}
+	err = json.Unmarshal(txs.Arr[0].Serialized, solanaTx)
+	require.Nil(t, err)
+	require.Equal(t, int64(100), solanaTx.Slot)
+	require.Equal(t, []*solanatypes.Deposit{{
+		Mint:         testMint,
+		Amount:       "2000000",
+		Decimals:     6,
+		Sender:       testSender,
+		TokenAccount: vaultAta,
+		Memo:         "ganache1:0xabc",
+	}}, solanaTx.Deposits)
+}
+
+func TestWatcher_ProcessBlockTransferOut(t *testing.T) {
+	txsCh := make(chan *types.Txs, 1)
+	w := newTestWatcher(txsCh)
+
+	bridgeTx := &solanatypes.Transaction{
+		Meta: &solanatypes.TransactionMeta{},
+		TransactionInner: &solanatypes.TransactionInner{
+			Signatures: []string{"sig2"},
+			Message: &solanatypes.TransactionMessage{
+				AccountKeys: []string{testSender, testBridgeProgram},
+				Instructions: []solanatypes.Instruction{
+					{ProgramIdIndex: 1, Data: encodeTransferOut(t)},
+				},
+			},
+		},
+	}
+	failedTx := &solanatypes.Transaction{
+		Meta:             &solanatypes.TransactionMeta{Err: "InstructionError"},
+		TransactionInner: bridgeTx.TransactionInner,
+	}
+
+	w.processBlock(&solanatypes.Block{
+		Slot:         100,
+		Transactions: []*solanatypes.Transaction{bridgeTx, failedTx},
+	})
+
+	txs := <-txsCh
+	require.Equal(t, 1, len(txs.Arr))
+	require.Equal(t, testBridgeProgram, txs.Arr[0].To)
+
+	solanaTx := &solanatypes.SolanaTransaction{}
+	err := json.Unmarshal(txs.Arr[0].Serialized, solanaTx)
+	require.Nil(t, err)
+	require.Equal(t, 0, len(solanaTx.Deposits))
+	require.Equal(t, []*solanatypes.BridgeTransferOut{{
+		Amount:       "1000",
+		TokenAddress: testMint,
+		ChainId:      189985,
+		Recipient:    "0x8095f5b69F2970f38DC6eBD2682ed71E4939f988",
+	}}, solanaTx.TransferOut)
+}
+
+func TestWatcher_HoldUntilFinalized(t *testing.T) {
+	newTx := func(sig string) map[string]interface{} {
+		return map[string]interface{}{
+			"meta": map[string]interface{}{},
+			"transaction": map[string]interface{}{
+				"signatures": []string{sig},
+				"message": map[string]interface{}{
+					"accountKeys":  []string{testSender, testBridgeProgram},
+					"instructions": []interface{}{map[string]interface{}{"programIdIndex": 1, "data": encodeTransferOut(t)}},
+				},
+			},
+		}
+	}
+
+	// Finalized blocks by slot. Block 101 was not fetched and block 103 replaced the one we fetched.
+	finalized := map[uint64]map[string]interface{}{
+		100: {"blockhash": "hash100", "transactions": []interface{}{newTx("sig100")}},
+		101: {"blockhash": "hash101", "transactions": []interface{}{newTx("sig101")}},
+		102: {"blockhash": "hash102", "transactions": []interface{}{newTx("sig102")}},
+		103: {"blockhash": "other_hash", "transactions": []interface{}{newTx("other_sig103")}},
+	}
+	server := newMockRpcServer(t, func(method string, params []json.RawMessage) (interface{}, *jsonrpc.RPCError) {
+		switch method {
+		case "getBlocks":
+			var start, end uint64
+			require.Nil(t, json.Unmarshal(params[0], &start))
+			require.Nil(t, json.Unmarshal(params[1], &end))
+
+			slots := make([]uint64, 0)
+			for slot := start; slot <= end; slot++ {
+				if _, ok := finalized[slot]; ok {
+					slots = append(slots, slot)
+				}
+			}
+			return slots, nil
+		case "getBlock":
+			var slot uint64
+			require.Nil(t, json.Unmarshal(params[0], &slot))
+			return finalized[slot], nil
+		}
+		return nil, &jsonrpc.RPCError{Code: -32601, Message: "Method not found"}
+	})
+	defer server.Close()
+
+	txsCh := make(chan *types.Txs, 4)
+	w := NewWatcher(config.Chain{
+		Chain:                 "solana-devnet",
+		Rpcs:                  []string{server.URL},
+		SolanaBridgeProgramId: testBridgeProgram,
+		SolanaCommitment:      solanatypes.CommitmentConfirmed,
+	}, getTestDb(), txsCh, nil)
+
+	newBlock := func(slot int, parentSlot int, hash string) *solanatypes.Block {
+		return &solanatypes.Block{
+			Slot:       uint64(slot),
+			ParentSlot: parentSlot,
+			BlockHash:  hash,
+			Transactions: []*solanatypes.Transaction{{
+				Meta: &solanatypes.TransactionMeta{},
+				TransactionInner: &solanatypes.TransactionInner{
+					Signatures: []string{fmt.Sprintf("sig%d", slot)},
+					Message: &solanatypes.TransactionMessage{
+						AccountKeys:  []string{testSender, testBridgeProgram},
+						Instructions: []solanatypes.Instruction{{ProgramIdIndex: 1, Data: encodeTransferOut(t)}},
+					},
+				},
+			}},
+		}
+	}
+
+	// Slot 101 was skipped on the fork we fetched, the parent of block 102 is block 100.
+	w.processBlock(newBlock(100, 99, "hash100"))
+	w.processBlock(newBlock(102, 100, "hash102"))
+	w.processBlock(newBlock(103, 102, "hash103"))
+	w.processBlock(newBlock(104, 103, "hash104"))
+	require.Equal(t, 0, len(txsCh))
+	require.Equal(t, 4, len(w.pendingBlocks))
+
+	w.processFinalizedSlot(103)
+	require.Equal(t, 1, len(w.pendingBlocks))
+	require.Equal(t, uint64(104), w.pendingBlocks[0].slot)
+	require.Equal(t, uint64(103), w.finalizedSlot)
+
+	// The blocks of the finalized fork are published, including the blocks we did not fetch.
+	require.Equal(t, 4, len(txsCh))
+	for _, expected := range []struct {
+		slot int64
+		hash string
+	}{{100, "sig100"}, {101, "sig101"}, {102, "sig102"}, {103, "other_sig103"}} {
+		txs := <-txsCh
+		require.Equal(t, expected.slot, txs.Block)
+		require.Equal(t, expected.hash, txs.Arr[0].Hash)
+	}
+}
@@ -0,0 +1,201 @@
+package tron
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/sisu-network/deyes/chains/tron/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/lib/log"
+)
+
+var (
+	RpcTimeOut = time.Second * 10
+)
+
+type APIErr struct {
+	message string
+}
+
+func NewApiErr(message string) error {
+	return &APIErr{message: message}
+}
+
+func (e *APIErr) Error() string {
+	return e.message
+}
+
+var (
+	ErrBlockNotFound = NewApiErr("tron block is not found")
+)
+
+// Client is a wrapper around the Tron full node HTTP API so that we can mock it in tests.
+type Client interface {
+	BlockNumber() (int64, error)
+	BlockByNumber(num int64) (*types.Block, error)
+	BroadcastHex(txHex string) (*types.BroadcastResult, error)
+	GetAccountResource(address string) (*types.AccountResource, error)
+	GetChainParameters() ([]types.ChainParameter, error)
+	TriggerConstantContract(owner, contract, selector, parameter string) (*types.ConstantContractResult, error)
+}
+
+type defaultClient struct {
+	chain      string
+	rpcs       []string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewTronClient(cfg config.Chain) Client {
+	return &defaultClient{
+		chain:      cfg.Chain,
+		rpcs:       cfg.Rpcs,
+		apiKey:     cfg.RpcSecret,
+		httpClient: &http.Client{Timeout: RpcTimeOut},
+	}
+}
+
+// post sends a request to a random rpc. If the rpc is unreachable, the next rpc is tried.
+func (c *defaultClient) post(endpoint string, body interface{}, result interface{}) error {
+	if len(c.rpcs) == 0 {
+		return fmt.Errorf("no rpc configured for chain %s", c.chain)
+	}
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	start := rand.Intn(len(c.rpcs))
+	for i := 0; i < len(c.rpcs); i++ {
+		rpc := c.rpcs[(start+i)%len(c.rpcs)]
+
+		var bz []byte
+		bz, err = c.doPost(rpc+endpoint, jsonData)
+		if err != nil {
+			log.Warnf("Failed to call %s on rpc %s, err = %v", endpoint, rpc, err)
+			continue
+		}
+
+		return json.Unmarshal(bz, result)
+	}
+
+	return err
+}
+
+func (c *defaultClient) doPost(url string, jsonData []byte) ([]byte, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(c.apiKey) > 0 {
+		// Only used for TronGrid
+		req.Header.Set("TRON-PRO-API-KEY", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bz, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d, body = %s", resp.StatusCode, string(bz))
+	}
+
+	return bz, nil
+}
+
+func (c *defaultClient) BlockNumber() (int64, error) {
+	block := &types.Block{}
+	if err := c.post("/wallet/getnowblock", map[string]interface{}{}, block); err != nil {
+		return 0, err
+	}
+
+	return block.Number(), nil
+}
+
+func (c *defaultClient) BlockByNumber(num int64) (*types.Block, error) {
+	block := &types.Block{}
+	if err := c.post("/wallet/getblockbynum", map[string]interface{}{"num": num}, block); err != nil {
+		return nil, err
+	}
+
+	if len(block.BlockID) == 0 {
+		return nil, ErrBlockNotFound
+	}
+
+	block.TxInfos = make(map[string]*types.TransactionInfo)
+	if len(block.Transactions) == 0 {
+		return block, nil
+	}
+
+	infos := make([]*types.TransactionInfo, 0)
+	err := c.post("/wallet/gettransactioninfobyblocknum", map[string]interface{}{"num": num}, &infos)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		block.TxInfos[info.Id] = info
+	}
+
+	return block, nil
+}
+
+func (c *defaultClient) BroadcastHex(txHex string) (*types.BroadcastResult, error) {
+	result := &types.BroadcastResult{}
+	if err := c.post("/wallet/broadcasthex", map[string]interface{}{"transaction": txHex}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *defaultClient) GetAccountResource(address string) (*types.AccountResource, error) {
+	result := &types.AccountResource{}
+	if err := c.post("/wallet/getaccountresource", map[string]interface{}{"address": address}, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *defaultClient) GetChainParameters() ([]types.ChainParameter, error) {
+	result := &struct {
+		ChainParameter []types.ChainParameter `json:"chainParameter"`
+	}{}
+	if err := c.post("/wallet/getchainparameters", map[string]interface{}{}, result); err != nil {
+		return nil, err
+	}
+
+	return result.ChainParameter, nil
+}
+
+func (c *defaultClient) TriggerConstantContract(owner, contract, selector, parameter string) (*types.ConstantContractResult, error) {
+	body := map[string]interface{}{
+		"owner_address":     owner,
+		"contract_address":  contract,
+		"function_selector": selector,
+		"parameter":         parameter,
+	}
+
+	result := &types.ConstantContractResult{}
+	if err := c.post("/wallet/triggerconstantcontract", body, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package tron
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/sisu-network/deyes/chains"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+// Response codes of the broadcast API.
+const (
+	CodeSuccess               = "SUCCESS"
+	CodeSigError              = "SIGERROR"
+	CodeContractValidateError = "CONTRACT_VALIDATE_ERROR"
+	CodeBandwidthError        = "BANDWITH_ERROR" // Typo is from the Tron node.
+	CodeDupTransactionError   = "DUP_TRANSACTION_ERROR"
+	CodeTaposError            = "TAPOS_ERROR"
+	CodeTooBigTransaction     = "TOO_BIG_TRANSACTION_ERROR"
+	CodeTransactionExpiration = "TRANSACTION_EXPIRATION_ERROR"
+)
+
+// TronDispatcher broadcasts signed transactions. Tron transactions do not have nonce; they are
+// bound to a recent block through ref_block_bytes and ref_block_hash and expire after a short time.
+type TronDispatcher struct {
+	chain  string
+	client Client
+}
+
+func NewDispatcher(chain string, client Client) chains.Dispatcher {
+	return &TronDispatcher{
+		chain:  chain,
+		client: client,
+	}
+}
+
+func (d *TronDispatcher) Start() {
+}
+
+func (d *TronDispatcher) Dispatch(request *types.DispatchedTxRequest) *types.DispatchedTxResult {
+	result, err := d.client.BroadcastHex(hex.EncodeToString(request.Tx))
+	if err != nil {
+		log.Errorf("Failed to broadcast tron tx, err = %v", err)
+		return types.NewDispatchTxError(request, types.ErrSubmitTx)
+	}
+
+	txHash := strings.ToLower(result.TxId)
+	if len(txHash) == 0 {
+		txHash = request.TxHash
+	}
+
+	if !result.Result {
+		message := decodeMessage(result.Message)
+		dispatchErr := broadcastCodeToDispatchError(result.Code, message)
+		if dispatchErr != types.ErrNil {
+			log.Errorf("Tron tx %s is rejected, code = %s, message = %s", txHash, result.Code, message)
+			return &types.DispatchedTxResult{
+				Success: false,
+				Err:     dispatchErr,
+				Chain:   request.Chain,
+				TxHash:  txHash,
+			}
+		}
+	}
+
+	log.Verbose("Tron tx is dispatched successfully, hash = ", txHash)
+
+	return &types.DispatchedTxResult{
+		Success: true,
+		Chain:   request.Chain,
+		TxHash:  txHash,
+	}
+}
+
+// decodeMessage decodes the hex encoded message returned by the broadcast API.
+func decodeMessage(message string) string {
+	bz, err := hex.DecodeString(message)
+	if err != nil {
+		return message
+	}
+
+	return string(bz)
+}
+
+func broadcastCodeToDispatchError(code string, message string) types.DispatchError {
+	switch code {
+	case CodeSuccess, CodeDupTransactionError:
+		// The same transaction has been submitted by another node. This is counted as a successful
+		// submission.
+		return types.ErrNil
+	case CodeSigError:
+		return types.ErrGeneric
+	case CodeBandwidthError:
+		return types.ErrInsufficientFee
+	case CodeContractValidateError:
+		lower := strings.ToLower(message)
+		if strings.Contains(lower, "balance is not sufficient") || strings.Contains(lower, "not enough") {
+			return types.ErrNotEnoughBalance
+		}
+		return types.ErrSubmitTx
+	case CodeTooBigTransaction:
+		return types.ErrMarshal
+	default:
+		return types.ErrSubmitTx
+	}
+}
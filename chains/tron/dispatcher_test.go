@@ -0,0 +1,55 @@
+package tron
+
+import (
+	"encoding/hex"
+	"testing"
+
+	trontypes "github.com/sisu-network/deyes/chains/tron/types"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTronDispatcher_Dispatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  bool
+		code    string
+		message string
+		success bool
+		err     types.DispatchError
+	}{
+		{name: "ok", result: true, success: true, err: types.ErrNil},
+		{name: "duplicated", code: CodeDupTransactionError, success: true, err: types.ErrNil},
+		{name: "bandwidth", code: CodeBandwidthError, err: types.ErrInsufficientFee},
+		{name: "balance", code: CodeContractValidateError, message: "Validate TransferContract error, balance is not sufficient.", err: types.ErrNotEnoughBalance},
+		{name: "validate", code: CodeContractValidateError, message: "Validate TransferContract error, no OwnerAccount.", err: types.ErrSubmitTx},
+		{name: "signature", code: CodeSigError, err: types.ErrGeneric},
+		{name: "expired", code: CodeTransactionExpiration, err: types.ErrSubmitTx},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &MockTronClient{
+				BroadcastHexFunc: func(txHex string) (*trontypes.BroadcastResult, error) {
+					require.Equal(t, "0a0b", txHex)
+					return &trontypes.BroadcastResult{
+						Result:  tc.result,
+						Code:    tc.code,
+						TxId:    "ABCD",
+						Message: hex.EncodeToString([]byte(tc.message)),
+					}, nil
+				},
+			}
+
+			dispatcher := NewDispatcher("tron-nile", client)
+			result := dispatcher.Dispatch(&types.DispatchedTxRequest{
+				Chain: "tron-nile",
+				Tx:    []byte{0x0a, 0x0b},
+			})
+
+			require.Equal(t, tc.success, result.Success)
+			require.Equal(t, tc.err, result.Err)
+			require.Equal(t, "abcd", result.TxHash)
+		})
+	}
+}
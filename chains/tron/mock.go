@@ -0,0 +1,62 @@
+package tron
+
+import (
+	"github.com/sisu-network/deyes/chains/tron/types"
+)
+
+type MockTronClient struct {
+	BlockNumberFunc             func() (int64, error)
+	BlockByNumberFunc           func(num int64) (*types.Block, error)
+	BroadcastHexFunc            func(txHex string) (*types.BroadcastResult, error)
+	GetAccountResourceFunc      func(address string) (*types.AccountResource, error)
+	GetChainParametersFunc      func() ([]types.ChainParameter, error)
+	TriggerConstantContractFunc func(owner, contract, selector, parameter string) (*types.ConstantContractResult, error)
+}
+
+func (c *MockTronClient) BlockNumber() (int64, error) {
+	if c.BlockNumberFunc != nil {
+		return c.BlockNumberFunc()
+	}
+
+	return 0, nil
+}
+
+func (c *MockTronClient) BlockByNumber(num int64) (*types.Block, error) {
+	if c.BlockByNumberFunc != nil {
+		return c.BlockByNumberFunc(num)
+	}
+
+	return nil, nil
+}
+
+func (c *MockTronClient) BroadcastHex(txHex string) (*types.BroadcastResult, error) {
+	if c.BroadcastHexFunc != nil {
+		return c.BroadcastHexFunc(txHex)
+	}
+
+	return nil, nil
+}
+
+func (c *MockTronClient) GetAccountResource(address string) (*types.AccountResource, error) {
+	if c.GetAccountResourceFunc != nil {
+		return c.GetAccountResourceFunc(address)
+	}
+
+	return nil, nil
+}
+
+func (c *MockTronClient) GetChainParameters() ([]types.ChainParameter, error) {
+	if c.GetChainParametersFunc != nil {
+		return c.GetChainParametersFunc()
+	}
+
+	return nil, nil
+}
+
+func (c *MockTronClient) TriggerConstantContract(owner, contract, selector, parameter string) (*types.ConstantContractResult, error) {
+	if c.TriggerConstantContractFunc != nil {
+		return c.TriggerConstantContractFunc(owner, contract, selector, parameter)
+	}
+
+	return nil, nil
+}
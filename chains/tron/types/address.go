@@ -0,0 +1,89 @@
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/mr-tron/base58"
+)
+
+const (
+	// AddressPrefix is the version byte of all Tron addresses.
+	AddressPrefix = byte(0x41)
+	AddressLength = 21
+)
+
+// Base58ToHex converts a base58check address (e.g. "TJRab...") to its hex form with 41 prefix.
+func Base58ToHex(addr string) (string, error) {
+	bz, err := base58.Decode(addr)
+	if err != nil {
+		return "", err
+	}
+
+	if len(bz) != AddressLength+4 {
+		return "", fmt.Errorf("invalid tron address length %d", len(bz))
+	}
+
+	payload, checksum := bz[:AddressLength], bz[AddressLength:]
+	if !bytes.Equal(doubleSha256(payload)[:4], checksum) {
+		return "", fmt.Errorf("invalid checksum for tron address %s", addr)
+	}
+
+	if payload[0] != AddressPrefix {
+		return "", fmt.Errorf("invalid tron address prefix %x", payload[0])
+	}
+
+	return hex.EncodeToString(payload), nil
+}
+
+// HexToBase58 converts a hex address to its base58check form. The hex address can be either 20
+// bytes (as in event logs) or 21 bytes with the 41 prefix.
+func HexToBase58(addr string) (string, error) {
+	bz, err := hex.DecodeString(strings.TrimPrefix(addr, "0x"))
+	if err != nil {
+		return "", err
+	}
+
+	switch len(bz) {
+	case AddressLength - 1:
+		bz = append([]byte{AddressPrefix}, bz...)
+	case AddressLength:
+		if bz[0] != AddressPrefix {
+			return "", fmt.Errorf("invalid tron address prefix %x", bz[0])
+		}
+	default:
+		return "", fmt.Errorf("invalid tron address length %d", len(bz))
+	}
+
+	return base58.Encode(append(bz, doubleSha256(bz)[:4]...)), nil
+}
+
+// NormalizeAddress returns the lower case hex form (with 41 prefix) of a base58 or hex address.
+func NormalizeAddress(addr string) (string, error) {
+	if strings.HasPrefix(addr, "T") {
+		return Base58ToHex(addr)
+	}
+
+	bz, err := hex.DecodeString(strings.TrimPrefix(addr, "0x"))
+	if err != nil {
+		return "", err
+	}
+
+	switch len(bz) {
+	case AddressLength - 1:
+		return hex.EncodeToString(append([]byte{AddressPrefix}, bz...)), nil
+	case AddressLength:
+		return hex.EncodeToString(bz), nil
+	default:
+		return "", fmt.Errorf("invalid tron address %s", addr)
+	}
+}
+
+func doubleSha256(bz []byte) []byte {
+	first := sha256.Sum256(bz)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
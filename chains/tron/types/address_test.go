@@ -0,0 +1,38 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddress_Conversion(t *testing.T) {
+	// USDT contract on Tron mainnet.
+	base58Addr := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	hexAddr := "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"
+
+	converted, err := Base58ToHex(base58Addr)
+	require.Nil(t, err)
+	require.Equal(t, hexAddr, converted)
+
+	converted, err = HexToBase58(hexAddr)
+	require.Nil(t, err)
+	require.Equal(t, base58Addr, converted)
+
+	// Addresses in event logs do not have the 41 prefix.
+	converted, err = HexToBase58(hexAddr[2:])
+	require.Nil(t, err)
+	require.Equal(t, base58Addr, converted)
+
+	normalized, err := NormalizeAddress(base58Addr)
+	require.Nil(t, err)
+	require.Equal(t, hexAddr, normalized)
+
+	normalized, err = NormalizeAddress("0x" + hexAddr[2:])
+	require.Nil(t, err)
+	require.Equal(t, hexAddr, normalized)
+
+	// Bad checksum
+	_, err = Base58ToHex("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u")
+	require.NotNil(t, err)
+}
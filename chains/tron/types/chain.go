@@ -0,0 +1,13 @@
+package types
+
+var (
+	TronChains = map[string]bool{
+		"tron-shasta":  true,
+		"tron-nile":    true,
+		"tron-mainnet": true,
+	}
+)
+
+func IsTronChain(chain string) bool {
+	return TronChains[chain]
+}
@@ -0,0 +1,19 @@
+package types
+
+// GasInfo contains the resource prices of a Tron chain and the resources that the vault can use
+// for free.
+type GasInfo struct {
+	EnergyFee          int64 // sun per unit of energy
+	BandwidthFee       int64 // sun per byte of bandwidth
+	AvailableEnergy    int64
+	AvailableBandwidth int64
+}
+
+// CostEstimate is the estimated resource usage and fee of a transaction. FeeLimit is the maximum
+// amount of sun that the transaction can burn for energy and should be set in the transaction.
+type CostEstimate struct {
+	Energy    int64
+	Bandwidth int64
+	Fee       int64
+	FeeLimit  int64
+}
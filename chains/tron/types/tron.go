@@ -0,0 +1,156 @@
+package types
+
+import "encoding/json"
+
+const (
+	ContractTypeTransfer     = "TransferContract"
+	ContractTypeTriggerSmart = "TriggerSmartContract"
+
+	ContractRetSuccess = "SUCCESS"
+
+	// keccak256("Transfer(address,address,uint256)")
+	Trc20TransferTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+	// TokenTrx is the token name used for native TRX transfers.
+	TokenTrx = "TRX"
+)
+
+type BlockHeader struct {
+	RawData struct {
+		Number     int64  `json:"number"`
+		ParentHash string `json:"parentHash"`
+		Timestamp  int64  `json:"timestamp"`
+	} `json:"raw_data"`
+}
+
+type Block struct {
+	BlockID      string         `json:"blockID"`
+	BlockHeader  BlockHeader    `json:"block_header"`
+	Transactions []*Transaction `json:"transactions"`
+
+	// Execution results of the transactions in this block, keyed by tx id. This is filled by the
+	// client from a separate API call.
+	TxInfos map[string]*TransactionInfo `json:"-"`
+}
+
+func (b *Block) Number() int64 {
+	return b.BlockHeader.RawData.Number
+}
+
+type Contract struct {
+	Type      string `json:"type"`
+	Parameter struct {
+		Value   json.RawMessage `json:"value"`
+		TypeUrl string          `json:"type_url"`
+	} `json:"parameter"`
+}
+
+type TransferContract struct {
+	OwnerAddress string `json:"owner_address"`
+	ToAddress    string `json:"to_address"`
+	Amount       int64  `json:"amount"`
+}
+
+type TriggerSmartContract struct {
+	OwnerAddress    string `json:"owner_address"`
+	ContractAddress string `json:"contract_address"`
+	Data            string `json:"data"`
+	CallValue       int64  `json:"call_value"`
+}
+
+type TransactionRet struct {
+	ContractRet string `json:"contractRet"`
+}
+
+type Transaction struct {
+	TxID    string `json:"txID"`
+	RawData struct {
+		Contract      []Contract `json:"contract"`
+		RefBlockBytes string     `json:"ref_block_bytes"`
+		RefBlockHash  string     `json:"ref_block_hash"`
+		Expiration    int64      `json:"expiration"`
+		FeeLimit      int64      `json:"fee_limit"`
+		Timestamp     int64      `json:"timestamp"`
+	} `json:"raw_data"`
+	RawDataHex string           `json:"raw_data_hex"`
+	Ret        []TransactionRet `json:"ret"`
+	Signature  []string         `json:"signature"`
+}
+
+func (tx *Transaction) Success() bool {
+	return len(tx.Ret) > 0 && tx.Ret[0].ContractRet == ContractRetSuccess
+}
+
+type Log struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+type Receipt struct {
+	EnergyUsage      int64  `json:"energy_usage"`
+	EnergyUsageTotal int64  `json:"energy_usage_total"`
+	EnergyFee        int64  `json:"energy_fee"`
+	NetUsage         int64  `json:"net_usage"`
+	NetFee           int64  `json:"net_fee"`
+	Result           string `json:"result"`
+}
+
+type TransactionInfo struct {
+	Id          string  `json:"id"`
+	Fee         int64   `json:"fee"`
+	BlockNumber int64   `json:"blockNumber"`
+	Receipt     Receipt `json:"receipt"`
+	Log         []Log   `json:"log"`
+	// Result is "FAILED" when the transaction failed, empty otherwise.
+	Result     string `json:"result"`
+	ResMessage string `json:"resMessage"`
+}
+
+type BroadcastResult struct {
+	Result  bool   `json:"result"`
+	TxId    string `json:"txid"`
+	Code    string `json:"code"`
+	Message string `json:"message"` // hex encoded
+}
+
+type AccountResource struct {
+	FreeNetUsed  int64 `json:"freeNetUsed"`
+	FreeNetLimit int64 `json:"freeNetLimit"`
+	NetUsed      int64 `json:"NetUsed"`
+	NetLimit     int64 `json:"NetLimit"`
+	EnergyUsed   int64 `json:"EnergyUsed"`
+	EnergyLimit  int64 `json:"EnergyLimit"`
+}
+
+type ChainParameter struct {
+	Key   string `json:"key"`
+	Value int64  `json:"value"`
+}
+
+type ConstantContractResult struct {
+	Result struct {
+		Result  bool   `json:"result"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"result"`
+	EnergyUsed     int64    `json:"energy_used"`
+	ConstantResult []string `json:"constant_result"`
+}
+
+// Transfer is a TRX or TRC-20 transfer to the vault.
+type Transfer struct {
+	// Token is "TRX" for native transfers, otherwise the base58 address of the TRC-20 contract.
+	Token    string `json:"token"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Amount   string `json:"amount"`
+	LogIndex int    `json:"log_index"`
+}
+
+// TronTransaction is the data model of a Tron transaction that we send to Sisu.
+type TronTransaction struct {
+	TxId        string      `json:"tx_id"`
+	BlockNumber int64       `json:"block_number"`
+	Transfers   []*Transfer `json:"transfers"`
+}
@@ -0,0 +1,445 @@
+package tron
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/sisu-network/deyes/chains"
+	trontypes "github.com/sisu-network/deyes/chains/tron/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/sisu-network/deyes/types"
+	"github.com/sisu-network/lib/log"
+)
+
+const (
+	TxTrackCacheSize = 1_000
+
+	// Chain parameters that define resource prices.
+	ParamEnergyFee      = "getEnergyFee"
+	ParamTransactionFee = "getTransactionFee"
+
+	// Approximate bandwidth (bytes) used by signed transactions. Bandwidth is the size of the
+	// transaction so it does not change much between transactions of the same type.
+	TrxTransferBandwidth   = 270
+	Trc20TransferBandwidth = 350
+
+	// FeeLimitMarginPercent is the extra energy fee added on top of the estimate when computing fee
+	// limit since the energy used by a contract call can change between estimation and execution.
+	FeeLimitMarginPercent = 20
+
+	trc20TransferSelector = "transfer(address,uint256)"
+)
+
+type Watcher struct {
+	cfg          config.Chain
+	client       Client
+	db           database.Database
+	vault        string // hex with 41 prefix
+	txTrackCache *lru.Cache
+	lock         *sync.RWMutex
+	txsCh        chan *types.Txs
+	txTrackCh    chan *chainstypes.TrackUpdate
+	doneCh       chan bool
+
+	// Block fetcher
+	blockCh      chan *trontypes.Block
+	blockFetcher *defaultBlockFetcher
+}
+
+func NewWatcher(db database.Database, cfg config.Chain, txsCh chan *types.Txs,
+	txTrackCh chan *chainstypes.TrackUpdate, client Client) chains.Watcher {
+	blockCh := make(chan *trontypes.Block)
+
+	return &Watcher{
+		blockCh:      blockCh,
+		blockFetcher: newBlockFetcher(cfg, blockCh, client),
+		db:           db,
+		cfg:          cfg,
+		txsCh:        txsCh,
+		txTrackCh:    txTrackCh,
+		client:       client,
+		lock:         &sync.RWMutex{},
+		txTrackCache: lru.New(TxTrackCacheSize),
+		doneCh:       make(chan bool),
+	}
+}
+
+func (w *Watcher) init() {
+	vaults, err := w.db.GetVaults(w.cfg.Chain)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(vaults) > 0 {
+		vault, err := trontypes.NormalizeAddress(vaults[0])
+		if err != nil {
+			log.Errorf("Invalid vault address %s in the db for chain %s", vaults[0], w.cfg.Chain)
+			return
+		}
+
+		w.vault = vault
+		log.Infof("Saved gateway in the db for chain %s is %s", w.cfg.Chain, w.vault)
+	} else {
+		log.Infof("Vault for chain %s is not set yet", w.cfg.Chain)
+	}
+}
+
+func (w *Watcher) SetVault(addr string, token string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	log.Verbosef("Setting vault for chain %s with address %s", w.cfg.Chain, addr)
+	vault, err := trontypes.NormalizeAddress(addr)
+	if err != nil {
+		log.Errorf("Invalid tron vault address %s, err = %v", addr, err)
+		return
+	}
+
+	err = w.db.SetVault(w.cfg.Chain, addr, token)
+	if err == nil {
+		w.vault = vault
+	} else {
+		log.Error("Failed to save vault")
+	}
+}
+
+func (w *Watcher) getVault() string {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	return w.vault
+}
+
+func (w *Watcher) Start() {
+	log.Infof("Starting Watcher for chain %s", w.cfg.Chain)
+	w.init()
+
+	go w.blockFetcher.start()
+	go w.waitForBlock()
+}
+
+func (w *Watcher) Stop() {
+	w.blockFetcher.stop()
+	w.doneCh <- true
+}
+
+func (w *Watcher) waitForBlock() {
+	for {
+		select {
+		case <-w.doneCh:
+			return
+
+		case block := <-w.blockCh:
+			log.Info(w.cfg.Chain, " Block length = ", len(block.Transactions))
+			w.processBlock(block)
+		}
+	}
+}
+
+func (w *Watcher) processBlock(block *trontypes.Block) {
+	txArr := make([]*types.Tx, 0)
+	vault := w.getVault()
+
+	for _, tx := range block.Transactions {
+		txId := strings.ToLower(tx.TxID)
+		info := block.TxInfos[tx.TxID]
+
+		if _, ok := w.txTrackCache.Get(txId); ok {
+			log.Verbose("Confirming tron tx with id = ", txId)
+
+			result := chainstypes.TrackResultConfirmed
+			if !isTxSuccess(tx, info) {
+				result = chainstypes.TrackResultFailure
+			}
+
+			// This is a transaction that we are tracking. Inform Sisu about this.
+			w.txTrackCh <- &chainstypes.TrackUpdate{
+				Chain:       w.cfg.Chain,
+				Bytes:       []byte(tx.RawDataHex),
+				Hash:        txId,
+				BlockHeight: block.Number(),
+				Result:      result,
+			}
+
+			continue
+		}
+
+		if len(vault) == 0 || !isTxSuccess(tx, info) {
+			continue
+		}
+
+		transfers := w.extractTransfers(tx, info, vault)
+		if len(transfers) == 0 {
+			continue
+		}
+
+		log.Infof("Found %d transfer(s) to the vault in tron tx %s", len(transfers), txId)
+		serialized, err := json.Marshal(&trontypes.TronTransaction{
+			TxId:        txId,
+			BlockNumber: block.Number(),
+			Transfers:   transfers,
+		})
+		if err != nil {
+			log.Errorf("Failed to marshal tron transaction, err = %v", err)
+			continue
+		}
+
+		txArr = append(txArr, &types.Tx{
+			Hash:       txId,
+			Serialized: serialized,
+			From:       transfers[0].From,
+			To:         transfers[0].To,
+			Success:    true,
+		})
+	}
+
+	if len(txArr) > 0 {
+		w.txsCh <- &types.Txs{
+			Chain:     w.cfg.Chain,
+			Block:     block.Number(),
+			BlockHash: block.BlockID,
+			Arr:       txArr,
+		}
+	}
+}
+
+// isTxSuccess returns true if the transaction and all the contract calls in it were executed
+// successfully.
+func isTxSuccess(tx *trontypes.Transaction, info *trontypes.TransactionInfo) bool {
+	if !tx.Success() {
+		return false
+	}
+
+	if info != nil && info.Result == "FAILED" {
+		return false
+	}
+
+	return true
+}
+
+// extractTransfers returns all TRX and TRC-20 transfers to the vault in a transaction. TRC-20
+// transfers are read from the Transfer event logs so that transfers done by intermediate contracts
+// are also detected.
+func (w *Watcher) extractTransfers(tx *trontypes.Transaction, info *trontypes.TransactionInfo,
+	vault string) []*trontypes.Transfer {
+	transfers := make([]*trontypes.Transfer, 0)
+
+	for _, contract := range tx.RawData.Contract {
+		if contract.Type != trontypes.ContractTypeTransfer {
+			continue
+		}
+
+		value := &trontypes.TransferContract{}
+		if err := json.Unmarshal(contract.Parameter.Value, value); err != nil {
+			log.Errorf("Failed to parse transfer contract in tx %s, err = %v", tx.TxID, err)
+			continue
+		}
+
+		if !strings.EqualFold(value.ToAddress, vault) {
+			continue
+		}
+
+		from, err := trontypes.HexToBase58(value.OwnerAddress)
+		if err != nil {
+			log.Errorf("Invalid owner address %s in tx %s", value.OwnerAddress, tx.TxID)
+			continue
+		}
+		to, _ := trontypes.HexToBase58(vault)
+
+		transfers = append(transfers, &trontypes.Transfer{
+			Token:    trontypes.TokenTrx,
+			From:     from,
+			To:       to,
+			Amount:   big.NewInt(value.Amount).String(),
+			LogIndex: -1,
+		})
+	}
+
+	if info == nil {
+		return transfers
+	}
+
+	for i, l := range info.Log {
+		transfer, err := parseTrc20Transfer(&l)
+		if err != nil {
+			log.Verbosef("Log %d in tx %s is not a TRC-20 transfer, err = %v", i, tx.TxID, err)
+			continue
+		}
+
+		toHex, _ := trontypes.Base58ToHex(transfer.To)
+		if toHex != vault {
+			continue
+		}
+
+		transfer.LogIndex = i
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers
+}
+
+// parseTrc20Transfer decodes a Transfer(address,address,uint256) event log.
+func parseTrc20Transfer(l *trontypes.Log) (*trontypes.Transfer, error) {
+	if len(l.Topics) != 3 || strings.ToLower(l.Topics[0]) != trontypes.Trc20TransferTopic {
+		return nil, fmt.Errorf("not a transfer event")
+	}
+
+	token, err := trontypes.HexToBase58(l.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := topicToAddress(l.Topics[1])
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := topicToAddress(l.Topics[2])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := hex.DecodeString(l.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trontypes.Transfer{
+		Token:  token,
+		From:   from,
+		To:     to,
+		Amount: new(big.Int).SetBytes(data).String(),
+	}, nil
+}
+
+// topicToAddress converts an indexed address topic (32 bytes, left padded) to a base58 address.
+func topicToAddress(topic string) (string, error) {
+	if len(topic) != 64 {
+		return "", fmt.Errorf("invalid address topic %s", topic)
+	}
+
+	return trontypes.HexToBase58(topic[24:])
+}
+
+func (w *Watcher) TrackTx(txHash string) {
+	log.Verbose("Tracking tx: ", txHash)
+	w.txTrackCache.Add(strings.ToLower(strings.TrimPrefix(txHash, "0x")), true)
+}
+
+// GetGasInfo returns the current energy and bandwidth prices of the chain together with the free
+// resources of the vault.
+func (w *Watcher) GetGasInfo() (*trontypes.GasInfo, error) {
+	params, err := w.client.GetChainParameters()
+	if err != nil {
+		return nil, err
+	}
+
+	gasInfo := &trontypes.GasInfo{}
+	for _, param := range params {
+		switch param.Key {
+		case ParamEnergyFee:
+			gasInfo.EnergyFee = param.Value
+		case ParamTransactionFee:
+			gasInfo.BandwidthFee = param.Value
+		}
+	}
+
+	vault := w.getVault()
+	if len(vault) == 0 {
+		return gasInfo, nil
+	}
+
+	resource, err := w.client.GetAccountResource(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	gasInfo.AvailableEnergy = max64(resource.EnergyLimit-resource.EnergyUsed, 0)
+	gasInfo.AvailableBandwidth = max64(resource.FreeNetLimit-resource.FreeNetUsed, 0) +
+		max64(resource.NetLimit-resource.NetUsed, 0)
+
+	return gasInfo, nil
+}
+
+// EstimateCost estimates the resources and fee needed for the vault to transfer an amount of token
+// to a recipient. token is either "TRX" or the address of a TRC-20 contract.
+func (w *Watcher) EstimateCost(token string, recipient string, amount *big.Int) (*trontypes.CostEstimate, error) {
+	vault := w.getVault()
+	if len(vault) == 0 {
+		return nil, fmt.Errorf("vault for chain %s is not set", w.cfg.Chain)
+	}
+
+	gasInfo, err := w.GetGasInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &trontypes.CostEstimate{
+		Bandwidth: TrxTransferBandwidth,
+	}
+
+	if !strings.EqualFold(token, trontypes.TokenTrx) {
+		contract, err := trontypes.NormalizeAddress(token)
+		if err != nil {
+			return nil, err
+		}
+
+		to, err := trontypes.NormalizeAddress(recipient)
+		if err != nil {
+			return nil, err
+		}
+
+		if amount == nil || amount.Sign() < 0 || amount.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid transfer amount %v", amount)
+		}
+
+		parameter := encodeTransferParameter(to, amount)
+		result, err := w.client.TriggerConstantContract(vault, contract, trc20TransferSelector, parameter)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Result.Result {
+			return nil, fmt.Errorf("failed to estimate energy, code = %s, message = %s",
+				result.Result.Code, result.Result.Message)
+		}
+
+		estimate.Energy = result.EnergyUsed
+		estimate.Bandwidth = Trc20TransferBandwidth
+		estimate.FeeLimit = estimate.Energy * gasInfo.EnergyFee * (100 + FeeLimitMarginPercent) / 100
+	}
+
+	// Only the resources that the vault does not have are paid with TRX.
+	estimate.Fee = max64(estimate.Energy-gasInfo.AvailableEnergy, 0) * gasInfo.EnergyFee
+	if estimate.Bandwidth > gasInfo.AvailableBandwidth {
+		estimate.Fee += estimate.Bandwidth * gasInfo.BandwidthFee
+	}
+
+	return estimate, nil
+}
+
+// encodeTransferParameter ABI encodes the parameters of transfer(address,uint256). to is a hex
+// address with 41 prefix.
+func encodeTransferParameter(to string, amount *big.Int) string {
+	bz := make([]byte, 64)
+	addr, _ := hex.DecodeString(to)
+	copy(bz[32-(len(addr)-1):32], addr[1:])
+	amount.FillBytes(bz[32:])
+
+	return hex.EncodeToString(bz)
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
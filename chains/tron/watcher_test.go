@@ -0,0 +1,215 @@
+package tron
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	trontypes "github.com/sisu-network/deyes/chains/tron/types"
+	chainstypes "github.com/sisu-network/deyes/chains/types"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testVaultHex  = "41a614f803b6fd780986a42c78ec9c7f77e6ded13d"
+	testSenderHex = "41b614f803b6fd780986a42c78ec9c7f77e6ded13e"
+	testTokenHex  = "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"
+)
+
+func getTestDb() database.Database {
+	db := database.NewDb(&config.Deyes{InMemory: true, DbHost: "localhost"})
+	err := db.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func toBase58(hexAddr string) string {
+	addr, err := trontypes.HexToBase58(hexAddr)
+	if err != nil {
+		panic(err)
+	}
+
+	return addr
+}
+
+func newTestWatcher(txsCh chan *types.Txs, txTrackCh chan *chainstypes.TrackUpdate, client Client) *Watcher {
+	cfg := config.Chain{
+		Chain:      "tron-nile",
+		BlockTime:  3000,
+		AdjustTime: 500,
+		Rpcs:       []string{"http://localhost:8090"},
+	}
+
+	watcher := NewWatcher(getTestDb(), cfg, txsCh, txTrackCh, client).(*Watcher)
+	watcher.SetVault(toBase58(testVaultHex), "")
+
+	return watcher
+}
+
+func newTransferTx(txId, from, to string, amount int64, ret string) *trontypes.Transaction {
+	value, _ := json.Marshal(&trontypes.TransferContract{
+		OwnerAddress: from,
+		ToAddress:    to,
+		Amount:       amount,
+	})
+
+	tx := &trontypes.Transaction{TxID: txId}
+	contract := trontypes.Contract{Type: trontypes.ContractTypeTransfer}
+	contract.Parameter.Value = value
+	tx.RawData.Contract = []trontypes.Contract{contract}
+	tx.Ret = []trontypes.TransactionRet{{ContractRet: ret}}
+
+	return tx
+}
+
+func newTrc20Tx(txId string) *trontypes.Transaction {
+	tx := &trontypes.Transaction{TxID: txId}
+	tx.RawData.Contract = []trontypes.Contract{{Type: trontypes.ContractTypeTriggerSmart}}
+	tx.Ret = []trontypes.TransactionRet{{ContractRet: trontypes.ContractRetSuccess}}
+
+	return tx
+}
+
+func newTransferLog(from, to string, amount int64) trontypes.Log {
+	return trontypes.Log{
+		Address: testTokenHex[2:],
+		Topics: []string{
+			trontypes.Trc20TransferTopic,
+			strings.Repeat("0", 24) + from[2:],
+			strings.Repeat("0", 24) + to[2:],
+		},
+		Data: fmt.Sprintf("%064x", amount),
+	}
+}
+
+func TestWatcher_ProcessBlock(t *testing.T) {
+	txsCh := make(chan *types.Txs, 1)
+	watcher := newTestWatcher(txsCh, nil, &MockTronClient{})
+
+	block := &trontypes.Block{
+		BlockID: "block_id",
+		Transactions: []*trontypes.Transaction{
+			newTransferTx("aa01", testSenderHex, testVaultHex, 1_000_000, trontypes.ContractRetSuccess),
+			newTransferTx("aa02", testSenderHex, testSenderHex, 1_000_000, trontypes.ContractRetSuccess),
+			newTrc20Tx("aa03"),
+			newTrc20Tx("aa04"),
+		},
+		TxInfos: map[string]*trontypes.TransactionInfo{
+			"aa03": {Id: "aa03", Log: []trontypes.Log{
+				newTransferLog(testSenderHex, testSenderHex, 10),
+				newTransferLog(testSenderHex, testVaultHex, 500),
+			}},
+			// Reverted contract call
+			"aa04": {Id: "aa04", Result: "FAILED", Log: []trontypes.Log{
+				newTransferLog(testSenderHex, testVaultHex, 700),
+			}},
+		},
+	}
+	block.BlockHeader.RawData.Number = 100
+
+	watcher.processBlock(block)
+
+	txs := <-txsCh
+	require.Equal(t, int64(100), txs.Block)
+	require.Equal(t, 2, len(txs.Arr))
+	require.Equal(t, "aa01", txs.Arr[0].Hash)
+	require.Equal(t, "aa03", txs.Arr[1].Hash)
+	require.Equal(t, toBase58(testSenderHex), txs.Arr[0].From)
+	require.Equal(t, toBase58(testVaultHex), txs.Arr[0].To)
+
+	tx := &trontypes.TronTransaction{}
+	err := json.Unmarshal(txs.Arr[0].Serialized, tx)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tx.Transfers))
+	require.Equal(t, trontypes.TokenTrx, tx.Transfers[0].Token)
+	require.Equal(t, "1000000", tx.Transfers[0].Amount)
+
+	tx = &trontypes.TronTransaction{}
+	err = json.Unmarshal(txs.Arr[1].Serialized, tx)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(tx.Transfers))
+	require.Equal(t, toBase58(testTokenHex), tx.Transfers[0].Token)
+	require.Equal(t, "500", tx.Transfers[0].Amount)
+	require.Equal(t, 1, tx.Transfers[0].LogIndex)
+}
+
+func TestWatcher_TrackTx(t *testing.T) {
+	txTrackCh := make(chan *chainstypes.TrackUpdate, 2)
+	watcher := newTestWatcher(nil, txTrackCh, &MockTronClient{})
+	watcher.TrackTx("0xBB01")
+	watcher.TrackTx("bb02")
+
+	block := &trontypes.Block{
+		BlockID: "block_id",
+		Transactions: []*trontypes.Transaction{
+			newTransferTx("bb01", testVaultHex, testSenderHex, 10, trontypes.ContractRetSuccess),
+			newTransferTx("bb02", testVaultHex, testSenderHex, 10, "OUT_OF_ENERGY"),
+		},
+	}
+	watcher.processBlock(block)
+
+	update := <-txTrackCh
+	require.Equal(t, "bb01", update.Hash)
+	require.Equal(t, chainstypes.TrackResultConfirmed, update.Result)
+
+	update = <-txTrackCh
+	require.Equal(t, "bb02", update.Hash)
+	require.Equal(t, chainstypes.TrackResultFailure, update.Result)
+}
+
+func TestWatcher_EstimateCost(t *testing.T) {
+	var parameter string
+	client := &MockTronClient{
+		GetChainParametersFunc: func() ([]trontypes.ChainParameter, error) {
+			return []trontypes.ChainParameter{
+				{Key: ParamEnergyFee, Value: 420},
+				{Key: ParamTransactionFee, Value: 1000},
+			}, nil
+		},
+		GetAccountResourceFunc: func(address string) (*trontypes.AccountResource, error) {
+			require.Equal(t, testVaultHex, address)
+			return &trontypes.AccountResource{
+				FreeNetLimit: 600,
+				FreeNetUsed:  500,
+				EnergyLimit:  10_000,
+			}, nil
+		},
+		TriggerConstantContractFunc: func(owner, contract, selector, param string) (*trontypes.ConstantContractResult, error) {
+			require.Equal(t, testTokenHex, contract)
+			require.Equal(t, "transfer(address,uint256)", selector)
+			parameter = param
+
+			result := &trontypes.ConstantContractResult{EnergyUsed: 30_000}
+			result.Result.Result = true
+			return result, nil
+		},
+	}
+	watcher := newTestWatcher(nil, nil, client)
+
+	gasInfo, err := watcher.GetGasInfo()
+	require.Nil(t, err)
+	require.Equal(t, int64(420), gasInfo.EnergyFee)
+	require.Equal(t, int64(100), gasInfo.AvailableBandwidth)
+
+	// TRC-20 transfer
+	estimate, err := watcher.EstimateCost(toBase58(testTokenHex), toBase58(testSenderHex), big.NewInt(255))
+	require.Nil(t, err)
+	require.Equal(t, strings.Repeat("0", 24)+testSenderHex[2:]+fmt.Sprintf("%064x", 255), parameter)
+	require.Equal(t, int64(30_000), estimate.Energy)
+	require.Equal(t, int64((30_000-10_000)*420+Trc20TransferBandwidth*1000), estimate.Fee)
+	require.Equal(t, int64(30_000*420*120/100), estimate.FeeLimit)
+
+	// TRX transfer uses free bandwidth only.
+	estimate, err = watcher.EstimateCost(trontypes.TokenTrx, toBase58(testSenderHex), big.NewInt(255))
+	require.Nil(t, err)
+	require.Equal(t, int64(0), estimate.Energy)
+	require.Equal(t, int64(TrxTransferBandwidth*1000), estimate.Fee)
+}
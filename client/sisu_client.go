@@ -20,7 +20,9 @@ type Client interface {
 	TryDial()
 	Ping(source string) error
 	BroadcastTxs(txs *types.Txs) error
+	PostRevertedTxs(txs *types.RevertedTxs) error
 	PostDeploymentResult(result *types.DispatchedTxResult) error
+	UpdateTokenPrices(prices []*types.TokenPrice) error
 	OnTxIncludedInBlock(txTrack *chainstypes.TrackUpdate) error
 }
 
@@ -89,6 +91,21 @@ func (c *DefaultClient) BroadcastTxs(txs *types.Txs) error {
 	return nil
 }
 
+// PostRevertedTxs tells Sisu that transactions reported before are no longer on the chain. A Sisu
+// version that does not support it returns an error.
+func (c *DefaultClient) PostRevertedTxs(txs *types.RevertedTxs) error {
+	log.Verbose("Posting reverted txs to Sisu...")
+
+	var result string
+	err := c.client.CallContext(context.Background(), &result, "tss_postRevertedTxs", txs)
+	if err != nil {
+		log.Error("Cannot post reverted txs to Sisu, err = ", err)
+		return err
+	}
+
+	return nil
+}
+
 func (c *DefaultClient) PostDeploymentResult(result *types.DispatchedTxResult) error {
 	log.Verbose("Sending Tx Deployment result back to Sisu...")
 
@@ -21,6 +21,7 @@ type ClientType string
 const (
 	ClientTypeBlockFrost ClientType = "block_frost"
 	ClientTypeSelfHost   ClientType = "self_host"
+	ClientTypeOgmios     ClientType = "ogmios"
 )
 
 type Chain struct {
@@ -38,20 +39,77 @@ type Chain struct {
 	RpcSecret  string     `toml:"rpc_secret" json:"rpc_secret"`
 	// SyncDB config
 	SyncDB SyncDbConfig `toml:"sync_db" json:"sync_db"`
+	// Websocket url of the Ogmios server, e.g. ws://localhost:1337. Only Ogmios v5 is supported.
+	OgmiosUrl string `toml:"ogmios_url" json:"ogmios_url"`
+	// Cardano network: mainnet, preprod or preview.
+	CardanoNetwork string `toml:"cardano_network" json:"cardano_network"`
+	// Additional submit api endpoints. They are used in order when the default endpoint of the
+	// client type fails.
+	CardanoSubmitUrls []string `toml:"cardano_submit_urls" json:"cardano_submit_urls"`
+	// Native assets accepted by the vault. Each entry is either a policy id (all assets of the
+	// policy), a policy id and hex asset name joined by "." or "*" for all assets. No native asset is
+	// accepted if empty.
+	CardanoAssetWhitelist []string `toml:"cardano_asset_whitelist" json:"cardano_asset_whitelist"`
 
 	// Solana
 	SolanaBridgeProgramId string `toml:"solana_bridge_program_id" json:"solana_bridge_program_id"`
+	// Accounts written by the instructions of the bridge program. Their recent prioritization fees
+	// are used to estimate the priority fee of bridge transactions.
+	SolanaBridgeWritableAccounts []string `toml:"solana_bridge_writable_accounts" json:"solana_bridge_writable_accounts"`
+	// Commitment level used to fetch blocks, either "confirmed" or "finalized" (default). With
+	// "confirmed", transactions are held until their blocks are finalized.
+	SolanaCommitment string `toml:"solana_commitment" json:"solana_commitment"`
+
+	// Lisk
+	// Version of the Lisk core: "v3" (default, Lisk Service v2) or "v4" (Lisk SDK 6, Lisk Service
+	// v3).
+	LiskVersion string `toml:"lisk_version" json:"lisk_version"`
 }
 
 type Token struct {
 	Symbol        string `toml:"symbol"`
 	CoincapName   string `toml:"coin_cap_name"`
 	CoinGeckoName string `toml:"coin_gecko_name"`
+
+	// DEX pool used by the "dex" price provider. The quote token of the pool must be pegged to USD.
+	DexChain string `toml:"dex_chain"`
+	DexPool  string `toml:"dex_pool"`
+	// Type of the pool: "uniswap_v3" (default) or "uniswap_v2".
+	DexPoolType      string `toml:"dex_pool_type"`
+	DexQuoteToken    string `toml:"dex_quote_token"`
+	DexBaseDecimals  int    `toml:"dex_base_decimals"`
+	DexQuoteDecimals int    `toml:"dex_quote_decimals"`
+	// Window of the time-weighted average price in seconds. Only used by Uniswap V3 pools.
+	DexTwapWindow int `toml:"dex_twap_window"`
+
+	// Chainlink USD feed used by the "chainlink" price provider.
+	ChainlinkChain string `toml:"chainlink_chain"`
+	ChainlinkFeed  string `toml:"chainlink_feed"`
+	// Max age of the latest round in seconds, which should be above the heartbeat of the feed.
+	ChainlinkMaxAge int `toml:"chainlink_max_age"`
 }
 
 type PriceProvider struct {
-	Url     string `toml:"url"`
+	Url string `toml:"url"`
+	// Comma separated API keys. The next key is used when the provider rejects a key or rate
+	// limits it.
 	Secrets string `toml:"secrets"`
+	// Timeout of a call to the provider in seconds.
+	Timeout int `toml:"timeout"`
+	// Max number of requests per minute allowed by the plan of the provider. 0 means no limit.
+	RequestsPerMinute int `toml:"requests_per_minute"`
+}
+
+// PriceOracle contains the checks of the prices returned by the price providers. A check with a
+// zero value is disabled.
+type PriceOracle struct {
+	// Min number of providers that return a price.
+	MinProviders int `toml:"min_providers"`
+	// Max spread between the lowest and the highest price of the providers, relative to the median
+	// (e.g. 0.05 for 5%).
+	MaxSpread float64 `toml:"max_spread"`
+	// Max change of a price versus the last accepted price (e.g. 0.2 for 20%).
+	MaxChange float64 `toml:"max_change"`
 }
 
 type Deyes struct {
@@ -63,7 +121,10 @@ type Deyes struct {
 
 	PriceProviders map[string]PriceProvider `toml:"price_providers"`
 	Tokens         map[string]Token         `toml:"tokens"`
+	PriceOracle    PriceOracle              `toml:"price_oracle"`
 
+	// Port of the JSON-RPC server used by Sisu. The server has no authentication and exposes
+	// admin methods like resetting a price circuit, so the port must not be public.
 	ServerPort    int    `toml:"server_port"`
 	SisuServerUrl string `toml:"sisu_server_url"`
 
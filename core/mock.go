@@ -9,6 +9,7 @@ type MockClient struct {
 	TryDialFunc              func()
 	PingFunc                 func(source string) error
 	BroadcastTxsFunc         func(txs *types.Txs) error
+	PostRevertedTxsFunc      func(txs *types.RevertedTxs) error
 	PostDeploymentResultFunc func(result *types.DispatchedTxResult) error
 	UpdateTokenPricesFunc    func(prices []*types.TokenPrice) error
 	OnTxIncludedInBlockFunc  func(txTrack *chainstypes.TrackUpdate) error
@@ -36,6 +37,14 @@ func (c *MockClient) BroadcastTxs(txs *types.Txs) error {
 	return nil
 }
 
+func (c *MockClient) PostRevertedTxs(txs *types.RevertedTxs) error {
+	if c.PostRevertedTxsFunc != nil {
+		return c.PostRevertedTxsFunc(txs)
+	}
+
+	return nil
+}
+
 func (c *MockClient) PostDeploymentResult(result *types.DispatchedTxResult) error {
 	if c.PostDeploymentResultFunc != nil {
 		return c.PostDeploymentResultFunc(result)
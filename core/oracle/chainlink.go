@@ -0,0 +1,103 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	chainseth "github.com/sisu-network/deyes/chains/eth"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/utils"
+)
+
+// DefaultChainlinkMaxAge is the default max age of the latest round of a feed in seconds. Most USD
+// feeds have a heartbeat of 1 hour or 24 hours.
+const DefaultChainlinkMaxAge = 25 * 60 * 60
+
+// The functions of AggregatorV3Interface used by the provider.
+const chainlinkAggregatorAbi = `[
+	{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"name":"latestRoundData","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]}
+]`
+
+// ChainlinkProvider reads the USD price of a token from a Chainlink aggregator.
+type ChainlinkProvider struct {
+	clients *ethClients
+	abi     abi.ABI
+	now     func() time.Time
+}
+
+func NewChainlinkProvider(chains map[string]config.Chain) Provider {
+	return newChainlinkProvider(chains, make(map[string]chainseth.EthClient))
+}
+
+func newChainlinkProvider(chains map[string]config.Chain, clients map[string]chainseth.EthClient) *ChainlinkProvider {
+	aggregatorAbi, err := abi.JSON(strings.NewReader(chainlinkAggregatorAbi))
+	if err != nil {
+		panic(err)
+	}
+
+	return &ChainlinkProvider{
+		clients: newEthClients(chains, clients),
+		abi:     aggregatorAbi,
+		now:     time.Now,
+	}
+}
+
+func (p *ChainlinkProvider) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
+	if token.ChainlinkFeed == "" {
+		return nil, fmt.Errorf("Empty chainlink feed, symbol = %s", token.Symbol)
+	}
+
+	client, err := p.clients.get(token.ChainlinkChain)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := common.HexToAddress(token.ChainlinkFeed)
+	outputs, err := callContract(ctx, client, p.abi, feed, "decimals")
+	if err != nil {
+		return nil, err
+	}
+	decimals, ok := outputs[0].(uint8)
+	if !ok {
+		return nil, fmt.Errorf("Invalid decimals of feed %s", token.ChainlinkFeed)
+	}
+
+	outputs, err = callContract(ctx, client, p.abi, feed, "latestRoundData")
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) != 5 {
+		return nil, fmt.Errorf("Invalid latest round of feed %s", token.ChainlinkFeed)
+	}
+	roundId, ok0 := outputs[0].(*big.Int)
+	answer, ok1 := outputs[1].(*big.Int)
+	updatedAt, ok2 := outputs[3].(*big.Int)
+	answeredInRound, ok3 := outputs[4].(*big.Int)
+	if !ok0 || !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("Invalid latest round of feed %s", token.ChainlinkFeed)
+	}
+
+	if answer.Sign() <= 0 {
+		return nil, fmt.Errorf("Invalid answer %s of feed %s", answer, token.ChainlinkFeed)
+	}
+	if answeredInRound.Cmp(roundId) < 0 {
+		return nil, fmt.Errorf("Round %s of feed %s is not answered", roundId, token.ChainlinkFeed)
+	}
+
+	maxAge := token.ChainlinkMaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultChainlinkMaxAge
+	}
+	age := p.now().Unix() - updatedAt.Int64()
+	if updatedAt.Sign() == 0 || age > int64(maxAge) {
+		return nil, fmt.Errorf("Latest round of feed %s is stale, updated at = %s", token.ChainlinkFeed, updatedAt)
+	}
+
+	return utils.ToSisuPrice(answer, int(decimals)), nil
+}
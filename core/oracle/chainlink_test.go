@@ -0,0 +1,68 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	chainseth "github.com/sisu-network/deyes/chains/eth"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var testChainlinkFeed = common.HexToAddress("0x4444444444444444444444444444444444444444")
+
+func newTestChainlinkProvider(t *testing.T, answer int64, updatedAt int64) *ChainlinkProvider {
+	provider := newChainlinkProvider(nil, make(map[string]chainseth.EthClient))
+	provider.clients.clients["eth"] = &chainseth.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+			require.Equal(t, testChainlinkFeed, *msg.To)
+
+			method, err := provider.abi.MethodById(msg.Data)
+			require.Nil(t, err)
+
+			switch method.Name {
+			case "decimals":
+				return method.Outputs.Pack(uint8(8))
+			default:
+				return method.Outputs.Pack(big.NewInt(10), big.NewInt(answer), big.NewInt(updatedAt),
+					big.NewInt(updatedAt), big.NewInt(10))
+			}
+		},
+	}
+	provider.now = func() time.Time {
+		return time.Unix(10_000, 0)
+	}
+
+	return provider
+}
+
+func TestChainlinkProvider(t *testing.T) {
+	token := config.Token{
+		Symbol:          "ETH",
+		ChainlinkChain:  "eth",
+		ChainlinkFeed:   testChainlinkFeed.Hex(),
+		ChainlinkMaxAge: 3600,
+	}
+
+	// 2000.5 USD with 8 decimals.
+	provider := newTestChainlinkProvider(t, 200_050_000_000, 9_000)
+	price, err := provider.GetPrice(context.Background(), token)
+	require.Nil(t, err)
+	expected := new(big.Int).Mul(big.NewInt(20_005), big.NewInt(utils.SisuUnit/10))
+	require.Equal(t, expected, price)
+
+	// The latest round is older than the max age.
+	provider = newTestChainlinkProvider(t, 200_050_000_000, 6_000)
+	_, err = provider.GetPrice(context.Background(), token)
+	require.NotNil(t, err)
+
+	// Negative answer.
+	provider = newTestChainlinkProvider(t, -1, 9_000)
+	_, err = provider.GetPrice(context.Background(), token)
+	require.NotNil(t, err)
+}
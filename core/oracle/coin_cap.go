@@ -1,13 +1,12 @@
 package oracle
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/network"
@@ -17,43 +16,63 @@ import (
 type CoinCapProvider struct {
 	providerCfg config.PriceProvider
 	networkHttp network.Http
+	secrets     *secretRing
 }
 
 func NewCoinCapProvider(networkHttp network.Http, providerCfg config.PriceProvider) Provider {
 	return &CoinCapProvider{
 		networkHttp: networkHttp,
 		providerCfg: providerCfg,
+		secrets:     newSecretRing(providerCfg.Secrets),
 	}
 }
 
-func (p *CoinCapProvider) GetPrice(token config.Token) (*big.Int, error) {
+func (p *CoinCapProvider) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
 	if token.CoincapName == "" {
 		return nil, fmt.Errorf("Empty token lowercase name in coin cap, symbol = %s", token.Symbol)
 	}
 
-	baseUrl := fmt.Sprintf("%s/%s", p.providerCfg.Url, token.CoincapName)
+	type Response struct {
+		Data struct {
+			PriceUsd string `json:"priceUsd"`
+		} `json:"data"`
+	}
 
-	req, err := http.NewRequest("GET", baseUrl, nil)
+	data, err := p.get(ctx, fmt.Sprintf("%s/%s", p.providerCfg.Url, token.CoincapName))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	secret := p.randomSecret()
-	if len(secret) == 0 {
-		return nil, fmt.Errorf("Invalid secret %s", p.providerCfg.Secrets)
+	response := &Response{}
+	err = json.Unmarshal(data, &response)
+	if err != nil {
+		return nil, err
 	}
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", secret))
 
-	q := req.URL.Query()
-	req.URL.RawQuery = q.Encode()
+	return utils.UsdToSisuPrice(response.Data.PriceUsd)
+}
+
+func (p *CoinCapProvider) GetPrices(ctx context.Context, tokens []config.Token) (map[string]*big.Int, error) {
+	names := make([]string, 0, len(tokens))
+	symbols := make(map[string]string)
+	for _, token := range tokens {
+		if token.CoincapName != "" {
+			names = append(names, token.CoincapName)
+			symbols[token.CoincapName] = token.Symbol
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("No token has a coin cap name")
+	}
 
 	type Response struct {
-		Data struct {
+		Data []struct {
+			Id       string `json:"id"`
 			PriceUsd string `json:"priceUsd"`
 		} `json:"data"`
 	}
 
-	data, err := p.networkHttp.Get(req)
+	data, err := p.get(ctx, fmt.Sprintf("%s?ids=%s", p.providerCfg.Url, strings.Join(names, ",")))
 	if err != nil {
 		return nil, err
 	}
@@ -64,15 +83,35 @@ func (p *CoinCapProvider) GetPrice(token config.Token) (*big.Int, error) {
 		return nil, err
 	}
 
-	return utils.UsdToSisuPrice(response.Data.PriceUsd)
+	prices := make(map[string]*big.Int)
+	for _, asset := range response.Data {
+		symbol, ok := symbols[asset.Id]
+		if !ok {
+			continue
+		}
+
+		price, err := utils.UsdToSisuPrice(asset.PriceUsd)
+		if err != nil {
+			return nil, err
+		}
+		prices[symbol] = price
+	}
+
+	return prices, nil
 }
 
-func (p *CoinCapProvider) randomSecret() string {
-	secrets := strings.Split(p.providerCfg.Secrets, ",")
-	if len(secrets) == 0 {
-		return ""
+func (p *CoinCapProvider) get(ctx context.Context, url string) ([]byte, error) {
+	if p.secrets.size() == 0 {
+		return nil, fmt.Errorf("Invalid secret %s", p.providerCfg.Secrets)
 	}
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r1 := rand.New(s1)
-	return secrets[r1.Intn(len(secrets))]
+
+	return p.secrets.do(func(secret string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", secret))
+
+		return p.networkHttp.Get(req)
+	})
 }
@@ -1,13 +1,12 @@
 package oracle
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/network"
@@ -17,33 +16,54 @@ import (
 type CoinMarketCap struct {
 	providerCfg config.PriceProvider
 	networkHttp network.Http
+	secrets     *secretRing
 }
 
 func NewCoinMarketCap(networkHttp network.Http, providerCfg config.PriceProvider) Provider {
 	return &CoinMarketCap{
 		networkHttp: networkHttp,
 		providerCfg: providerCfg,
+		secrets:     newSecretRing(providerCfg.Secrets),
 	}
 }
 
-func (p *CoinMarketCap) GetPrice(token config.Token) (*big.Int, error) {
-	baseUrl := p.providerCfg.Url
-	req, err := http.NewRequest("GET", baseUrl, nil)
+func (p *CoinMarketCap) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
+	prices, err := p.GetPrices(ctx, []config.Token{token})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	secret := p.randomSecret()
-	if len(secret) == 0 {
+	price, ok := prices[token.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("Token %s not found in the response", token.Symbol)
+	}
+
+	return price, nil
+}
+
+func (p *CoinMarketCap) GetPrices(ctx context.Context, tokens []config.Token) (map[string]*big.Int, error) {
+	if p.secrets.size() == 0 {
 		return nil, fmt.Errorf("Invalid secret %s", p.providerCfg.Secrets)
 	}
-	req.Header.Add("X-CMC_PRO_API_KEY", secret)
 
-	q := req.URL.Query()
-	q.Add("symbol", token.Symbol)
-	req.URL.RawQuery = q.Encode()
+	symbols := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		symbols = append(symbols, token.Symbol)
+	}
+
+	data, err := p.secrets.do(func(secret string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", p.providerCfg.Url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Add("X-CMC_PRO_API_KEY", secret)
 
-	data, err := p.networkHttp.Get(req)
+		q := req.URL.Query()
+		q.Add("symbol", strings.Join(symbols, ","))
+		req.URL.RawQuery = q.Encode()
+
+		return p.networkHttp.Get(req)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -64,22 +84,15 @@ func (p *CoinMarketCap) GetPrice(token config.Token) (*big.Int, error) {
 		return nil, err
 	}
 
-	tokenPrice, ok := response.Data[token.Symbol]
-	if !ok {
-		return nil, fmt.Errorf("Token %s not found in the response %s", token.Symbol, string(data))
-	}
-
-	price := utils.FloatToWei(tokenPrice.Quote.Usd.Value)
+	prices := make(map[string]*big.Int)
+	for _, symbol := range symbols {
+		tokenPrice, ok := response.Data[symbol]
+		if !ok {
+			continue
+		}
 
-	return price, nil
-}
-
-func (p *CoinMarketCap) randomSecret() string {
-	secrets := strings.Split(p.providerCfg.Secrets, ",")
-	if len(secrets) == 0 {
-		return ""
+		prices[symbol] = utils.FloatToWei(tokenPrice.Quote.Usd.Value)
 	}
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r1 := rand.New(s1)
-	return secrets[r1.Intn(len(secrets))]
+
+	return prices, nil
 }
@@ -1,10 +1,10 @@
 package oracle
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
-	"math/rand"
 	"net/http"
 	"strings"
 
@@ -16,53 +16,88 @@ import (
 type CoingeckoProvider struct {
 	providerCfg config.PriceProvider
 	networkHttp network.Http
+	secrets     *secretRing
 }
 
 func NewCoingeckoProvider(networkHttp network.Http, providerCfg config.PriceProvider) Provider {
 	return &CoingeckoProvider{
 		networkHttp: networkHttp,
 		providerCfg: providerCfg,
+		secrets:     newSecretRing(providerCfg.Secrets),
 	}
 }
 
-func (p *CoingeckoProvider) GetPrice(token config.Token) (*big.Int, error) {
-	coinId := token.CoinGeckoName
-	if coinId == "" {
+func (p *CoingeckoProvider) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
+	if token.CoinGeckoName == "" {
 		return nil, fmt.Errorf("Empty token lowercase name in coin cap, symbol = %s", token.Symbol)
 	}
 
-	baseUrl := fmt.Sprintf("%s?ids=%s&vs_currencies=usd", p.providerCfg.Url, coinId)
-	req, err := http.NewRequest("GET", baseUrl, nil)
+	prices, err := p.GetPrices(ctx, []config.Token{token})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	q := req.URL.Query()
-	req.URL.RawQuery = q.Encode()
+	price, ok := prices[token.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("Token %s not found in the response", token.CoinGeckoName)
+	}
 
-	type Response struct {
-		USD float32 `json:"usd"`
+	return price, nil
+}
+
+func (p *CoingeckoProvider) GetPrices(ctx context.Context, tokens []config.Token) (map[string]*big.Int, error) {
+	coinIds := make([]string, 0, len(tokens))
+	symbols := make(map[string]string)
+	for _, token := range tokens {
+		if token.CoinGeckoName != "" {
+			coinIds = append(coinIds, token.CoinGeckoName)
+			symbols[token.CoinGeckoName] = token.Symbol
+		}
+	}
+	if len(coinIds) == 0 {
+		return nil, fmt.Errorf("No token has a coingecko name")
 	}
 
-	data, err := p.networkHttp.Get(req)
+	baseUrl := fmt.Sprintf("%s?ids=%s&vs_currencies=usd", p.providerCfg.Url, strings.Join(coinIds, ","))
+	// The public API does not need a key.
+	data, err := p.secrets.do(func(secret string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", baseUrl, nil)
+		if err != nil {
+			return nil, err
+		}
+		if secret != "" {
+			req.Header.Set("x-cg-pro-api-key", secret)
+		}
+
+		return p.networkHttp.Get(req)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	type Response struct {
+		USD float32 `json:"usd"`
+	}
+
 	response := map[string]Response{}
 	err = json.Unmarshal(data, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	return utils.UsdToSisuPrice(fmt.Sprintf("%f", response[coinId].USD))
-}
+	prices := make(map[string]*big.Int)
+	for coinId, symbol := range symbols {
+		tokenPrice, ok := response[coinId]
+		if !ok {
+			continue
+		}
 
-func (p *CoingeckoProvider) randomSecret() string {
-	secrets := strings.Split(p.providerCfg.Secrets, ",")
-	if len(secrets) == 0 {
-		return ""
+		price, err := utils.UsdToSisuPrice(fmt.Sprintf("%f", tokenPrice.USD))
+		if err != nil {
+			return nil, err
+		}
+		prices[symbol] = price
 	}
 
-	return secrets[rand.Intn(len(secrets))]
+	return prices, nil
 }
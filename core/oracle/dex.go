@@ -0,0 +1,194 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	chainseth "github.com/sisu-network/deyes/chains/eth"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/utils"
+)
+
+const (
+	DexPoolUniswapV3 = "uniswap_v3"
+	DexPoolUniswapV2 = "uniswap_v2"
+
+	// DefaultTwapWindow is the default window of the time-weighted average price in seconds.
+	DefaultTwapWindow = 1800
+)
+
+// The functions of Uniswap V2 and V3 pools used by the provider.
+const dexPoolAbi = `[
+	{"name":"token0","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"name":"token1","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"name":"observe","type":"function","stateMutability":"view","inputs":[{"name":"secondsAgos","type":"uint32[]"}],"outputs":[{"name":"tickCumulatives","type":"int56[]"},{"name":"secondsPerLiquidityCumulativeX128s","type":"uint160[]"}]},
+	{"name":"getReserves","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}]}
+]`
+
+// DexProvider reads the price of a token from a DEX pool with a token pegged to USD. It uses the
+// time-weighted average price of Uniswap V3 pools, which is resistant to manipulation within a
+// block, or the reserves of Uniswap V2 pools.
+type DexProvider struct {
+	clients *ethClients
+	abi     abi.ABI
+}
+
+func NewDexProvider(chains map[string]config.Chain) Provider {
+	return newDexProvider(chains, make(map[string]chainseth.EthClient))
+}
+
+func newDexProvider(chains map[string]config.Chain, clients map[string]chainseth.EthClient) *DexProvider {
+	poolAbi, err := abi.JSON(strings.NewReader(dexPoolAbi))
+	if err != nil {
+		panic(err)
+	}
+
+	return &DexProvider{
+		clients: newEthClients(chains, clients),
+		abi:     poolAbi,
+	}
+}
+
+func (p *DexProvider) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
+	if token.DexPool == "" || token.DexQuoteToken == "" {
+		return nil, fmt.Errorf("Empty dex pool or quote token, symbol = %s", token.Symbol)
+	}
+
+	client, err := p.clients.get(token.DexChain)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := common.HexToAddress(token.DexPool)
+	token0, err := p.callAddress(ctx, client, pool, "token0")
+	if err != nil {
+		return nil, err
+	}
+	token1, err := p.callAddress(ctx, client, pool, "token1")
+	if err != nil {
+		return nil, err
+	}
+
+	quote := common.HexToAddress(token.DexQuoteToken)
+	if quote != token0 && quote != token1 {
+		return nil, fmt.Errorf("Quote token %s is not in pool %s", token.DexQuoteToken, token.DexPool)
+	}
+	baseIsToken0 := quote == token1
+
+	// price is the amount of the quote token in its smallest unit for one base token.
+	var price *big.Int
+	switch token.DexPoolType {
+	case DexPoolUniswapV2:
+		price, err = p.getV2Price(ctx, client, pool, baseIsToken0, token.DexBaseDecimals)
+	case DexPoolUniswapV3, "":
+		price, err = p.getV3Price(ctx, client, pool, baseIsToken0, token.DexBaseDecimals, token.DexTwapWindow)
+	default:
+		err = fmt.Errorf("Unknown dex pool type %s", token.DexPoolType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ToSisuPrice(price, token.DexQuoteDecimals), nil
+}
+
+// getV3Price returns the price from the time-weighted average tick of a Uniswap V3 pool over the
+// window.
+func (p *DexProvider) getV3Price(ctx context.Context, client chainseth.EthClient, pool common.Address,
+	baseIsToken0 bool, baseDecimals int, window int) (*big.Int, error) {
+	if window <= 0 {
+		window = DefaultTwapWindow
+	}
+
+	outputs, err := p.call(ctx, client, pool, "observe", []uint32{uint32(window), 0})
+	if err != nil {
+		return nil, err
+	}
+
+	tickCumulatives, ok := outputs[0].([]*big.Int)
+	if !ok || len(tickCumulatives) != 2 {
+		return nil, fmt.Errorf("Invalid observe result of pool %s", pool)
+	}
+
+	return twapPrice(tickCumulatives[0], tickCumulatives[1], window, baseIsToken0, baseDecimals), nil
+}
+
+// twapPrice converts the tick cumulatives at the start and the end of a window to the amount of
+// quote token (in its smallest unit) for one base token. The price of token0 in token1 at a tick
+// is 1.0001^tick.
+func twapPrice(start, end *big.Int, window int, baseIsToken0 bool, baseDecimals int) *big.Int {
+	delta := new(big.Int).Sub(end, start)
+	windowInt := big.NewInt(int64(window))
+	tick := new(big.Int).Quo(delta, windowInt)
+	// Round to negative infinity like the Uniswap oracle library.
+	if delta.Sign() < 0 && new(big.Int).Rem(delta, windowInt).Sign() != 0 {
+		tick.Sub(tick, big.NewInt(1))
+	}
+
+	exp := float64(tick.Int64())
+	if !baseIsToken0 {
+		exp = -exp
+	}
+
+	price := new(big.Float).SetFloat64(math.Pow(1.0001, exp))
+	price.Mul(price, new(big.Float).SetInt(pow10(baseDecimals)))
+
+	ret, _ := price.Int(nil)
+	return ret
+}
+
+// getV2Price returns the spot price from the reserves of a Uniswap V2 pool.
+func (p *DexProvider) getV2Price(ctx context.Context, client chainseth.EthClient, pool common.Address,
+	baseIsToken0 bool, baseDecimals int) (*big.Int, error) {
+	outputs, err := p.call(ctx, client, pool, "getReserves")
+	if err != nil {
+		return nil, err
+	}
+
+	reserve0, ok0 := outputs[0].(*big.Int)
+	reserve1, ok1 := outputs[1].(*big.Int)
+	if !ok0 || !ok1 {
+		return nil, fmt.Errorf("Invalid reserves of pool %s", pool)
+	}
+
+	baseReserve, quoteReserve := reserve0, reserve1
+	if !baseIsToken0 {
+		baseReserve, quoteReserve = reserve1, reserve0
+	}
+
+	if baseReserve.Sign() == 0 {
+		return nil, fmt.Errorf("Empty reserve in pool %s", pool)
+	}
+
+	price := new(big.Int).Mul(quoteReserve, pow10(baseDecimals))
+	return price.Div(price, baseReserve), nil
+}
+
+func (p *DexProvider) callAddress(ctx context.Context, client chainseth.EthClient, pool common.Address,
+	method string) (common.Address, error) {
+	outputs, err := p.call(ctx, client, pool, method)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	address, ok := outputs[0].(common.Address)
+	if !ok {
+		return common.Address{}, fmt.Errorf("Invalid result of %s of pool %s", method, pool)
+	}
+
+	return address, nil
+}
+
+func (p *DexProvider) call(ctx context.Context, client chainseth.EthClient, pool common.Address, method string,
+	args ...interface{}) ([]interface{}, error) {
+	return callContract(ctx, client, p.abi, pool, method, args...)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,111 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	chainseth "github.com/sisu-network/deyes/chains/eth"
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/utils"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testDexPool   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	testDexToken0 = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	testDexToken1 = common.HexToAddress("0x3333333333333333333333333333333333333333")
+)
+
+// newTestDexProvider returns a provider whose pool returns the given outputs for each method.
+func newTestDexProvider(t *testing.T, outputs map[string][]interface{}) *DexProvider {
+	provider := newDexProvider(nil, make(map[string]chainseth.EthClient))
+	provider.clients.clients["eth"] = &chainseth.MockEthClient{
+		CallContractFunc: func(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+			require.Equal(t, testDexPool, *msg.To)
+
+			method, err := provider.abi.MethodById(msg.Data)
+			require.Nil(t, err)
+
+			return method.Outputs.Pack(outputs[method.Name]...)
+		},
+	}
+
+	return provider
+}
+
+func TestDexProvider_UniswapV3(t *testing.T) {
+	provider := newTestDexProvider(t, map[string][]interface{}{
+		"token0": {testDexToken0},
+		"token1": {testDexToken1},
+		// The average tick over the window is 0, a price of 1 raw token1 for 1 raw token0.
+		"observe": {
+			[]*big.Int{big.NewInt(1000), big.NewInt(1000)},
+			[]*big.Int{big.NewInt(0), big.NewInt(0)},
+		},
+	})
+
+	token := config.Token{
+		Symbol:           "USDT",
+		DexChain:         "eth",
+		DexPool:          testDexPool.Hex(),
+		DexQuoteToken:    testDexToken1.Hex(),
+		DexBaseDecimals:  6,
+		DexQuoteDecimals: 6,
+	}
+	price, err := provider.GetPrice(context.Background(), token)
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(utils.SisuUnit), price)
+
+	// A quote token that is not in the pool is rejected.
+	token.DexQuoteToken = testDexPool.Hex()
+	_, err = provider.GetPrice(context.Background(), token)
+	require.NotNil(t, err)
+}
+
+func TestDexProvider_UniswapV2(t *testing.T) {
+	ethReserve, _ := new(big.Int).SetString("10000000000000000000", 10)
+	provider := newTestDexProvider(t, map[string][]interface{}{
+		"token0": {testDexToken0},
+		"token1": {testDexToken1},
+		// 10 ETH and 20,000 USDC.
+		"getReserves": {big.NewInt(20_000_000_000), ethReserve, uint32(0)},
+	})
+
+	price, err := provider.GetPrice(context.Background(), config.Token{
+		Symbol:           "ETH",
+		DexChain:         "eth",
+		DexPool:          testDexPool.Hex(),
+		DexPoolType:      DexPoolUniswapV2,
+		DexQuoteToken:    testDexToken0.Hex(),
+		DexBaseDecimals:  18,
+		DexQuoteDecimals: 6,
+	})
+	require.Nil(t, err)
+	require.Equal(t, new(big.Int).Mul(big.NewInt(2000), big.NewInt(utils.SisuUnit)), price)
+}
+
+func TestDexProvider_UnknownChain(t *testing.T) {
+	provider := newDexProvider(map[string]config.Chain{}, make(map[string]chainseth.EthClient))
+
+	_, err := provider.GetPrice(context.Background(), config.Token{
+		Symbol:        "ETH",
+		DexChain:      "eth",
+		DexPool:       testDexPool.Hex(),
+		DexQuoteToken: testDexToken0.Hex(),
+	})
+	require.NotNil(t, err)
+}
+
+func TestTwapPrice(t *testing.T) {
+	// The average tick is -1.5, which is rounded down to -2.
+	price := twapPrice(big.NewInt(0), big.NewInt(-3), 2, true, 8)
+	require.Equal(t, big.NewInt(99980002), price)
+
+	// The average tick is 1.5, which is rounded down to 1. The base token is token1 so the price is
+	// inverted.
+	price = twapPrice(big.NewInt(0), big.NewInt(3), 2, false, 8)
+	require.Equal(t, big.NewInt(99990000), price)
+}
@@ -0,0 +1,76 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	chainseth "github.com/sisu-network/deyes/chains/eth"
+	"github.com/sisu-network/deyes/config"
+)
+
+// ethClients lazily creates and caches the clients of the EVM chains read by the on-chain price
+// providers.
+type ethClients struct {
+	chains  map[string]config.Chain
+	clients map[string]chainseth.EthClient
+	lock    *sync.Mutex
+}
+
+func newEthClients(chains map[string]config.Chain, clients map[string]chainseth.EthClient) *ethClients {
+	return &ethClients{
+		chains:  chains,
+		clients: clients,
+		lock:    &sync.Mutex{},
+	}
+}
+
+func (c *ethClients) get(chain string) (chainseth.EthClient, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if client, ok := c.clients[chain]; ok {
+		return client, nil
+	}
+
+	cfg, ok := c.chains[chain]
+	if !ok {
+		return nil, fmt.Errorf("Chain %s of the price provider is not configured", chain)
+	}
+
+	client := chainseth.NewEthClients(cfg, false)
+	client.Start()
+	c.clients[chain] = client
+
+	return client, nil
+}
+
+// callContract calls a view method of a contract at the latest block and returns its outputs.
+func callContract(ctx context.Context, client chainseth.EthClient, contractAbi abi.ABI, address common.Address,
+	method string, args ...interface{}) ([]interface{}, error) {
+	input, err := contractAbi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, chainseth.RpcTimeOut)
+	defer cancel()
+
+	bz, err := client.CallContract(ctx, ethereum.CallMsg{To: &address, Data: input}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := contractAbi.Unpack(method, bz)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("Empty result of %s of contract %s", method, address)
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,118 @@
+package oracle
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/lib/log"
+)
+
+type PriceErrCode int
+
+const (
+	// Not enough providers returned a price.
+	PriceErrQuorum PriceErrCode = iota + 1
+	// The prices of the providers are too far from each other.
+	PriceErrSpread
+	// The price changed too much versus the last accepted price.
+	PriceErrDeviation
+)
+
+// PriceErr is returned when the prices of the providers fail the checks of the oracle.
+type PriceErr struct {
+	Code    PriceErrCode
+	Token   string
+	message string
+}
+
+func NewPriceErr(code PriceErrCode, token string, message string) error {
+	return &PriceErr{Code: code, Token: token, message: message}
+}
+
+func (e *PriceErr) Error() string {
+	return fmt.Sprintf("price of token %s is rejected: %s", e.Token, e.message)
+}
+
+// IsPriceErrCode returns true if err is a PriceErr with the given code.
+func IsPriceErrCode(err error, code PriceErrCode) bool {
+	priceErr, ok := err.(*PriceErr)
+	return ok && priceErr.Code == code
+}
+
+// checkPrices returns the median of the provider prices if they pass the quorum and spread checks
+// and the median does not deviate too much from the last accepted price. last is nil if there is
+// no recent accepted price.
+func checkPrices(cfg config.PriceOracle, token string, prices map[string]*big.Int, last *big.Int) (*big.Int, error) {
+	values := make([]*big.Int, 0, len(prices))
+	for _, price := range prices {
+		values = append(values, price)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+
+	minProviders := cfg.MinProviders
+	if minProviders < 1 {
+		minProviders = 1
+	}
+	if len(values) < minProviders {
+		err := NewPriceErr(PriceErrQuorum, token, fmt.Sprintf("%d providers returned a price, min = %d",
+			len(values), minProviders))
+		logRejectedPrices(err, prices)
+		return nil, err
+	}
+
+	median := values[len(values)/2]
+	if cfg.MaxSpread > 0 {
+		spread := new(big.Int).Sub(values[len(values)-1], values[0])
+		if ratio(spread, median) > cfg.MaxSpread {
+			err := NewPriceErr(PriceErrSpread, token, fmt.Sprintf("spread %s is higher than %.4f of median %s",
+				spread, cfg.MaxSpread, median))
+			logRejectedPrices(err, prices)
+			return nil, err
+		}
+	}
+
+	if cfg.MaxChange > 0 && last != nil && last.Sign() > 0 {
+		change := new(big.Int).Sub(median, last)
+		if ratio(change.Abs(change), last) > cfg.MaxChange {
+			err := NewPriceErr(PriceErrDeviation, token, fmt.Sprintf("median %s changes more than %.4f from the last price %s",
+				median, cfg.MaxChange, last))
+			logRejectedPrices(err, prices)
+			return nil, err
+		}
+	}
+
+	return median, nil
+}
+
+// ratio returns a / b.
+func ratio(a, b *big.Int) float64 {
+	if b.Sign() == 0 {
+		if a.Sign() == 0 {
+			return 0
+		}
+		return 1
+	}
+
+	value, _ := new(big.Float).Quo(new(big.Float).SetInt(a), new(big.Float).SetInt(b)).Float64()
+	return value
+}
+
+func logRejectedPrices(err error, prices map[string]*big.Int) {
+	names := make([]string, 0, len(prices))
+	for name := range prices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([]string, 0, len(names))
+	for _, name := range names {
+		values = append(values, fmt.Sprintf("%s = %s", name, prices[name]))
+	}
+
+	log.Errorf("%v. Prices: [%s]", err, strings.Join(values, ", "))
+}
@@ -0,0 +1,45 @@
+package oracle
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPrices(t *testing.T) {
+	cfg := config.PriceOracle{
+		MinProviders: 2,
+		MaxSpread:    0.05,
+		MaxChange:    0.2,
+	}
+
+	prices := map[string]*big.Int{
+		"p1": big.NewInt(1000),
+		"p2": big.NewInt(1010),
+		"p3": big.NewInt(1020),
+	}
+	median, err := checkPrices(cfg, "ETH", prices, big.NewInt(900))
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(1010), median)
+
+	// Quorum
+	_, err = checkPrices(cfg, "ETH", map[string]*big.Int{"p1": big.NewInt(1000)}, nil)
+	require.True(t, IsPriceErrCode(err, PriceErrQuorum))
+
+	// Spread
+	prices["p3"] = big.NewInt(1500)
+	_, err = checkPrices(cfg, "ETH", prices, nil)
+	require.True(t, IsPriceErrCode(err, PriceErrSpread))
+
+	// Deviation from the last accepted price
+	prices["p3"] = big.NewInt(1020)
+	_, err = checkPrices(cfg, "ETH", prices, big.NewInt(800))
+	require.True(t, IsPriceErrCode(err, PriceErrDeviation))
+
+	// Disabled checks
+	median, err = checkPrices(config.PriceOracle{}, "ETH", map[string]*big.Int{"p1": big.NewInt(7)}, big.NewInt(1))
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(7), median)
+}
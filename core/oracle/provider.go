@@ -1,11 +1,20 @@
 package oracle
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/sisu-network/deyes/config"
 )
 
 type Provider interface {
-	GetPrice(token config.Token) (*big.Int, error)
+	GetPrice(ctx context.Context, token config.Token) (*big.Int, error)
+}
+
+// BatchProvider is a provider that fetches the prices of many tokens in one request.
+type BatchProvider interface {
+	Provider
+	// GetPrices returns the prices of the tokens keyed by token symbol. Tokens without a price are
+	// not in the result.
+	GetPrices(ctx context.Context, tokens []config.Token) (map[string]*big.Int, error)
 }
@@ -0,0 +1,145 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/lib/log"
+)
+
+// DefaultProviderTimeout is the default timeout of a call to a price provider in seconds.
+const DefaultProviderTimeout = 10
+
+// providerWrapper runs the calls to a provider with a timeout and within the rate limit of the
+// plan of the provider.
+type providerWrapper struct {
+	name     string
+	provider Provider
+	timeout  time.Duration
+	limiter  *rateLimiter
+}
+
+func newProviderWrapper(name string, provider Provider, cfg config.PriceProvider) *providerWrapper {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+
+	return &providerWrapper{
+		name:     name,
+		provider: provider,
+		timeout:  time.Duration(timeout) * time.Second,
+		limiter:  newRateLimiter(cfg.RequestsPerMinute),
+	}
+}
+
+func (w *providerWrapper) GetPrice(token config.Token) (*big.Int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	if err := w.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("Rate limit of provider %s is reached, err = %v", w.name, err)
+	}
+
+	return w.provider.GetPrice(ctx, token)
+}
+
+// GetPrices returns the prices of the tokens keyed by token symbol. Providers that support it
+// fetch all tokens in one request, other providers are called for each token.
+func (w *providerWrapper) GetPrices(tokens []config.Token) map[string]*big.Int {
+	if batch, ok := w.provider.(BatchProvider); ok && len(tokens) > 1 {
+		ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+		defer cancel()
+
+		if err := w.limiter.Wait(ctx); err != nil {
+			log.Errorf("Rate limit of provider %s is reached, err = %v", w.name, err)
+			return nil
+		}
+
+		prices, err := batch.GetPrices(ctx, tokens)
+		if err != nil {
+			log.Errorf("Failed to get token prices for provider %s, err = %s", w.name, err)
+			return nil
+		}
+
+		return prices
+	}
+
+	prices := make(map[string]*big.Int)
+	for _, token := range tokens {
+		price, err := w.GetPrice(token)
+		if err != nil {
+			log.Errorf("Failed to get token price for provider %s, err = %s", w.name, err)
+			continue
+		}
+
+		prices[token.Symbol] = price
+	}
+
+	return prices
+}
+
+// rateLimiter is a token bucket that allows a number of requests per minute.
+type rateLimiter struct {
+	rate   float64 // Tokens added per second.
+	burst  float64
+	tokens float64
+	last   time.Time
+	lock   *sync.Mutex
+}
+
+// newRateLimiter returns nil, which does not limit requests, if requestsPerMinute is not positive.
+// The bucket holds the requests of 10 seconds so that a refresh of several tokens is not delayed.
+func newRateLimiter(requestsPerMinute int) *rateLimiter {
+	if requestsPerMinute <= 0 {
+		return nil
+	}
+
+	burst := float64(requestsPerMinute) / 6
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   float64(requestsPerMinute) / 60,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+		lock:   &sync.Mutex{},
+	}
+}
+
+// Wait blocks until a request is allowed or the context is done.
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	for {
+		l.lock.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.lock.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBatchProvider struct {
+	mockProvider
+	batchCalls int
+}
+
+func (p *mockBatchProvider) GetPrices(ctx context.Context, tokens []config.Token) (map[string]*big.Int, error) {
+	p.batchCalls++
+	return p.prices, nil
+}
+
+type slowProvider struct{}
+
+func (p *slowProvider) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestProviderWrapper_GetPrices(t *testing.T) {
+	tokens := []config.Token{{Symbol: "ETH"}, {Symbol: "ADA"}}
+
+	// A batch provider is called once for all tokens.
+	batch := &mockBatchProvider{mockProvider: mockProvider{prices: map[string]*big.Int{
+		"ETH": big.NewInt(1800),
+		"ADA": big.NewInt(3),
+	}}}
+	prices := newProviderWrapper("batch", batch, config.PriceProvider{}).GetPrices(tokens)
+	require.Equal(t, 1, batch.batchCalls)
+	require.Equal(t, 2, len(prices))
+
+	// Other providers are called for each token. Tokens without a price are skipped.
+	provider := &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1800)}}
+	prices = newProviderWrapper("single", provider, config.PriceProvider{}).GetPrices(tokens)
+	require.Equal(t, map[string]*big.Int{"ETH": big.NewInt(1800)}, prices)
+}
+
+func TestProviderWrapper_Timeout(t *testing.T) {
+	wrapper := newProviderWrapper("slow", &slowProvider{}, config.PriceProvider{})
+	wrapper.timeout = 10 * time.Millisecond
+
+	_, err := wrapper.GetPrice(config.Token{Symbol: "ETH"})
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestRateLimiter(t *testing.T) {
+	require.Nil(t, newRateLimiter(0))
+
+	// 60 requests per minute allows a burst of 10 requests, then one request per second.
+	limiter := newRateLimiter(60)
+	for i := 0; i < 10; i++ {
+		require.Nil(t, limiter.Wait(context.Background()))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, limiter.Wait(ctx))
+}
@@ -0,0 +1,91 @@
+package oracle
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sisu-network/deyes/network"
+	"github.com/sisu-network/lib/log"
+)
+
+// secretRing holds the API keys of a provider. The current key is used until the provider rejects
+// it or rate limits it, then the next key is used.
+type secretRing struct {
+	secrets []string
+	index   int
+	lock    *sync.Mutex
+}
+
+func newSecretRing(secrets string) *secretRing {
+	ring := &secretRing{
+		secrets: make([]string, 0),
+		lock:    &sync.Mutex{},
+	}
+	for _, secret := range strings.Split(secrets, ",") {
+		secret = strings.TrimSpace(secret)
+		if secret != "" {
+			ring.secrets = append(ring.secrets, secret)
+		}
+	}
+
+	return ring
+}
+
+func (r *secretRing) size() int {
+	return len(r.secrets)
+}
+
+func (r *secretRing) current() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.secrets) == 0 {
+		return ""
+	}
+
+	return r.secrets[r.index]
+}
+
+// rotate moves to the next key if the rejected secret is still the current key. Concurrent
+// requests rejected with the same key rotate only once.
+func (r *secretRing) rotate(secret string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if len(r.secrets) == 0 || r.secrets[r.index] != secret {
+		return
+	}
+
+	r.index = (r.index + 1) % len(r.secrets)
+}
+
+// do calls get with the current key. If the provider responds with 401 or 429, the key is rotated
+// and get is called again with the next key until all keys have been tried.
+func (r *secretRing) do(get func(secret string) ([]byte, error)) ([]byte, error) {
+	attempts := len(r.secrets)
+	if attempts == 0 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		secret := r.current()
+
+		var data []byte
+		data, err = get(secret)
+		if err == nil {
+			return data, nil
+		}
+
+		statusCode := network.GetStatusCode(err)
+		if statusCode != http.StatusUnauthorized && statusCode != http.StatusTooManyRequests {
+			return nil, err
+		}
+
+		log.Warnf("Price provider rejects a key with status %d, rotating the key", statusCode)
+		r.rotate(secret)
+	}
+
+	return nil, err
+}
@@ -0,0 +1,52 @@
+package oracle
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/network"
+	"github.com/sisu-network/deyes/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretRing_Rotate(t *testing.T) {
+	rejected := map[string]int{
+		"key1": http.StatusTooManyRequests,
+		"key2": http.StatusUnauthorized,
+	}
+	usedKeys := make([]string, 0)
+	networkHttp := &network.MockHttp{
+		GetFunc: func(req *http.Request) ([]byte, error) {
+			key := req.Header.Get("X-CMC_PRO_API_KEY")
+			usedKeys = append(usedKeys, key)
+			if statusCode, ok := rejected[key]; ok {
+				return nil, network.NewHttpStatusErr(statusCode, "")
+			}
+
+			return []byte(`{"data":{"ETH":{"quote":{"USD":{"price":1800}}},"ADA":{"quote":{"USD":{"price":3}}}}}`), nil
+		},
+	}
+
+	provider := NewCoinMarketCap(networkHttp, config.PriceProvider{Secrets: "key1, key2,key3"}).(*CoinMarketCap)
+	prices, err := provider.GetPrices(context.Background(), []config.Token{{Symbol: "ETH"}, {Symbol: "ADA"}})
+	require.Nil(t, err)
+	require.Equal(t, []string{"key1", "key2", "key3"}, usedKeys)
+	require.Equal(t, new(big.Int).Mul(big.NewInt(1800), big.NewInt(utils.SisuUnit)), prices["ETH"])
+	require.Equal(t, new(big.Int).Mul(big.NewInt(3), big.NewInt(utils.SisuUnit)), prices["ADA"])
+
+	// The working key is used for the next requests.
+	usedKeys = usedKeys[:0]
+	_, err = provider.GetPrice(context.Background(), config.Token{Symbol: "ETH"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"key3"}, usedKeys)
+
+	// Other errors do not rotate the key.
+	rejected["key3"] = http.StatusInternalServerError
+	usedKeys = usedKeys[:0]
+	_, err = provider.GetPrice(context.Background(), config.Token{Symbol: "ETH"})
+	require.NotNil(t, err)
+	require.Equal(t, []string{"key3"}, usedKeys)
+}
@@ -3,17 +3,20 @@ package oracle
 import (
 	"fmt"
 	"math/big"
-	"sort"
 	"sync"
 	"time"
 
 	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
 	"github.com/sisu-network/deyes/network"
+	"github.com/sisu-network/deyes/types"
 	"github.com/sisu-network/lib/log"
 )
 
 const (
 	UpdateFrequency = 1000 * 60 * 60 // 1 hour
+	// MaxPriceAge is the max age of a price served when all providers fail.
+	MaxPriceAge = 1000 * 60 * 60 * 6 // 6 hours
 )
 
 type priceCache struct {
@@ -23,125 +26,324 @@ type priceCache struct {
 }
 
 type TokenPriceManager interface {
+	// Start refreshes the prices of all tokens in the background. Prices that change are sent to
+	// priceUpdateCh.
+	Start(priceUpdateCh chan []*types.TokenPrice)
+	// GetPrice returns the last price of a token fetched in the background. It returns a PriceErr
+	// if the prices of the providers fail the checks of the oracle.
 	GetPrice(id string) (*big.Int, error)
+	// ResetCircuit closes the circuit of a token and accepts the current price of the providers as
+	// the new reference price, without checking its change versus the last accepted price. The
+	// caller is logged.
+	ResetCircuit(id string, caller string) (*big.Int, error)
 }
 
 type defaultTokenPriceManager struct {
 	priceProviderCfgs map[string]config.PriceProvider
 	networkHttp       network.Http
+	db                database.Database
 	cache             *sync.Map
 	updateFrequency   int64
-	providers         map[string]Provider
+	maxPriceAge       int64
+	providers         map[string]*providerWrapper
 	tokens            map[string]config.Token
-}
+	oracleCfg         config.PriceOracle
+	circuits          *sync.Map
+	priceUpdateCh     chan []*types.TokenPrice
 
-func NewTokenPriceManager(providerCfgs map[string]config.PriceProvider,
-	tokens map[string]config.Token, networkHttp network.Http) TokenPriceManager {
+	// Last prices sent to Sisu. Only accessed by the refresh goroutine.
+	pushedPrices map[string]*big.Int
+	// Errors of the last refresh by token id.
+	refreshErrs *sync.Map
+}
 
-	providers := make(map[string]Provider)
-	for name, providerCfg := range providerCfgs {
+func NewTokenPriceManager(cfg *config.Deyes, networkHttp network.Http, db database.Database) TokenPriceManager {
+	providers := make(map[string]*providerWrapper)
+	for name, providerCfg := range cfg.PriceProviders {
+		var provider Provider
 		switch name {
 		case "coin_cap":
-			provider := NewCoinCapProvider(networkHttp, providerCfg)
-			providers[name] = provider
+			provider = NewCoinCapProvider(networkHttp, providerCfg)
 
 		case "coin_market_cap":
-			provider := NewCoinMarketCap(networkHttp, providerCfg)
-			providers[name] = provider
+			provider = NewCoinMarketCap(networkHttp, providerCfg)
 
 		case "coingecko":
-			provider := NewCoingeckoProvider(networkHttp, providerCfg)
-			providers[name] = provider
+			provider = NewCoingeckoProvider(networkHttp, providerCfg)
+
+		case "dex":
+			provider = NewDexProvider(cfg.Chains)
+
+		case "chainlink":
+			provider = NewChainlinkProvider(cfg.Chains)
 
 		default:
 			log.Errorf("Unknown price provider %s", name)
+			continue
 		}
+
+		providers[name] = newProviderWrapper(name, provider, providerCfg)
 	}
 
 	return &defaultTokenPriceManager{
-		priceProviderCfgs: providerCfgs,
+		priceProviderCfgs: cfg.PriceProviders,
 		networkHttp:       networkHttp,
+		db:                db,
 		cache:             &sync.Map{},
 		updateFrequency:   UpdateFrequency,
-		tokens:            tokens,
+		maxPriceAge:       MaxPriceAge,
+		tokens:            cfg.Tokens,
+		oracleCfg:         cfg.PriceOracle,
+		circuits:          &sync.Map{},
+		refreshErrs:       &sync.Map{},
 		providers:         providers,
+		pushedPrices:      make(map[string]*big.Int),
 	}
 }
 
-func (m *defaultTokenPriceManager) getTokenPrices(id string) (*big.Int, error) {
-	token, ok := m.tokens[id]
-	if !ok {
-		return nil, fmt.Errorf("Token %s not supported", id)
-	}
-
+// getProviderPrices gets the prices of the tokens from all providers in parallel. The result is
+// keyed by provider name, then by token symbol.
+func (m *defaultTokenPriceManager) getProviderPrices(tokens []config.Token) map[string]map[string]*big.Int {
 	priceMap := &sync.Map{}
 	wg := &sync.WaitGroup{}
-	// TODO: Run each provider with a timeout.
 	for name, provider := range m.providers {
 		wg.Add(1)
-		go func(name string, provider Provider) {
+		go func(name string, provider *providerWrapper) {
 			defer wg.Done()
 
-			price, err := provider.GetPrice(token)
-			if err != nil {
-				log.Errorf("Failed to get token price for provider %s, err = %s", name, err)
-				return
-			}
-
-			priceMap.Store(name, price)
+			priceMap.Store(name, provider.GetPrices(tokens))
 		}(name, provider)
 	}
 	wg.Wait()
 
 	// Accumulate prices
-	prices := make([]*big.Int, 0)
-	priceMap.Range(func(key, value interface{}) bool { // name, price
-		prices = append(prices, value.(*big.Int))
+	providerPrices := make(map[string]map[string]*big.Int)
+	priceMap.Range(func(key, value interface{}) bool { // name, prices
+		providerPrices[key.(string)] = value.(map[string]*big.Int)
 
 		return true
 	})
 
+	return providerPrices
+}
+
+// checkTokenPrice returns the price of a token if the prices of the providers pass the checks of
+// the oracle. The change of the price is checked versus the last price if it is not nil.
+func (m *defaultTokenPriceManager) checkTokenPrice(id string, token config.Token,
+	providerPrices map[string]map[string]*big.Int, last *big.Int) (*big.Int, error) {
+	prices := make(map[string]*big.Int)
+	for name, tokenPrices := range providerPrices {
+		if price, ok := tokenPrices[token.Symbol]; ok {
+			prices[name] = price
+		}
+	}
+
 	if len(prices) == 0 {
 		return nil, fmt.Errorf("Cannot find price from any provider for token %s", id)
 	}
 
-	// Sort all prices
-	sort.Slice(prices, func(i, j int) bool {
-		return prices[i].Cmp(prices[j]) < 0
-	})
+	return checkPrices(m.oracleCfg, id, prices, last)
+}
+
+// getLastPrice returns the last accepted price of a token, whatever its age. The median of the
+// providers is compared with it.
+func (m *defaultTokenPriceManager) getLastPrice(id string) *big.Int {
+	if cache := m.getCache(id); cache != nil {
+		return cache.price
+	}
 
-	// Get the median
-	return prices[len(prices)/2], nil
+	return nil
 }
 
-func (m *defaultTokenPriceManager) GetPrice(id string) (*big.Int, error) {
-	if TestTokenPrices[id] != nil {
-		return TestTokenPrices[id], nil
+// updateCircuit opens the circuit of a token when its prices fail the spread or deviation check.
+// No price of the token is published while its circuit is open. The circuit is closed when a new
+// price passes all the checks in a refresh or when it is reset. A price that moved more than the
+// max change keeps the circuit open until it is reset.
+func (m *defaultTokenPriceManager) updateCircuit(id string, err error) {
+	if err == nil {
+		m.circuits.Delete(id)
+		return
 	}
 
-	value, ok := m.cache.Load(id)
-	if ok {
-		// check expiration time
-		now := time.Now()
-		cache, ok := value.(*priceCache)
-		if ok {
-			if cache.updateTime+m.updateFrequency > now.UnixMilli() {
-				return cache.price, nil
-			}
-		}
+	if IsPriceErrCode(err, PriceErrSpread) || IsPriceErrCode(err, PriceErrDeviation) {
+		m.circuits.Store(id, err)
 	}
+}
 
-	// Load from server.
-	price, err := m.getTokenPrices(id)
+func (m *defaultTokenPriceManager) getCircuit(id string) error {
+	value, ok := m.circuits.Load(id)
+	if !ok {
+		return nil
+	}
+
+	return value.(error)
+}
+
+func (m *defaultTokenPriceManager) Start(priceUpdateCh chan []*types.TokenPrice) {
+	m.priceUpdateCh = priceUpdateCh
+	m.loadPrices()
+
+	go m.loopRefresh()
+}
+
+// loadPrices loads the last good prices saved in the db into the cache.
+func (m *defaultTokenPriceManager) loadPrices() {
+	prices, err := m.db.GetTokenPrices()
+	if err != nil {
+		log.Errorf("Failed to load token prices from the db, err = %v", err)
+		return
+	}
+
+	for _, price := range prices {
+		m.cache.Store(price.Id, &priceCache{
+			id:         price.Id,
+			price:      price.Price,
+			updateTime: price.UpdateTime,
+		})
+	}
+}
+
+func (m *defaultTokenPriceManager) loopRefresh() {
+	for {
+		m.refresh()
+		time.Sleep(time.Duration(m.updateFrequency) * time.Millisecond)
+	}
+}
+
+// refresh updates the prices of all tokens, saves them in the db and sends the prices that changed
+// to Sisu. The previous price of a token is kept if all providers fail.
+func (m *defaultTokenPriceManager) refresh() {
+	tokens := make([]config.Token, 0, len(m.tokens))
+	for _, token := range m.tokens {
+		tokens = append(tokens, token)
+	}
+	providerPrices := m.getProviderPrices(tokens)
+
+	now := time.Now().UnixMilli()
+	updated := make([]*types.TokenPrice, 0, len(m.tokens))
+	for id, token := range m.tokens {
+		price, err := m.checkTokenPrice(id, token, providerPrices, m.getLastPrice(id))
+		m.updateCircuit(id, err)
+		if err != nil {
+			log.Warnf("Failed to refresh price of token %s, err = %v", id, err)
+			m.refreshErrs.Store(id, err)
+			continue
+		}
+		m.refreshErrs.Delete(id)
 
-	if err == nil {
-		// Save into the cache
 		m.cache.Store(id, &priceCache{
 			id:         id,
 			price:      price,
-			updateTime: time.Now().UnixMilli(),
+			updateTime: now,
+		})
+		updated = append(updated, &types.TokenPrice{
+			Id:         id,
+			PublicId:   token.Symbol,
+			Price:      price,
+			UpdateTime: now,
 		})
 	}
 
-	return price, err
+	if len(updated) == 0 {
+		return
+	}
+
+	if err := m.db.SaveTokenPrices(updated); err != nil {
+		log.Errorf("Failed to save token prices, err = %v", err)
+	}
+
+	changed := make([]*types.TokenPrice, 0, len(updated))
+	for _, price := range updated {
+		pushed := m.pushedPrices[price.Id]
+		if pushed == nil || pushed.Cmp(price.Price) != 0 {
+			changed = append(changed, price)
+			m.pushedPrices[price.Id] = price.Price
+		}
+	}
+
+	if len(changed) > 0 && m.priceUpdateCh != nil {
+		m.priceUpdateCh <- changed
+	}
+}
+
+func (m *defaultTokenPriceManager) getCache(id string) *priceCache {
+	value, ok := m.cache.Load(id)
+	if !ok {
+		return nil
+	}
+
+	cache, _ := value.(*priceCache)
+	return cache
+}
+
+// GetPrice returns the cached price of a token. Prices are only fetched by the refresh loop so that
+// they are checked, saved and sent to Sisu in one place. A price that failed to refresh is returned
+// while it is not older than the max price age.
+func (m *defaultTokenPriceManager) GetPrice(id string) (*big.Int, error) {
+	if TestTokenPrices[id] != nil {
+		return TestTokenPrices[id], nil
+	}
+
+	if _, ok := m.tokens[id]; !ok {
+		return nil, fmt.Errorf("Token %s not supported", id)
+	}
+
+	if circuitErr := m.getCircuit(id); circuitErr != nil {
+		return nil, circuitErr
+	}
+
+	now := time.Now().UnixMilli()
+	cache := m.getCache(id)
+	if cache != nil && cache.updateTime+m.maxPriceAge > now {
+		if cache.updateTime+m.updateFrequency <= now {
+			log.Warnf("Using stale price of token %s updated at %d", id, cache.updateTime)
+		}
+
+		return cache.price, nil
+	}
+
+	if value, ok := m.refreshErrs.Load(id); ok {
+		return nil, value.(error)
+	}
+
+	if cache != nil {
+		return nil, fmt.Errorf("Price of token %s is older than the max price age", id)
+	}
+
+	return nil, fmt.Errorf("Price of token %s is not available yet", id)
+}
+
+// ResetCircuit closes the circuit of a token with a new reference price. It is used when the price
+// of a token really moved more than the max change, which keeps the circuit open. The new price
+// must still pass the other checks of the oracle.
+func (m *defaultTokenPriceManager) ResetCircuit(id string, caller string) (*big.Int, error) {
+	token, ok := m.tokens[id]
+	if !ok {
+		return nil, fmt.Errorf("Token %s not supported", id)
+	}
+
+	price, err := m.checkTokenPrice(id, token, m.getProviderPrices([]config.Token{token}), nil)
+	if err != nil {
+		log.Warnf("Failed to reset circuit of token %s requested by %s, err = %v", id, caller, err)
+		return nil, err
+	}
+
+	oldPrice := m.getLastPrice(id)
+	now := time.Now().UnixMilli()
+	m.cache.Store(id, &priceCache{
+		id:         id,
+		price:      price,
+		updateTime: now,
+	})
+	m.circuits.Delete(id)
+
+	// The price is sent to Sisu by the next refresh.
+	err = m.db.SaveTokenPrices([]*types.TokenPrice{{Id: id, PublicId: token.Symbol, Price: price, UpdateTime: now}})
+	if err != nil {
+		log.Errorf("Failed to save token price, err = %v", err)
+	}
+
+	log.Warnf("Circuit of token %s is reset by %s, old price = %v, new price = %s", id, caller, oldPrice, price)
+
+	return price, nil
 }
@@ -1,6 +1,7 @@
 package oracle
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -60,7 +61,7 @@ func TestTokenPriceManager(t *testing.T) {
 		},
 	}
 
-	tpm := NewTokenPriceManager(providerCfgs, tokens, network.NewHttp())
+	tpm := NewTokenPriceManager(&config.Deyes{PriceProviders: providerCfgs, Tokens: tokens}, network.NewHttp(), nil)
 	price, err := tpm.GetPrice("MATIC")
 	require.Nil(t, err)
 
@@ -74,7 +75,7 @@ func TestCoinCapProvider(t *testing.T) {
 		Url:     "https://api.coincap.io/v2/assets",
 		Secrets: os.Getenv("COIN_CAP_SECRET"),
 	})
-	price, err := p.GetPrice(config.Token{CoincapName: "avalanche"})
+	price, err := p.GetPrice(context.Background(), config.Token{CoincapName: "avalanche"})
 	require.Nil(t, err)
 
 	log.Infof("Price = %s", price)
@@ -86,7 +87,7 @@ func TestCoingeckoProvider(t *testing.T) {
 	p := NewCoingeckoProvider(network.NewHttp(), config.PriceProvider{
 		Url: "https://api.coingecko.com/api/v3/simple/price",
 	})
-	price, err := p.GetPrice(config.Token{CoinGeckoName: "matic-network"})
+	price, err := p.GetPrice(context.Background(), config.Token{CoinGeckoName: "matic-network"})
 	require.Nil(t, err)
 
 	log.Infof("Price = %s", price)
@@ -101,7 +102,7 @@ func TestCoinMarketCap(t *testing.T) {
 		Secrets: os.Getenv("COIN_MARKET_CAP_SECRET"),
 	})
 
-	price, err := p.GetPrice(config.Token{Symbol: "MATIC"})
+	price, err := p.GetPrice(context.Background(), config.Token{Symbol: "MATIC"})
 	require.Nil(t, err)
 
 	log.Infof("Price = %s", price)
@@ -0,0 +1,210 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/database"
+	"github.com/sisu-network/deyes/types"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	prices map[string]*big.Int
+	err    error
+}
+
+func (p *mockProvider) GetPrice(ctx context.Context, token config.Token) (*big.Int, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	price, ok := p.prices[token.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("token %s not found", token.Symbol)
+	}
+
+	return price, nil
+}
+
+func newTestPriceManager(t *testing.T, provider Provider) (*defaultTokenPriceManager, database.Database) {
+	db := database.NewDb(&config.Deyes{InMemory: true, DbHost: "localhost"})
+	require.Nil(t, db.Init())
+
+	tokens := map[string]config.Token{
+		"ETH": {Symbol: "ETH"},
+		"ADA": {Symbol: "ADA"},
+	}
+	m := NewTokenPriceManager(&config.Deyes{Tokens: tokens}, nil, db).(*defaultTokenPriceManager)
+	setTestProviders(m, map[string]Provider{"mock": provider})
+
+	return m, db
+}
+
+func setTestProviders(m *defaultTokenPriceManager, providers map[string]Provider) {
+	m.providers = make(map[string]*providerWrapper)
+	for name, provider := range providers {
+		m.providers[name] = newProviderWrapper(name, provider, config.PriceProvider{})
+	}
+}
+
+func TestTokenPriceManager_Refresh(t *testing.T) {
+	provider := &mockProvider{
+		prices: map[string]*big.Int{
+			"ETH": big.NewInt(1800),
+			"ADA": big.NewInt(3),
+		},
+	}
+	m, db := newTestPriceManager(t, provider)
+	priceUpdateCh := make(chan []*types.TokenPrice, 10)
+	m.priceUpdateCh = priceUpdateCh
+
+	// All prices are sent the first time.
+	m.refresh()
+	require.Equal(t, 2, len(<-priceUpdateCh))
+
+	saved, err := db.GetTokenPrices()
+	require.Nil(t, err)
+	require.Equal(t, 2, len(saved))
+
+	// Only the price that changed is sent. A token whose providers fail keeps its previous price.
+	provider.prices = map[string]*big.Int{"ETH": big.NewInt(1900)}
+	m.refresh()
+	changed := <-priceUpdateCh
+	require.Equal(t, 1, len(changed))
+	require.Equal(t, "ETH", changed[0].Id)
+	require.Equal(t, big.NewInt(1900), changed[0].Price)
+
+	price, err := m.GetPrice("ADA")
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(3), price)
+
+	// No update is sent when the prices do not change.
+	m.refresh()
+	require.Equal(t, 0, len(priceUpdateCh))
+}
+
+func TestTokenPriceManager_StalePrice(t *testing.T) {
+	provider := &mockProvider{err: fmt.Errorf("provider is down")}
+	m, db := newTestPriceManager(t, provider)
+
+	now := time.Now().UnixMilli()
+	err := db.SaveTokenPrices([]*types.TokenPrice{
+		{Id: "ETH", PublicId: "ETH", Price: big.NewInt(1800), UpdateTime: now - UpdateFrequency - 1000},
+		{Id: "ADA", PublicId: "ADA", Price: big.NewInt(3), UpdateTime: now - MaxPriceAge - 1000},
+	})
+	require.Nil(t, err)
+
+	m.Start(make(chan []*types.TokenPrice, 10))
+
+	// The stale price is served while it is not older than the max price age.
+	price, err := m.GetPrice("ETH")
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(1800), price)
+
+	_, err = m.GetPrice("ADA")
+	require.NotNil(t, err)
+}
+
+func TestTokenPriceManager_Median(t *testing.T) {
+	m, _ := newTestPriceManager(t, nil)
+	setTestProviders(m, map[string]Provider{
+		"p1": &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1700)}},
+		"p2": &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1800)}},
+		"p3": &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(2500)}},
+	})
+
+	// GetPrice does not fetch the prices.
+	_, err := m.GetPrice("ETH")
+	require.NotNil(t, err)
+
+	m.refresh()
+	price, err := m.GetPrice("ETH")
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(1800), price)
+}
+
+func TestTokenPriceManager_Circuit(t *testing.T) {
+	p1 := &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1800)}}
+	p2 := &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1810)}}
+	m, _ := newTestPriceManager(t, nil)
+	setTestProviders(m, map[string]Provider{"p1": p1, "p2": p2})
+	m.oracleCfg = config.PriceOracle{MinProviders: 2, MaxSpread: 0.05, MaxChange: 0.2}
+	priceUpdateCh := make(chan []*types.TokenPrice, 10)
+	m.priceUpdateCh = priceUpdateCh
+
+	m.refresh()
+	require.Equal(t, 1, len(<-priceUpdateCh))
+
+	// The providers disagree. The price is not published and GetPrice returns the error even if
+	// the cached price is recent.
+	p2.prices["ETH"] = big.NewInt(2700)
+	m.refresh()
+	require.Equal(t, 0, len(priceUpdateCh))
+	_, err := m.GetPrice("ETH")
+	require.True(t, IsPriceErrCode(err, PriceErrSpread))
+
+	// GetPrice does not fetch the prices while the circuit is open.
+	p2.prices["ETH"] = big.NewInt(1820)
+	_, err = m.GetPrice("ETH")
+	require.True(t, IsPriceErrCode(err, PriceErrSpread))
+
+	// The circuit is closed when the prices pass the checks again in a refresh.
+	m.refresh()
+	refreshed := <-priceUpdateCh
+	price, err := m.GetPrice("ETH")
+	require.Nil(t, err)
+	require.Equal(t, refreshed[0].Price, price)
+
+	// A provider is down and the cached price is too old: the error of the refresh is returned.
+	p2.err = fmt.Errorf("provider is down")
+	m.refresh()
+	m.cache.Store("ETH", &priceCache{id: "ETH", price: big.NewInt(1820), updateTime: 0})
+	_, err = m.GetPrice("ETH")
+	require.True(t, IsPriceErrCode(err, PriceErrQuorum))
+}
+
+func TestTokenPriceManager_ResetCircuit(t *testing.T) {
+	p1 := &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1800)}}
+	p2 := &mockProvider{prices: map[string]*big.Int{"ETH": big.NewInt(1800)}}
+	m, db := newTestPriceManager(t, nil)
+	setTestProviders(m, map[string]Provider{"p1": p1, "p2": p2})
+	m.oracleCfg = config.PriceOracle{MinProviders: 2, MaxSpread: 0.05, MaxChange: 0.2}
+	m.refresh()
+
+	// The price moves more than the max change. The circuit stays open even after the last price
+	// is older than the max price age.
+	p1.prices["ETH"] = big.NewInt(2500)
+	p2.prices["ETH"] = big.NewInt(2500)
+	m.refresh()
+	m.cache.Store("ETH", &priceCache{id: "ETH", price: big.NewInt(1800), updateTime: 0})
+	m.refresh()
+	_, err := m.GetPrice("ETH")
+	require.True(t, IsPriceErrCode(err, PriceErrDeviation))
+
+	// The providers must still agree to reset the circuit.
+	p2.prices["ETH"] = big.NewInt(3000)
+	_, err = m.ResetCircuit("ETH", "test")
+	require.True(t, IsPriceErrCode(err, PriceErrSpread))
+
+	p2.prices["ETH"] = big.NewInt(2500)
+	price, err := m.ResetCircuit("ETH", "test")
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(2500), price)
+
+	price, err = m.GetPrice("ETH")
+	require.Nil(t, err)
+	require.Equal(t, big.NewInt(2500), price)
+
+	prices, err := db.GetTokenPrices()
+	require.Nil(t, err)
+	require.Len(t, prices, 1)
+	require.Equal(t, big.NewInt(2500), prices[0].Price)
+
+	_, err = m.ResetCircuit("BTC", "test")
+	require.NotNil(t, err)
+}
@@ -4,11 +4,16 @@ import (
 	"fmt"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/sisu-network/deyes/chains"
 	"github.com/sisu-network/deyes/chains/cardano"
+	chaincosmos "github.com/sisu-network/deyes/chains/cosmos"
+	cosmostypes "github.com/sisu-network/deyes/chains/cosmos/types"
 	chainseth "github.com/sisu-network/deyes/chains/eth"
 	chainlisk "github.com/sisu-network/deyes/chains/lisk"
+	chaintron "github.com/sisu-network/deyes/chains/tron"
+	trontypes "github.com/sisu-network/deyes/chains/tron/types"
 
 	"github.com/sisu-network/deyes/chains/solana"
 	chainstypes "github.com/sisu-network/deyes/chains/types"
@@ -22,11 +27,18 @@ import (
 	"github.com/sisu-network/deyes/core/oracle"
 )
 
+var (
+	// PriceRetryDelay is the wait time before sending again the token prices that Sisu failed to
+	// receive.
+	PriceRetryDelay = 30 * time.Second
+)
+
 // This struct handles the logic in deyes.
 // TODO: Make this processor to support multiple chains at the same time.
 type Processor struct {
 	db         database.Database
 	txsCh      chan *types.Txs
+	revertedCh chan *types.RevertedTxs
 	txTrackCh  chan *chainstypes.TrackUpdate
 	chain      string
 	blockTime  int
@@ -38,6 +50,14 @@ type Processor struct {
 	tpm         oracle.TokenPriceManager
 
 	sisuReady atomic.Value
+
+	// Token prices that changed, sent by the token price manager.
+	priceUpdateCh chan []*types.TokenPrice
+	// sisuReadyCh is notified when Sisu becomes ready to send the pending prices.
+	sisuReadyCh chan bool
+	// Token prices that are not sent yet because Sisu is not ready or failed to receive them. It is
+	// only accessed by the goroutine that sends token prices.
+	pendingPrices map[string]*types.TokenPrice
 }
 
 func NewProcessor(
@@ -53,6 +73,9 @@ func NewProcessor(
 		dispatchers: make(map[string]chains.Dispatcher),
 		sisuClient:  sisuClient,
 		tpm:         tpm,
+
+		sisuReadyCh:   make(chan bool, 1),
+		pendingPrices: make(map[string]*types.TokenPrice),
 	}
 }
 
@@ -61,9 +84,14 @@ func (p *Processor) Start() {
 	log.Info("tp.cfg.Chains = ", p.cfg.Chains)
 
 	p.txsCh = make(chan *types.Txs, 1000)
+	p.revertedCh = make(chan *types.RevertedTxs, 1000)
 	p.txTrackCh = make(chan *chainstypes.TrackUpdate, 1000)
+	p.priceUpdateCh = make(chan []*types.TokenPrice, 10)
 
 	go p.listen()
+	go p.loopUpdateTokenPrices()
+
+	p.tpm.Start(p.priceUpdateCh)
 
 	for chain, cfg := range p.cfg.Chains {
 		log.Info("Supported chain and config: ", chain, cfg)
@@ -80,18 +108,31 @@ func (p *Processor) Start() {
 		} else if libchain.IsCardanoChain(chain) {
 			// Cardano chain
 			client := p.getCardanoClient(cfg)
-			watcher = cardano.NewWatcher(cfg, p.db, p.txsCh, p.txTrackCh, client)
-			dispatcher = cardano.NewDispatcher(client)
+			cardanoWatcher := cardano.NewWatcher(cfg, p.db, p.txsCh, p.revertedCh, p.txTrackCh, client)
+			watcher = cardanoWatcher
+			dispatcher = cardano.NewDispatcher(cardanoWatcher)
 
 		} else if libchain.IsSolanaChain(chain) {
 			// Solana
 			watcher = solana.NewWatcher(cfg, p.db, p.txsCh, p.txTrackCh)
-			dispatcher = solana.NewDispatcher(cfg.Rpcs, cfg.Wss)
+			dispatcher = solana.NewDispatcher(cfg, p.txTrackCh)
 
 		} else if libchain.IsLiskChain(chain) {
 			client := chainlisk.NewLiskClient(cfg)
+			client.Start()
+
 			watcher = chainlisk.NewWatcher(p.db, cfg, p.txsCh, p.txTrackCh, client)
-			dispatcher = chainlisk.NewDispatcher(chain, client)
+			dispatcher = chainlisk.NewDispatcher(cfg, client)
+
+		} else if cosmostypes.IsCosmosChain(chain) {
+			client := chaincosmos.NewCosmosClient(cfg)
+			watcher = chaincosmos.NewWatcher(p.db, cfg, p.txsCh, p.txTrackCh, client)
+			dispatcher = chaincosmos.NewDispatcher(chain, client)
+
+		} else if trontypes.IsTronChain(chain) {
+			client := chaintron.NewTronClient(cfg)
+			watcher = chaintron.NewWatcher(p.db, cfg, p.txsCh, p.txTrackCh, client)
+			dispatcher = chaintron.NewDispatcher(chain, client)
 
 		} else {
 			panic(fmt.Errorf("Unknown chain %s", chain))
@@ -106,16 +147,17 @@ func (p *Processor) Start() {
 }
 
 func (p *Processor) getCardanoClient(cfg config.Chain) *cardano.DefaultCardanoClient {
-	var (
-		provider  cardano.Provider
-		submitURL string
-	)
+	var provider cardano.Provider
+
+	network, err := cardano.GetNetwork(cfg)
+	if err != nil {
+		panic(err)
+	}
+	log.Infof("Cardano network for chain %s is %s", cfg.Chain, network)
 
 	if cfg.ClientType == config.ClientTypeBlockFrost && len(cfg.RpcSecret) > 0 {
 		log.Info("Use blockfrost API client")
-		// TODO: Make this configurable
 		provider = cardano.NewBlockfrostProvider(cfg)
-		submitURL = "https://cardano-preprod.blockfrost.io/api/v0" + "/tx/submit"
 	} else if cfg.ClientType == config.ClientTypeSelfHost {
 		log.Info("Use Self-host client")
 		db, err := cardano.ConnectDB(cfg.SyncDB)
@@ -124,14 +166,17 @@ func (p *Processor) getCardanoClient(cfg config.Chain) *cardano.DefaultCardanoCl
 		}
 
 		provider = cardano.NewSyncDBConnector(db)
-		submitURL = cfg.SyncDB.SubmitURL
+	} else if cfg.ClientType == config.ClientTypeOgmios {
+		log.Info("Use Ogmios client")
+		// Transactions are submitted through Ogmios first.
+		provider = cardano.NewOgmiosProvider(cfg)
 	} else {
 		panic(fmt.Errorf("unknown cardano client type: %s", cfg.ClientType))
 	}
 
 	return cardano.NewDefaultCardanoClient(
 		provider,
-		submitURL,
+		cardano.GetSubmitUrls(cfg, network),
 		cfg.RpcSecret, // only used for Blockfrost API
 	)
 }
@@ -146,6 +191,13 @@ func (p *Processor) listen() {
 				log.Warnf("txs: Sisu is not ready")
 			}
 
+		case reverted := <-p.revertedCh:
+			if p.sisuReady.Load() == true {
+				p.sisuClient.PostRevertedTxs(reverted)
+			} else {
+				log.Warnf("reverted txs: Sisu is not ready")
+			}
+
 		case txTrackUpdate := <-p.txTrackCh:
 			log.Verbose("There is a tx to confirm with hash: ", txTrackUpdate.Hash)
 			p.sisuClient.OnTxIncludedInBlock(txTrackUpdate)
@@ -153,6 +205,55 @@ func (p *Processor) listen() {
 	}
 }
 
+// loopUpdateTokenPrices sends the token prices that changed to Sisu. It runs in its own goroutine
+// so that a slow Sisu does not block the transactions of the watchers. Prices that Sisu failed to
+// receive are sent again after PriceRetryDelay.
+func (p *Processor) loopUpdateTokenPrices() {
+	var retryCh <-chan time.Time
+	for {
+		select {
+		case prices := <-p.priceUpdateCh:
+			for _, price := range prices {
+				p.pendingPrices[price.Id] = price
+			}
+
+		case <-p.sisuReadyCh:
+		case <-retryCh:
+		}
+
+		retryCh = nil
+		if !p.updateTokenPrices() {
+			retryCh = time.After(PriceRetryDelay)
+		}
+	}
+}
+
+// updateTokenPrices sends the pending token prices to Sisu. The prices are kept until Sisu is
+// ready. It returns false if Sisu failed to receive the prices.
+func (p *Processor) updateTokenPrices() bool {
+	if len(p.pendingPrices) == 0 {
+		return true
+	}
+
+	if p.sisuReady.Load() != true {
+		log.Warnf("prices: Sisu is not ready")
+		return true
+	}
+
+	pendings := make([]*types.TokenPrice, 0, len(p.pendingPrices))
+	for _, price := range p.pendingPrices {
+		pendings = append(pendings, price)
+	}
+
+	if err := p.sisuClient.UpdateTokenPrices(pendings); err != nil {
+		log.Errorf("Failed to send token prices to Sisu, err = %v", err)
+		return false
+	}
+
+	p.pendingPrices = make(map[string]*types.TokenPrice)
+	return true
+}
+
 func (tp *Processor) SetVault(chain, addr string, token string) {
 	log.Infof("Setting gateway, chain = %s, addr = %s", chain, addr)
 	watcher := tp.GetWatcher(chain)
@@ -209,8 +310,21 @@ func (tp *Processor) GetWatcher(chain string) chains.Watcher {
 
 func (p *Processor) SetSisuReady(isReady bool) {
 	p.sisuReady.Store(isReady)
+
+	if isReady {
+		// Send the prices that were updated before Sisu was ready. A notification that is not
+		// handled yet is enough.
+		select {
+		case p.sisuReadyCh <- true:
+		default:
+		}
+	}
 }
 
 func (tp *Processor) GetTokenPrice(id string) (*big.Int, error) {
 	return tp.tpm.GetPrice(id)
 }
+
+func (tp *Processor) ResetTokenPriceCircuit(id string, caller string) (*big.Int, error) {
+	return tp.tpm.ResetCircuit(id, caller)
+}
@@ -1,8 +1,12 @@
 package core
 
 import (
+	"fmt"
+	"math/big"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/sisu-network/deyes/config"
 	"github.com/sisu-network/deyes/core/oracle"
@@ -41,8 +45,7 @@ func mockForProcessor() (config.Deyes, database.Database, *MockClient, oracle.To
 	networkHttp := network.NewHttp()
 	sisuClient := &MockClient{}
 
-	priceManager := oracle.NewTokenPriceManager(cfg.PriceProviders, make(map[string]config.Token),
-		networkHttp)
+	priceManager := oracle.NewTokenPriceManager(&cfg, networkHttp, db)
 
 	return cfg, db, sisuClient, priceManager
 
@@ -87,3 +90,47 @@ func TestProcessor(t *testing.T) {
 		done.Wait()
 	})
 }
+
+func TestProcessor_UpdateTokenPrices(t *testing.T) {
+	PriceRetryDelay = 10 * time.Millisecond
+	defer func() { PriceRetryDelay = 30 * time.Second }()
+
+	pushCh := make(chan []*types.TokenPrice, 10)
+	failures := int32(1)
+	sisuClient := &MockClient{
+		UpdateTokenPricesFunc: func(prices []*types.TokenPrice) error {
+			pushCh <- prices
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				return fmt.Errorf("sisu is not available")
+			}
+			return nil
+		},
+	}
+
+	processor := NewProcessor(&config.Deyes{}, nil, sisuClient, nil)
+	processor.priceUpdateCh = make(chan []*types.TokenPrice, 10)
+	go processor.loopUpdateTokenPrices()
+
+	// Prices are not sent before Sisu is ready.
+	processor.priceUpdateCh <- []*types.TokenPrice{{Id: "ETH", Price: big.NewInt(1800)}}
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 0, len(pushCh))
+
+	// Setting Sisu ready many times does not block.
+	for i := 0; i < 3; i++ {
+		processor.SetSisuReady(true)
+	}
+
+	// The first push fails and is retried without a new price update.
+	for i := 0; i < 2; i++ {
+		select {
+		case prices := <-pushCh:
+			require.Equal(t, []*types.TokenPrice{{Id: "ETH", Price: big.NewInt(1800)}}, prices)
+		case <-time.After(time.Second):
+			t.Fatal("prices are not sent")
+		}
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 0, len(pushCh))
+}
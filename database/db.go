@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"path/filepath"
 	"sort"
@@ -31,6 +32,18 @@ type Database interface {
 	// Vault address
 	SetVault(chain, address string, token string) error
 	GetVaults(chain string) ([]string, error)
+
+	// Cardano utxos
+	SaveUtxos(chain string, utxos []*types.CardanoUtxo) error
+	GetUtxos(chain, address string) ([]*types.CardanoUtxo, error)
+	ReserveUtxos(chain, txHash string, ids []string, reservedUntil, now int64) error
+	ReleaseUtxos(chain, txHash string) error
+	SetUtxosSpent(chain, txHash string, spent bool) error
+	DeleteUtxos(chain string, ids []string) error
+
+	// Token prices
+	SaveTokenPrices(prices []*types.TokenPrice) error
+	GetTokenPrices() ([]*types.TokenPrice, error)
 }
 
 // A struct for saving txs into database.
@@ -176,8 +189,16 @@ func (d *DefaultDatabase) inMemoryMigration() error {
 		if err != nil {
 			return err
 		}
+
 		query := string(dat)
 
+		// sqlite does not support changing the type of a column. It does not enforce the size of
+		// columns either so the migrations that resize a column are skipped.
+		if strings.Contains(strings.ToUpper(query), " MODIFY ") {
+			log.Verbosef("Skipping migration %s for in-memory db", f)
+			continue
+		}
+
 		_, err = d.db.Exec(query)
 		if err != nil {
 			return err
@@ -304,3 +325,171 @@ func (d *DefaultDatabase) getWatchAddress(chain, typ string) ([]string, error) {
 
 	return ret, nil
 }
+
+// SaveUtxos inserts utxos that are not in the db yet. The reservation of existing utxos is kept.
+func (d *DefaultDatabase) SaveUtxos(chain string, utxos []*types.CardanoUtxo) error {
+	query := "INSERT IGNORE INTO utxo (id, chain, address, tx_hash, tx_index, serialized) VALUES (?, ?, ?, ?, ?, ?)"
+	if d.cfg.InMemory {
+		query = "INSERT INTO utxo (id, chain, address, tx_hash, tx_index, serialized) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT(id, chain) DO NOTHING"
+	}
+
+	for _, utxo := range utxos {
+		_, err := d.db.Exec(query, utxo.Id, chain, utxo.Address, utxo.TxHash, utxo.Index, utxo.Serialized)
+		if err != nil {
+			log.Errorf("Cannot save utxo %s for chain %s, err = %v", utxo.Id, chain, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *DefaultDatabase) GetUtxos(chain, address string) ([]*types.CardanoUtxo, error) {
+	rows, err := d.db.Query("SELECT id, tx_hash, tx_index, serialized, spending_tx, reserved_until, spent FROM utxo WHERE chain=? AND address=?",
+		chain, address)
+	if err != nil {
+		log.Error("Failed to load utxos for chain ", chain, ". Error = ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	ret := make([]*types.CardanoUtxo, 0)
+
+	for rows.Next() {
+		utxo := &types.CardanoUtxo{Chain: chain, Address: address}
+		var spendingTx sql.NullString
+		var reservedUntil sql.NullInt64
+		var spent sql.NullBool
+		err = rows.Scan(&utxo.Id, &utxo.TxHash, &utxo.Index, &utxo.Serialized, &spendingTx, &reservedUntil, &spent)
+		if err != nil {
+			return nil, err
+		}
+
+		utxo.SpendingTx = spendingTx.String
+		utxo.ReservedUntil = reservedUntil.Int64
+		utxo.Spent = spent.Bool
+		ret = append(ret, utxo)
+	}
+
+	return ret, nil
+}
+
+// ReserveUtxos reserves utxos for a transaction until the given time. It fails without reserving
+// any utxo if one of them is spent or reserved by another transaction.
+func (d *DefaultDatabase) ReserveUtxos(chain, txHash string, ids []string, reservedUntil, now int64) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		result, err := tx.Exec("UPDATE utxo SET spending_tx=?, reserved_until=? WHERE chain=? AND id=? AND spent=FALSE AND (spending_tx='' OR spending_tx=? OR reserved_until<=?)",
+			txHash, reservedUntil, chain, id, txHash, now)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		count, err := result.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if count == 0 {
+			tx.Rollback()
+			return fmt.Errorf("utxo %s is not available on chain %s", id, chain)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseUtxos removes the reservation of a transaction that is not spent.
+func (d *DefaultDatabase) ReleaseUtxos(chain, txHash string) error {
+	_, err := d.db.Exec("UPDATE utxo SET spending_tx='', reserved_until=0 WHERE chain=? AND spending_tx=? AND spent=FALSE",
+		chain, txHash)
+	if err != nil {
+		log.Errorf("Cannot release utxos of tx %s on chain %s, err = %v", txHash, chain, err)
+	}
+
+	return err
+}
+
+// SetUtxosSpent marks the utxos reserved by a transaction as spent when the transaction is
+// confirmed, or as unspent when the block of the transaction is rolled back.
+func (d *DefaultDatabase) SetUtxosSpent(chain, txHash string, spent bool) error {
+	_, err := d.db.Exec("UPDATE utxo SET spent=? WHERE chain=? AND spending_tx=?", spent, chain, txHash)
+	if err != nil {
+		log.Errorf("Cannot update utxos of tx %s on chain %s, err = %v", txHash, chain, err)
+	}
+
+	return err
+}
+
+// DeleteUtxos removes utxos from the db.
+func (d *DefaultDatabase) DeleteUtxos(chain string, ids []string) error {
+	for _, id := range ids {
+		_, err := d.db.Exec("DELETE FROM utxo WHERE chain=? AND id=?", chain, id)
+		if err != nil {
+			log.Errorf("Cannot delete utxo %s on chain %s, err = %v", id, chain, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveTokenPrices inserts or updates the last good price of tokens.
+func (d *DefaultDatabase) SaveTokenPrices(prices []*types.TokenPrice) error {
+	query := "INSERT INTO token_price (id, public_id, price, update_time) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE public_id=?, price=?, update_time=?"
+	if d.cfg.InMemory {
+		query = "INSERT INTO token_price (id, public_id, price, update_time) VALUES (?, ?, ?, ?) ON CONFLICT(id) DO UPDATE SET public_id=?, price=?, update_time=?"
+	}
+
+	for _, price := range prices {
+		value := price.Price.String()
+		_, err := d.db.Exec(query, price.Id, price.PublicId, value, price.UpdateTime,
+			price.PublicId, value, price.UpdateTime)
+		if err != nil {
+			log.Errorf("Cannot save price of token %s, err = %v", price.Id, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *DefaultDatabase) GetTokenPrices() ([]*types.TokenPrice, error) {
+	rows, err := d.db.Query("SELECT id, public_id, price, update_time FROM token_price")
+	if err != nil {
+		log.Error("Failed to load token prices, err = ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+	ret := make([]*types.TokenPrice, 0)
+
+	for rows.Next() {
+		var id, publicId, price sql.NullString
+		var updateTime sql.NullInt64
+		if err := rows.Scan(&id, &publicId, &price, &updateTime); err != nil {
+			return nil, err
+		}
+
+		value, ok := new(big.Int).SetString(price.String, 10)
+		if !ok {
+			log.Errorf("Invalid price %s of token %s", price.String, id.String)
+			continue
+		}
+
+		ret = append(ret, &types.TokenPrice{
+			Id:         id.String,
+			PublicId:   publicId.String,
+			Price:      value,
+			UpdateTime: updateTime.Int64,
+		})
+	}
+
+	return ret, nil
+}
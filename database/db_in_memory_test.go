@@ -7,3 +7,11 @@ import (
 func TestInMemory_SetVaults(t *testing.T) {
 	testSetVaults(t, true)
 }
+
+func TestInMemory_UtxoReservation(t *testing.T) {
+	testUtxoReservation(t, true)
+}
+
+func TestInMemory_TokenPrices(t *testing.T) {
+	testTokenPrices(t, true)
+}
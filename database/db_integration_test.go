@@ -24,6 +24,16 @@ func (suite *IntegrationDbSuite) TestSetVaults() {
 	testSetVaults(suite.T(), false)
 }
 
+func (suite *IntegrationDbSuite) TestUtxoReservation() {
+	resetDb()
+	testUtxoReservation(suite.T(), false)
+}
+
+func (suite *IntegrationDbSuite) TestTokenPrices() {
+	resetDb()
+	testTokenPrices(suite.T(), false)
+}
+
 func TestIntegrationSuite(t *testing.T) {
 	// Uncomment this line to run the entire suite.
 	// suite.Run(t, new(IntegrationDbSuite))
@@ -1,9 +1,12 @@
 package database
 
 import (
+	"math/big"
+	"sort"
 	"testing"
 
 	"github.com/sisu-network/deyes/config"
+	"github.com/sisu-network/deyes/types"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,3 +69,89 @@ func testSetVaults(t *testing.T, inMemory bool) {
 	err = db.Close()
 	require.Nil(t, err)
 }
+
+func testUtxoReservation(t *testing.T, inMemory bool) {
+	db := getTestDb(t, inMemory)
+
+	chain := "cardano-testnet"
+	utxos := []*types.CardanoUtxo{
+		{Id: "hash1#0", Address: "vault", TxHash: "hash1", Index: 0, Serialized: []byte{1}},
+		{Id: "hash1#1", Address: "vault", TxHash: "hash1", Index: 1, Serialized: []byte{2}},
+	}
+	require.Nil(t, db.SaveUtxos(chain, utxos))
+	// Saving again does not fail.
+	require.Nil(t, db.SaveUtxos(chain, utxos))
+
+	require.Nil(t, db.ReserveUtxos(chain, "tx1", []string{"hash1#0"}, 200, 100))
+
+	// A reserved utxo cannot be reserved by another transaction before it expires.
+	require.NotNil(t, db.ReserveUtxos(chain, "tx2", []string{"hash1#1", "hash1#0"}, 200, 150))
+	saved, err := db.GetUtxos(chain, "vault")
+	require.Nil(t, err)
+	require.Len(t, saved, 2)
+	for _, utxo := range saved {
+		if utxo.Id == "hash1#0" {
+			require.Equal(t, "tx1", utxo.SpendingTx)
+			require.False(t, utxo.IsAvailable(150))
+			require.True(t, utxo.IsAvailable(200))
+		} else {
+			require.Equal(t, "", utxo.SpendingTx)
+			require.Equal(t, []byte{2}, utxo.Serialized)
+		}
+	}
+
+	// The reservation is released.
+	require.Nil(t, db.ReleaseUtxos(chain, "tx1"))
+	require.Nil(t, db.ReserveUtxos(chain, "tx2", []string{"hash1#1", "hash1#0"}, 300, 150))
+
+	// Spent utxos cannot be reserved again even after the reservation expires.
+	require.Nil(t, db.SetUtxosSpent(chain, "tx2", true))
+	require.NotNil(t, db.ReserveUtxos(chain, "tx3", []string{"hash1#0"}, 500, 400))
+	require.Nil(t, db.ReleaseUtxos(chain, "tx2"))
+	saved, err = db.GetUtxos(chain, "vault")
+	require.Nil(t, err)
+	for _, utxo := range saved {
+		require.True(t, utxo.Spent)
+		require.False(t, utxo.IsAvailable(400))
+	}
+
+	require.Nil(t, db.DeleteUtxos(chain, []string{"hash1#0"}))
+	saved, err = db.GetUtxos(chain, "vault")
+	require.Nil(t, err)
+	require.Len(t, saved, 1)
+	require.Equal(t, "hash1#1", saved[0].Id)
+
+	err = db.Close()
+	require.Nil(t, err)
+}
+
+func testTokenPrices(t *testing.T, inMemory bool) {
+	db := getTestDb(t, inMemory)
+
+	prices, err := db.GetTokenPrices()
+	require.Nil(t, err)
+	require.Empty(t, prices)
+
+	err = db.SaveTokenPrices([]*types.TokenPrice{
+		{Id: "ETH", PublicId: "ETH", Price: big.NewInt(1800), UpdateTime: 100},
+		{Id: "ADA", PublicId: "ADA", Price: big.NewInt(3), UpdateTime: 100},
+	})
+	require.Nil(t, err)
+
+	// Update the price of ETH.
+	err = db.SaveTokenPrices([]*types.TokenPrice{
+		{Id: "ETH", PublicId: "ETH", Price: big.NewInt(1900), UpdateTime: 200},
+	})
+	require.Nil(t, err)
+
+	prices, err = db.GetTokenPrices()
+	require.Nil(t, err)
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Id < prices[j].Id })
+	require.Equal(t, []*types.TokenPrice{
+		{Id: "ADA", PublicId: "ADA", Price: big.NewInt(3), UpdateTime: 100},
+		{Id: "ETH", PublicId: "ETH", Price: big.NewInt(1900), UpdateTime: 200},
+	}, prices)
+
+	err = db.Close()
+	require.Nil(t, err)
+}
@@ -0,0 +1 @@
+ALTER TABLE utxo MODIFY id VARCHAR(128);
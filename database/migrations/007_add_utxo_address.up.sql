@@ -0,0 +1 @@
+ALTER TABLE utxo ADD COLUMN address VARCHAR(256) DEFAULT '';
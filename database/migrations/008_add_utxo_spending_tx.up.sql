@@ -0,0 +1 @@
+ALTER TABLE utxo ADD COLUMN spending_tx VARCHAR(256) DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE utxo DROP COLUMN reserved_until;
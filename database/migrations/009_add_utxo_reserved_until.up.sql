@@ -0,0 +1 @@
+ALTER TABLE utxo ADD COLUMN reserved_until BIGINT DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE utxo ADD COLUMN spent BOOLEAN DEFAULT FALSE;
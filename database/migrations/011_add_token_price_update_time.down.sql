@@ -0,0 +1 @@
+ALTER TABLE token_price DROP COLUMN update_time;
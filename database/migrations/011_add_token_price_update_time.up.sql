@@ -0,0 +1 @@
+ALTER TABLE token_price ADD COLUMN update_time BIGINT DEFAULT 0;
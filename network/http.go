@@ -1,6 +1,7 @@
 package network
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 )
@@ -9,6 +10,30 @@ type Http interface {
 	Get(req *http.Request) ([]byte, error)
 }
 
+// HttpStatusErr is returned when the server responds with an error status code.
+type HttpStatusErr struct {
+	StatusCode int
+	Body       string
+}
+
+func NewHttpStatusErr(statusCode int, body string) error {
+	return &HttpStatusErr{StatusCode: statusCode, Body: body}
+}
+
+func (e *HttpStatusErr) Error() string {
+	return fmt.Sprintf("http status %d, body = %s", e.StatusCode, e.Body)
+}
+
+// GetStatusCode returns the status code of an HttpStatusErr or 0 for other errors.
+func GetStatusCode(err error) int {
+	statusErr, ok := err.(*HttpStatusErr)
+	if !ok {
+		return 0
+	}
+
+	return statusErr.StatusCode
+}
+
 type DefaultHttp struct {
 	client *http.Client
 }
@@ -28,6 +53,13 @@ func (d *DefaultHttp) Get(req *http.Request) ([]byte, error) {
 	defer resp.Body.Close()
 
 	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, NewHttpStatusErr(resp.StatusCode, string(buf))
+	}
 
-	return buf, err
+	return buf, nil
 }
@@ -1,14 +1,20 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/echovl/cardano-go"
+	"github.com/ethereum/go-ethereum/rpc"
 	chainscardano "github.com/sisu-network/deyes/chains/cardano"
 	chainseth "github.com/sisu-network/deyes/chains/eth"
+	chainslisk "github.com/sisu-network/deyes/chains/lisk"
 	deyesethtypes "github.com/sisu-network/deyes/chains/eth/types"
 	chainssolana "github.com/sisu-network/deyes/chains/solana"
+	chainstron "github.com/sisu-network/deyes/chains/tron"
+	trontypes "github.com/sisu-network/deyes/chains/tron/types"
 	"github.com/sisu-network/deyes/core"
 	"github.com/sisu-network/deyes/types"
 
@@ -52,6 +58,13 @@ func (api *ApiHandler) GetTokenPrice(id string) (*big.Int, error) {
 	return api.processor.GetTokenPrice(id)
 }
 
+// ResetTokenPriceCircuit accepts the current price of a token whose circuit is open because its
+// price moved more than the max change of the oracle. The server does not authenticate its
+// callers so its port must not be reachable from the public internet.
+func (api *ApiHandler) ResetTokenPriceCircuit(ctx context.Context, id string) (*big.Int, error) {
+	return api.processor.ResetTokenPriceCircuit(id, rpc.PeerInfoFromContext(ctx).RemoteAddr)
+}
+
 ///// ETH
 
 func (api *ApiHandler) GetNonce(chain string, address string) (int64, error) {
@@ -141,6 +154,56 @@ func (api *ApiHandler) CardanoSubmitTx(chain string, tx *cardano.Tx) (*cardano.H
 	return watcher.SubmitTx(tx)
 }
 
+// CardanoEstimateTx builds an unsigned transaction that sends the outputs from the vault. It
+// selects the vault utxos at maxBlock and computes the fee, the min-UTXO of the outputs and the
+// change.
+func (api *ApiHandler) CardanoEstimateTx(chain string, outputs []*types.CardanoTxOutput, maxBlock uint64) (*types.CardanoEstimateTxResult, error) {
+	if !libchain.IsCardanoChain(chain) {
+		return nil, fmt.Errorf("Invalid Cardano chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainscardano.Watcher)
+
+	return watcher.EstimateTx(outputs, maxBlock)
+}
+
+// CardanoReserveUtxos reserves vault utxos (with id "txHash#index") for a pending transaction.
+// Reserved utxos are not returned by CardanoUtxos until the reservation expires after
+// expirySeconds, the transaction fails or it is released.
+func (api *ApiHandler) CardanoReserveUtxos(chain string, txHash string, utxoIds []string, expirySeconds int64) error {
+	if !libchain.IsCardanoChain(chain) {
+		return fmt.Errorf("Invalid Cardano chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainscardano.Watcher)
+
+	return watcher.ReserveUtxos(txHash, utxoIds, time.Duration(expirySeconds)*time.Second)
+}
+
+// CardanoReleaseUtxos releases the utxos reserved for a transaction that will not be submitted.
+func (api *ApiHandler) CardanoReleaseUtxos(chain string, txHash string) error {
+	if !libchain.IsCardanoChain(chain) {
+		return fmt.Errorf("Invalid Cardano chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainscardano.Watcher)
+
+	return watcher.ReleaseUtxos(txHash)
+}
+
+///// Lisk
+
+// LiskEstimateFee returns the min fee in beddows of a transfer of amount to the recipient.
+func (api *ApiHandler) LiskEstimateFee(chain string, recipient string, amount uint64, data string) (uint64, error) {
+	if !libchain.IsLiskChain(chain) {
+		return 0, fmt.Errorf("Invalid Lisk chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainslisk.Watcher)
+
+	return watcher.EstimateFee(recipient, amount, data)
+}
+
 ///// Solana
 func (api *ApiHandler) SolanaQueryRecentBlock(chain string) (*types.SolanaQueryRecentBlockResult, error) {
 	if !libchain.IsSolanaChain(chain) {
@@ -158,3 +221,55 @@ func (api *ApiHandler) SolanaQueryRecentBlock(chain string) (*types.SolanaQueryR
 		Height: height,
 	}, nil
 }
+
+// SolanaPriorityFee returns the percentiles of recent prioritization fees (micro-lamports per
+// compute unit) for transactions writing to the given accounts. The writable accounts of the
+// bridge program are used if no account is given.
+func (api *ApiHandler) SolanaPriorityFee(chain string, accounts []string) (*types.SolanaPriorityFeeResult, error) {
+	if !libchain.IsSolanaChain(chain) {
+		return nil, fmt.Errorf("Invalid Solana chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainssolana.Watcher)
+	return watcher.GetPriorityFee(accounts)
+}
+
+// SolanaEstimateComputeUnits simulates an unsigned base64 encoded transaction message and returns
+// the compute units it consumes.
+func (api *ApiHandler) SolanaEstimateComputeUnits(chain string, message string) (*types.SolanaComputeUnitsResult, error) {
+	if !libchain.IsSolanaChain(chain) {
+		return nil, fmt.Errorf("Invalid Solana chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainssolana.Watcher)
+	return watcher.EstimateComputeUnits(message)
+}
+
+///// Tron
+
+// TronGasInfo returns the energy and bandwidth prices of a Tron chain and the free resources of the
+// vault.
+func (api *ApiHandler) TronGasInfo(chain string) (*trontypes.GasInfo, error) {
+	if !trontypes.IsTronChain(chain) {
+		return nil, fmt.Errorf("Invalid Tron chain %s", chain)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainstron.Watcher)
+	return watcher.GetGasInfo()
+}
+
+// TronEstimateCost estimates the energy, bandwidth and fee for the vault to transfer an amount of
+// token to a recipient. token is "TRX" or the address of a TRC-20 contract.
+func (api *ApiHandler) TronEstimateCost(chain string, token string, recipient string, amount string) (*trontypes.CostEstimate, error) {
+	if !trontypes.IsTronChain(chain) {
+		return nil, fmt.Errorf("Invalid Tron chain %s", chain)
+	}
+
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid amount %s", amount)
+	}
+
+	watcher := api.processor.GetWatcher(chain).(*chainstron.Watcher)
+	return watcher.EstimateCost(token, recipient, value)
+}
@@ -1,6 +1,43 @@
 package types
 
+// CardanoTxOutput is an output of a transaction to estimate. Amount is the CBOR encoding of a
+// cardano Value.
+type CardanoTxOutput struct {
+	Address string
+	Amount  []byte
+}
+
+// CardanoEstimateTxResult contains an unsigned transaction ready for signing.
+type CardanoEstimateTxResult struct {
+	// CBOR of the unsigned transaction.
+	Tx []byte
+	// Hash of the transaction body, which is the message to sign.
+	TxHash string
+	Fee    uint64
+	// Min ADA of each output, in the order of the requested outputs.
+	MinUtxos []uint64
+}
+
 type SolanaQueryRecentBlockResult struct {
 	Hash   string
 	Height int64
 }
+
+// SolanaPriorityFeeResult contains percentiles of recent prioritization fees in micro-lamports per
+// compute unit.
+type SolanaPriorityFeeResult struct {
+	Min      uint64
+	Low      uint64 // 25th percentile
+	Medium   uint64 // 50th percentile
+	High     uint64 // 75th percentile
+	VeryHigh uint64 // 95th percentile
+	Max      uint64
+}
+
+type SolanaComputeUnitsResult struct {
+	UnitsConsumed uint64
+	// ComputeUnitLimit is the units consumed with a safety margin. It should be used in the
+	// SetComputeUnitLimit instruction.
+	ComputeUnitLimit uint64
+	Logs             []string
+}
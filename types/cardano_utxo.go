@@ -12,6 +12,15 @@ type CardanoTransactionUtxo struct {
 	Address  string                   `json:"Address"`
 	Amount   []providertypes.TxAmount `json:"amount"`
 	Metadata *CardanoTxMetadata       `json:"metadata"`
+
+	// Deposit data decoded from Amount by the watcher.
+	Lovelace string                 `json:"lovelace,omitempty"`
+	Assets   []*providertypes.Asset `json:"assets,omitempty"`
+	// RejectedAssets are native assets that are not in the whitelist of the chain.
+	RejectedAssets []*providertypes.Asset `json:"rejected_assets,omitempty"`
+	// MetadataError is set when the metadata does not follow our convention. The deposit is still
+	// reported so that Sisu can refund it.
+	MetadataError string `json:"metadata_error,omitempty"`
 }
 
 type CardanoTxMetadata struct {
@@ -22,3 +31,26 @@ type CardanoTxMetadata struct {
 	// transaction requires some ADA in it.
 	NativeAda int `json:"native_ada,omitempty"`
 }
+
+// CardanoUtxo is a vault utxo saved in the db. A utxo reserved by a pending transaction cannot be
+// used by another transaction until the reservation expires.
+type CardanoUtxo struct {
+	Id      string
+	Chain   string
+	Address string
+	TxHash  string
+	Index   int
+	// CBOR of the utxo value.
+	Serialized []byte
+
+	// Hash of the transaction that spends the utxo.
+	SpendingTx string
+	// Unix time in seconds when the reservation expires.
+	ReservedUntil int64
+	Spent         bool
+}
+
+// IsAvailable returns true if the utxo is not spent and not reserved at the given time.
+func (u *CardanoUtxo) IsAvailable(now int64) bool {
+	return !u.Spent && (len(u.SpendingTx) == 0 || u.ReservedUntil <= now)
+}
@@ -7,6 +7,9 @@ type DispatchedTxRequest struct {
 
 	// For ETH chains
 	PubKey []byte
+
+	// For Solana. The last block height at which the recent blockhash of the transaction is valid.
+	LastValidBlockHeight int64
 }
 
 type DispatchedTxResult struct {
@@ -14,6 +17,8 @@ type DispatchedTxResult struct {
 	Err     DispatchError // We use int since json RPC cannot marshal error
 	Chain   string
 	TxHash  string
+	// Reason is the error returned by the chain, if any.
+	Reason string
 }
 
 func NewDispatchTxError(request *DispatchedTxRequest, err DispatchError) *DispatchedTxResult {
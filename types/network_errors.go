@@ -9,4 +9,9 @@ const (
 	ErrMarshal
 	ErrSubmitTx
 	ErrNonceNotMatched
+	ErrInsufficientFee
+	ErrTxExpired         // the transaction can no longer be included, e.g. its recent blockhash expired
+	ErrInputsSpent       // the inputs of the transaction are spent or do not exist
+	ErrValueNotConserved // the inputs of the transaction do not equal its outputs and fee
+	ErrInvalidSignature  // the transaction is not signed by its sender
 )
@@ -6,4 +6,6 @@ type TokenPrice struct {
 	Id       string
 	PublicId string
 	Price    *big.Int
+	// UpdateTime is the time in milliseconds when the price was fetched.
+	UpdateTime int64
 }
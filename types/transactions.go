@@ -24,3 +24,13 @@ type Txs struct {
 	BaseFee     *big.Int
 	PriorityFee *big.Int
 }
+
+// List of transactions in a block that has been rolled back. They were reported in a Txs before and
+// are no longer on the chain. It is a separate type from Txs so that they are never taken as new
+// deposits. Cardano only.
+type RevertedTxs struct {
+	Chain     string
+	Block     int64
+	BlockHash string
+	Arr       []*Tx
+}